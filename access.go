@@ -0,0 +1,123 @@
+package expiringmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	accessBufferSize = 4096
+	accessBatchSize  = 64
+)
+
+type access[K comparable] struct {
+	key K
+	at  int64
+}
+
+// readHooks are called with each key read, they are copied on write so a
+// read only loads them.
+type readHooks[K comparable] struct {
+	mutex sync.Mutex
+	fns   atomic.Pointer[[]*func(K)]
+}
+
+// onRead calls fn with every key a read finds until the returned func is
+// called. fn runs with the key's shard locked so it must not call back into
+// the map.
+func (m *ExpiringMap[K, V]) onRead(fn func(key K)) func() {
+	h := m.readHooks
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	var fns []*func(K)
+	if old := h.fns.Load(); old != nil {
+		fns = append(fns, *old...)
+	}
+	hook := &fn
+	fns = append(fns, hook)
+	h.fns.Store(&fns)
+	return func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		var fns []*func(K)
+		for _, f := range *h.fns.Load() {
+			if f != hook {
+				fns = append(fns, f)
+			}
+		}
+		h.fns.Store(&fns)
+	}
+}
+
+// accessed records a read for the eviction policy and the read hooks, it
+// must be called with the shard locked.
+func (m *ExpiringMap[K, V]) accessed(s *shard[K, V], key K, now time.Time) {
+	if fns := m.readHooks.fns.Load(); fns != nil {
+		for _, fn := range *fns {
+			(*fn)(key)
+		}
+	}
+	if s.evictor == nil {
+		return
+	}
+	if m.accesses == nil {
+		s.evictor.access(key, now.UnixNano())
+		return
+	}
+	select {
+	case m.accesses <- access[K]{key, now.UnixNano()}:
+	default:
+		m.stats.droppedAccesses.Add(1)
+	}
+}
+
+func (m *ExpiringMap[K, V]) startAccessBuffer() func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		batch := make([]access[K], 0, accessBatchSize)
+		for {
+			select {
+			case <-stop:
+				return
+			case a := <-m.accesses:
+				batch = append(batch[:0], a)
+			}
+		fill:
+			for len(batch) < accessBatchSize {
+				select {
+				case a := <-m.accesses:
+					batch = append(batch, a)
+				default:
+					break fill
+				}
+			}
+			m.applyAccesses(batch)
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// applyAccesses takes each shard's lock once per batch.
+func (m *ExpiringMap[K, V]) applyAccesses(batch []access[K]) {
+	byShard := make(map[*shard[K, V]][]access[K])
+	for _, a := range batch {
+		s := m.shard(a.key)
+		byShard[s] = append(byShard[s], a)
+	}
+	for s, accesses := range byShard {
+		s.lock()
+		// accesses to keys a resize has moved are dropped with the history
+		if !s.moved {
+			for _, a := range accesses {
+				s.evictor.access(a.key, a.at)
+			}
+		}
+		s.unlock()
+	}
+}
@@ -0,0 +1,51 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBufferedAccess(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, Animal](2),
+		WithBufferedAccess[string, Animal](),
+	)
+	defer m.Close()
+
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	read := time.Now().UnixNano()
+	m.Get("elephant")
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		s := m.shard("elephant")
+		s.mutex.Lock()
+		used := s.evictor.(*lruEvictor[string]).elements["elephant"].Value.(*lruItem[string]).used
+		s.mutex.Unlock()
+		if used >= read {
+			break
+		}
+	}
+
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+	if m.Has("monkey") || !m.Has("elephant") {
+		t.Error("expected buffered reads to reach the eviction policy.")
+	}
+}
+
+func TestBufferedAccessClose(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, Animal](2),
+		WithBufferedAccess[string, Animal](),
+	)
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Close()
+	for i := 0; i < accessBufferSize+10; i++ {
+		m.Get("elephant")
+	}
+	if m.Stats().DroppedAccesses == 0 {
+		t.Error("expected reads to be dropped once the buffer is full.")
+	}
+}
@@ -0,0 +1,81 @@
+package expiringmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReadAllocations(t *testing.T) {
+	strings := New[string, Animal]()
+	strings.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	ints := New[int, Animal]()
+	ints.Set(1, Animal{"elephant"}, time.Now().Add(time.Minute))
+	structs := New[animalKey, Animal]()
+	structs.Set(animalKey{"elephant", 1}, Animal{"elephant"}, time.Now().Add(time.Minute))
+	bounded := New(WithMaxEntries[string, Animal](10))
+	bounded.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+
+	for name, fn := range map[string]func(){
+		"string get":  func() { strings.Get("elephant") },
+		"string miss": func() { strings.Get("monkey") },
+		"string has":  func() { strings.Has("elephant") },
+		"int get":     func() { ints.Get(1) },
+		"int has":     func() { ints.Has(2) },
+		"struct get":  func() { structs.Get(animalKey{"elephant", 1}) },
+		"bounded get": func() { bounded.Get("elephant") },
+		"ttl":         func() { strings.TTL("elephant") },
+	} {
+		if allocs := testing.AllocsPerRun(100, fn); allocs != 0 {
+			t.Errorf("%s: expected no allocations, got %v", name, allocs)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	m := New[string, int]()
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		m.Set(keys[i], i, time.Now().Add(time.Hour))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Get(keys[i&1023])
+			i++
+		}
+	})
+}
+
+func BenchmarkHas(b *testing.B) {
+	m := New[int, int]()
+	for i := 0; i < 1024; i++ {
+		m.Set(i, i, time.Now().Add(time.Hour))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Has(i & 2047)
+			i++
+		}
+	})
+}
+
+func BenchmarkSet(b *testing.B) {
+	m := New[int, int]()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ttl := time.Now().Add(time.Hour)
+		i := 0
+		for pb.Next() {
+			m.Set(i&1023, i, ttl)
+			i++
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package expiringmap
+
+import (
+	"container/list"
+	"time"
+)
+
+// arcEvictor implements Adaptive Replacement Cache for one shard. t1 holds
+// keys seen once and t2 keys seen again, b1 and b2 remember keys recently
+// evicted from each and move the target size p of t1 when they come back.
+// Keys deleted or expired are forgotten, coming back says nothing about the
+// cache being too small.
+type arcEvictor[K comparable] struct {
+	capacity int
+	p        int
+
+	t1, t2, b1, b2 *list.List
+	elements       map[K]*list.Element
+}
+
+type arcItem[K comparable] struct {
+	key  K
+	used int64
+	list *list.List
+}
+
+func newARCEvictor[K comparable](capacity int) *arcEvictor[K] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &arcEvictor[K]{
+		capacity: capacity,
+		t1:       list.New(),
+		t2:       list.New(),
+		b1:       list.New(),
+		b2:       list.New(),
+		elements: make(map[K]*list.Element),
+	}
+}
+
+func (e *arcEvictor[K]) add(key K, ttl time.Time, now int64) {
+	el, ok := e.elements[key]
+	if !ok {
+		e.push(e.t1, key, now)
+		return
+	}
+	item := el.Value.(*arcItem[K])
+	switch item.list {
+	case e.b1:
+		e.p += e.delta(e.b2, e.b1)
+		if e.p > e.capacity {
+			e.p = e.capacity
+		}
+	case e.b2:
+		e.p -= e.delta(e.b1, e.b2)
+		if e.p < 0 {
+			e.p = 0
+		}
+	}
+	item.list.Remove(el)
+	e.push(e.t2, key, now)
+}
+
+func (e *arcEvictor[K]) delta(other, hit *list.List) int {
+	if hit.Len() == 0 || other.Len() <= hit.Len() {
+		return 1
+	}
+	return other.Len() / hit.Len()
+}
+
+func (e *arcEvictor[K]) access(key K, now int64) {
+	el, ok := e.elements[key]
+	if !ok {
+		return
+	}
+	item := el.Value.(*arcItem[K])
+	if item.list == e.t1 || item.list == e.t2 {
+		item.list.Remove(el)
+		e.push(e.t2, key, now)
+	}
+}
+
+// evicted moves key to the ghost list of the list it was in, remove then
+// leaves it there.
+func (e *arcEvictor[K]) evicted(key K) {
+	el, ok := e.elements[key]
+	if !ok {
+		return
+	}
+	item := el.Value.(*arcItem[K])
+	switch item.list {
+	case e.t1:
+		e.t1.Remove(el)
+		e.push(e.b1, key, item.used)
+		e.trim(e.b1)
+	case e.t2:
+		e.t2.Remove(el)
+		e.push(e.b2, key, item.used)
+		e.trim(e.b2)
+	}
+}
+
+func (e *arcEvictor[K]) remove(key K) {
+	el, ok := e.elements[key]
+	if !ok {
+		return
+	}
+	if item := el.Value.(*arcItem[K]); item.list == e.t1 || item.list == e.t2 {
+		item.list.Remove(el)
+		delete(e.elements, key)
+	}
+}
+
+func (e *arcEvictor[K]) trim(ghosts *list.List) {
+	for ghosts.Len() > e.capacity {
+		el := ghosts.Back()
+		ghosts.Remove(el)
+		delete(e.elements, el.Value.(*arcItem[K]).key)
+	}
+}
+
+func (e *arcEvictor[K]) push(l *list.List, key K, used int64) {
+	e.elements[key] = l.PushFront(&arcItem[K]{key: key, used: used, list: l})
+}
+
+func (e *arcEvictor[K]) victim() (K, int64, bool) {
+	from := e.t2
+	if e.t1.Len() > 0 && (e.t1.Len() > e.p || e.t2.Len() == 0) {
+		from = e.t1
+	}
+	el := from.Back()
+	if el == nil {
+		return *new(K), 0, false
+	}
+	item := el.Value.(*arcItem[K])
+	return item.key, item.used, true
+}
@@ -0,0 +1,74 @@
+package expiringmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestARCEvictor(t *testing.T) {
+	e := newARCEvictor[string](2)
+	e.add("elephant", NoExpiry, 1)
+	e.add("monkey", NoExpiry, 2)
+	e.access("elephant", 3)
+
+	if key, _, _ := e.victim(); key != "monkey" {
+		t.Errorf("expected keys seen once to go first, got %s", key)
+	}
+	e.evicted("monkey")
+	e.remove("monkey")
+	e.add("tiger", NoExpiry, 4)
+	if key, _, _ := e.victim(); key != "tiger" {
+		t.Errorf("expected tiger, got %s", key)
+	}
+
+	e.evicted("tiger")
+	e.remove("tiger")
+	e.add("tiger", NoExpiry, 5)
+	if e.p != 1 {
+		t.Errorf("a ghost hit in b1 should grow p, got %d", e.p)
+	}
+	if key, _, _ := e.victim(); key != "elephant" {
+		t.Errorf("expected the older frequent key to go once t1 is under p, got %s", key)
+	}
+
+	e.remove("tiger")
+	if _, ok := e.elements["tiger"]; ok || e.b2.Len() != 0 {
+		t.Error("a deleted key shouldn't be remembered as a ghost")
+	}
+	e.add("tiger", NoExpiry, 6)
+	if e.p != 1 {
+		t.Errorf("a deleted key coming back shouldn't move p, got %d", e.p)
+	}
+}
+
+func TestEvictARC(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, int](64),
+		WithEvictionPolicy[string, int](EvictARC),
+	)
+	hot := make([]string, 32)
+	for i := range hot {
+		hot[i] = "hot" + strconv.Itoa(i)
+		m.Set(hot[i], i, time.Now().Add(time.Minute))
+	}
+	for i := 0; i < 1000; i++ {
+		for _, key := range hot {
+			m.Get(key)
+		}
+		key := "scan" + strconv.Itoa(i)
+		m.Set(key, i, time.Now().Add(time.Minute))
+	}
+	if m.Len() > 64 {
+		t.Errorf("expected at most 64 entries, got %d", m.Len())
+	}
+	kept := 0
+	for _, key := range hot {
+		if m.Has(key) {
+			kept += 1
+		}
+	}
+	if kept < 24 {
+		t.Errorf("expected frequently read keys to survive a scan, kept %d", kept)
+	}
+}
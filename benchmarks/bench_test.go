@@ -0,0 +1,113 @@
+package benchmarks
+
+import (
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	seed     = 42
+	keyCount = 1 << 16
+	capacity = 1 << 15
+)
+
+var keys = func() []string {
+	keys := make([]string, keyCount)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+	return keys
+}()
+
+// workload returns the keys read or written in order, skewed towards low
+// indexes like a typical cache.
+func workload(n int) []string {
+	r := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(r, 1.1, 1, keyCount-1)
+	out := make([]string, n)
+	for i := range out {
+		out[i] = keys[zipf.Uint64()]
+	}
+	return out
+}
+
+var trace = workload(1 << 20)
+
+func run(b *testing.B, fn func(c Cache, next func() string)) {
+	for _, factory := range Factories {
+		b.Run(factory.Name, func(b *testing.B) {
+			c := factory.New(capacity)
+			defer c.Close()
+			for i := 0; i < capacity; i++ {
+				c.Set(trace[i], i, time.Hour)
+			}
+			var cursor atomic.Uint64
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				offset := int(cursor.Add(1<<12)) & (len(trace) - 1)
+				i := 0
+				next := func() string {
+					i++
+					return trace[(offset+i)&(len(trace)-1)]
+				}
+				for pb.Next() {
+					fn(c, next)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	run(b, func(c Cache, next func() string) {
+		c.Set(next(), 1, time.Hour)
+	})
+}
+
+func BenchmarkGet(b *testing.B) {
+	run(b, func(c Cache, next func() string) {
+		c.Get(next())
+	})
+}
+
+// BenchmarkMixed reads nine times for every write.
+func BenchmarkMixed(b *testing.B) {
+	var n atomic.Uint64
+	run(b, func(c Cache, next func() string) {
+		if n.Add(1)%10 == 0 {
+			c.Set(next(), 1, time.Hour)
+		} else {
+			c.Get(next())
+		}
+	})
+}
+
+// BenchmarkReadHeavy reads 99 times for every write, the workload
+// WithReadOptimizedLocking is meant for.
+func BenchmarkReadHeavy(b *testing.B) {
+	var n atomic.Uint64
+	run(b, func(c Cache, next func() string) {
+		if n.Add(1)%100 == 0 {
+			c.Set(next(), 1, time.Hour)
+		} else {
+			c.Get(next())
+		}
+	})
+}
+
+// BenchmarkExpiryHeavy writes entries that expire almost immediately, so most
+// reads find expired entries.
+func BenchmarkExpiryHeavy(b *testing.B) {
+	var n atomic.Uint64
+	run(b, func(c Cache, next func() string) {
+		if n.Add(1)%2 == 0 {
+			c.Set(next(), 1, time.Millisecond)
+		} else {
+			c.Get(next())
+		}
+	})
+}
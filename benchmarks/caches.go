@@ -0,0 +1,152 @@
+package benchmarks
+
+import (
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/dgraph-io/ristretto"
+	"github.com/jellydator/ttlcache/v3"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// Cache is the common surface the workloads exercise.
+type Cache interface {
+	Set(key string, value int, ttl time.Duration)
+	Get(key string) (int, bool)
+	Close()
+}
+
+type Factory struct {
+	Name string
+	New  func(capacity int) Cache
+}
+
+var Factories = []Factory{
+	{"expiringmap", newExpiringMap},
+	{"expiringmap-buffered", newBufferedExpiringMap},
+	{"expiringmap-rwlock", newReadLockingExpiringMap},
+	{"go-cache", newGoCache},
+	{"ttlcache", newTTLCache},
+	{"ristretto", newRistretto},
+}
+
+type expiringMap struct {
+	m expiringmap.ExpiringMap[string, int]
+}
+
+func newExpiringMap(capacity int) Cache {
+	return &expiringMap{m: expiringmap.New(expiringmap.WithMaxEntries[string, int](capacity))}
+}
+
+// newBufferedExpiringMap is the baseline for newReadLockingExpiringMap, which
+// needs buffered access to share shard locks when bounded.
+func newBufferedExpiringMap(capacity int) Cache {
+	return &expiringMap{m: expiringmap.New(
+		expiringmap.WithMaxEntries[string, int](capacity),
+		expiringmap.WithBufferedAccess[string, int](),
+	)}
+}
+
+func newReadLockingExpiringMap(capacity int) Cache {
+	return &expiringMap{m: expiringmap.New(
+		expiringmap.WithMaxEntries[string, int](capacity),
+		expiringmap.WithBufferedAccess[string, int](),
+		expiringmap.WithReadOptimizedLocking[string, int](),
+	)}
+}
+
+func (c *expiringMap) Set(key string, value int, ttl time.Duration) {
+	c.m.Set(key, value, time.Now().Add(ttl))
+}
+
+func (c *expiringMap) Get(key string) (int, bool) {
+	return c.m.Get(key)
+}
+
+func (c *expiringMap) Close() {
+	c.m.Close()
+}
+
+// go-cache has no capacity bound.
+type goCache struct {
+	c *gocache.Cache
+}
+
+func newGoCache(capacity int) Cache {
+	return &goCache{c: gocache.New(time.Minute, time.Minute)}
+}
+
+func (c *goCache) Set(key string, value int, ttl time.Duration) {
+	c.c.Set(key, value, ttl)
+}
+
+func (c *goCache) Get(key string) (int, bool) {
+	value, ok := c.c.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int), true
+}
+
+func (c *goCache) Close() {}
+
+type ttlCache struct {
+	c *ttlcache.Cache[string, int]
+}
+
+func newTTLCache(capacity int) Cache {
+	c := ttlcache.New(
+		ttlcache.WithCapacity[string, int](uint64(capacity)),
+		ttlcache.WithDisableTouchOnHit[string, int](),
+	)
+	go c.Start()
+	return &ttlCache{c: c}
+}
+
+func (c *ttlCache) Set(key string, value int, ttl time.Duration) {
+	c.c.Set(key, value, ttl)
+}
+
+func (c *ttlCache) Get(key string) (int, bool) {
+	item := c.c.Get(key)
+	if item == nil || item.IsExpired() {
+		return 0, false
+	}
+	return item.Value(), true
+}
+
+func (c *ttlCache) Close() {
+	c.c.Stop()
+}
+
+type ristrettoCache struct {
+	c *ristretto.Cache
+}
+
+func newRistretto(capacity int) Cache {
+	c, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: int64(capacity) * 10,
+		MaxCost:     int64(capacity),
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return &ristrettoCache{c: c}
+}
+
+func (c *ristrettoCache) Set(key string, value int, ttl time.Duration) {
+	c.c.SetWithTTL(key, value, 1, ttl)
+}
+
+func (c *ristrettoCache) Get(key string) (int, bool) {
+	value, ok := c.c.Get(key)
+	if !ok {
+		return 0, false
+	}
+	return value.(int), true
+}
+
+func (c *ristrettoCache) Close() {
+	c.c.Close()
+}
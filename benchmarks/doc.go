@@ -0,0 +1,12 @@
+// Package benchmarks compares expiringmap with go-cache, ttlcache and
+// ristretto on the same workloads, run with
+//
+//	go test -bench . -benchmem
+//
+// Every workload draws keys from a fixed seed so runs are comparable.
+//
+// The expiringmap-buffered and expiringmap-rwlock variants compare exclusive
+// and shared shard locks for Gets. Shared locks only win once reads dominate
+// and many goroutines hit the same shards, BenchmarkGet and BenchmarkReadHeavy
+// on several cores, otherwise the default mutexes are as fast or faster.
+package benchmarks
@@ -0,0 +1,22 @@
+module github.com/aicacia/go-expiringmap/benchmarks
+
+go 1.20
+
+require (
+	github.com/aicacia/go-expiringmap v0.0.0-00010101000000-000000000000
+	github.com/dgraph-io/ristretto v0.1.1
+	github.com/jellydator/ttlcache/v3 v3.2.0
+	github.com/patrickmn/go-cache v2.1.0+incompatible
+)
+
+require (
+	github.com/aicacia/go-cmap v0.0.0-20240724224630-f18e88ea2705 // indirect
+	github.com/cespare/xxhash/v2 v2.1.1 // indirect
+	github.com/dustin/go-humanize v1.0.0 // indirect
+	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	golang.org/x/sys v0.0.0-20221010170243-090e33056c14 // indirect
+)
+
+replace github.com/aicacia/go-expiringmap => ../
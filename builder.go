@@ -0,0 +1,230 @@
+package expiringmap
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MapBuilder is a fluent alternative to passing options to New, Build checks
+// that the settings make sense together.
+type MapBuilder[K comparable, V any] struct {
+	config     config[K, V]
+	defaultTTL time.Duration
+	policySet  bool
+}
+
+func Builder[K comparable, V any]() *MapBuilder[K, V] {
+	return &MapBuilder[K, V]{}
+}
+
+func (b *MapBuilder[K, V]) MaxEntries(n int) *MapBuilder[K, V] {
+	b.config.maxEntries = n
+	return b
+}
+
+func (b *MapBuilder[K, V]) MaxWeight(max int64, weigher func(key K, value V) int64) *MapBuilder[K, V] {
+	b.config.maxWeight = max
+	b.config.weigher = weigher
+	return b
+}
+
+func (b *MapBuilder[K, V]) EvictionPolicy(policy EvictionPolicy) *MapBuilder[K, V] {
+	b.config.evictionPolicy = policy
+	b.policySet = true
+	return b
+}
+
+func (b *MapBuilder[K, V]) BufferedAccess() *MapBuilder[K, V] {
+	b.config.bufferedAccess = true
+	return b
+}
+
+// DefaultTTL is how long values stored by Put, or loaded without a ttl, live.
+func (b *MapBuilder[K, V]) DefaultTTL(ttl time.Duration) *MapBuilder[K, V] {
+	b.defaultTTL = ttl
+	return b
+}
+
+func (b *MapBuilder[K, V]) TTLProvider(fn func(key K, value V) time.Time) *MapBuilder[K, V] {
+	b.config.ttlProvider = fn
+	return b
+}
+
+// OnRemoved is called with every entry leaving the map, whatever the reason.
+func (b *MapBuilder[K, V]) OnRemoved(fn func(key K, value V, reason RemovalReason)) *MapBuilder[K, V] {
+	b.config.removalListeners = append(b.config.removalListeners, fn)
+	return b
+}
+
+// OnEvicted is called only with entries evicted to make room.
+func (b *MapBuilder[K, V]) OnEvicted(fn func(key K, value V)) *MapBuilder[K, V] {
+	return b.OnRemoved(func(key K, value V, reason RemovalReason) {
+		if reason == RemovalEvicted {
+			fn(key, value)
+		}
+	})
+}
+
+func (b *MapBuilder[K, V]) NearExpiry(fraction float64, fn func(key K, value V, ttl time.Time)) *MapBuilder[K, V] {
+	b.config.nearExpiry = fn
+	b.config.nearExpiryFraction = fraction
+	return b
+}
+
+func (b *MapBuilder[K, V]) InvalidationBus(bus Bus[K]) *MapBuilder[K, V] {
+	b.config.bus = bus
+	return b
+}
+
+func (b *MapBuilder[K, V]) CircuitBreaker(failures int, cooldown time.Duration) *MapBuilder[K, V] {
+	b.config.breakerFailures = failures
+	b.config.breakerCooldown = cooldown
+	return b
+}
+
+func (b *MapBuilder[K, V]) Retry(policy RetryPolicy) *MapBuilder[K, V] {
+	b.config.retry = &policy
+	return b
+}
+
+func (b *MapBuilder[K, V]) WarmLimits(batchSize, parallelism int) *MapBuilder[K, V] {
+	b.config.warmBatchSize = batchSize
+	b.config.warmParallelism = parallelism
+	return b
+}
+
+func (b *MapBuilder[K, V]) ValueCopier(copy func(V) V) *MapBuilder[K, V] {
+	b.config.copier = copy
+	return b
+}
+
+func (b *MapBuilder[K, V]) ReplayBuffer(n int) *MapBuilder[K, V] {
+	b.config.replaySize = n
+	return b
+}
+
+func (b *MapBuilder[K, V]) LastWriteWins(origin string) *MapBuilder[K, V] {
+	b.config.lww = true
+	b.config.origin = origin
+	return b
+}
+
+func (b *MapBuilder[K, V]) Clock(now func() time.Time) *MapBuilder[K, V] {
+	b.config.clock = now
+	return b
+}
+
+func (b *MapBuilder[K, V]) ShardResizing(grow, shrink int) *MapBuilder[K, V] {
+	return b.With(WithShardResizing[K, V](grow, shrink))
+}
+
+func (b *MapBuilder[K, V]) LockSampling(rate int) *MapBuilder[K, V] {
+	return b.With(WithLockSampling[K, V](rate))
+}
+
+func (b *MapBuilder[K, V]) ReadOptimizedLocking() *MapBuilder[K, V] {
+	return b.With(WithReadOptimizedLocking[K, V]())
+}
+
+func (b *MapBuilder[K, V]) ErrorHook(fn func(err error)) *MapBuilder[K, V] {
+	return b.With(WithErrorHook[K, V](fn))
+}
+
+func (b *MapBuilder[K, V]) MutationGuard(onMutation func(key K)) *MapBuilder[K, V] {
+	return b.With(WithMutationGuard[K, V](onMutation))
+}
+
+func (b *MapBuilder[K, V]) LeakDetection(patience time.Duration, onLeak func(err error)) *MapBuilder[K, V] {
+	return b.With(WithLeakDetection[K, V](patience, onLeak))
+}
+
+func (b *MapBuilder[K, V]) CRDT(origin string, tombstoneTTL time.Duration) *MapBuilder[K, V] {
+	return b.With(WithCRDT[K, V](origin, tombstoneTTL))
+}
+
+func (b *MapBuilder[K, V]) Tracer(tracer Tracer) *MapBuilder[K, V] {
+	return b.With(WithTracer[K, V](tracer))
+}
+
+// With applies options the builder has no method for. Build still checks the
+// settings they make.
+func (b *MapBuilder[K, V]) With(options ...Option[K, V]) *MapBuilder[K, V] {
+	for _, option := range options {
+		option(&b.config)
+	}
+	return b
+}
+
+// Build returns the map, or every problem found with the settings joined
+// into one error.
+func (b *MapBuilder[K, V]) Build() (ExpiringMap[K, V], error) {
+	if err := b.validate(); err != nil {
+		return ExpiringMap[K, V]{}, err
+	}
+	c := b.config
+	if ttl := b.defaultTTL; ttl > 0 {
+		now := c.clock
+		if now == nil {
+			now = time.Now
+		}
+		c.ttlProvider = func(K, V) time.Time {
+			return now().Add(ttl)
+		}
+	}
+	return New(func(config *config[K, V]) { *config = c }), nil
+}
+
+func (b *MapBuilder[K, V]) validate() error {
+	var errs []error
+	c := &b.config
+	if c.maxEntries < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: max entries must not be negative, got %d", c.maxEntries))
+	}
+	if c.maxWeight < 0 || (c.maxWeight > 0 && c.weigher == nil) {
+		errs = append(errs, fmt.Errorf("expiringmap: max weight must not be negative and needs a weigher, got %d", c.maxWeight))
+	}
+	if !c.bounded() && b.policySet {
+		errs = append(errs, errors.New("expiringmap: an eviction policy needs max entries or max weight"))
+	}
+	if !c.bounded() && c.bufferedAccess {
+		errs = append(errs, errors.New("expiringmap: buffered access needs max entries or max weight"))
+	}
+	if _, ok := evictionPolicyNames[c.evictionPolicy]; !ok {
+		errs = append(errs, fmt.Errorf("expiringmap: unknown eviction policy %d", c.evictionPolicy))
+	}
+	if b.defaultTTL < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: default ttl must not be negative, got %v", b.defaultTTL))
+	}
+	if b.defaultTTL != 0 && c.ttlProvider != nil {
+		errs = append(errs, errors.New("expiringmap: default ttl and ttl provider are mutually exclusive"))
+	}
+	if c.nearExpiry != nil && (c.nearExpiryFraction <= 0 || c.nearExpiryFraction >= 1) {
+		errs = append(errs, fmt.Errorf("expiringmap: near expiry fraction must be between 0 and 1, got %v", c.nearExpiryFraction))
+	}
+	if c.breakerFailures < 0 || (c.breakerFailures > 0 && c.breakerCooldown <= 0) {
+		errs = append(errs, fmt.Errorf("expiringmap: circuit breaker needs positive failures and cooldown, got %d and %v", c.breakerFailures, c.breakerCooldown))
+	}
+	if c.retry != nil && (c.retry.Attempts < 1 || c.retry.Jitter < 0 || c.retry.Jitter > 1) {
+		errs = append(errs, errors.New("expiringmap: retry needs at least 1 attempt and a jitter between 0 and 1"))
+	}
+	if c.warmBatchSize < 0 || c.warmParallelism < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: warm limits must not be negative, got %d and %d", c.warmBatchSize, c.warmParallelism))
+	}
+	if c.replaySize < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: replay buffer size must not be negative, got %d", c.replaySize))
+	}
+	if c.growAt < 0 || c.shrinkAt < 0 || (c.shrinkAt > 0 && c.shrinkAt >= c.growAt) {
+		errs = append(errs, fmt.Errorf("expiringmap: shard resizing needs shrink below grow, got %d and %d", c.growAt, c.shrinkAt))
+	}
+	if c.lockSampling < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: lock sampling rate must not be negative, got %d", c.lockSampling))
+	}
+	if c.leakPatience < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: leak patience must not be negative, got %v", c.leakPatience))
+	}
+	if c.tombstoneTTL < 0 {
+		errs = append(errs, fmt.Errorf("expiringmap: tombstone ttl must not be negative, got %v", c.tombstoneTTL))
+	}
+	return errors.Join(errs...)
+}
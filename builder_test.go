@@ -0,0 +1,90 @@
+package expiringmap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilder(t *testing.T) {
+	var evicted []string
+	var removed []RemovalReason
+	m, err := Builder[string, int]().
+		MaxEntries(2).
+		EvictionPolicy(EvictLRU).
+		DefaultTTL(time.Minute).
+		OnEvicted(func(key string, _ int) {
+			evicted = append(evicted, key)
+		}).
+		OnRemoved(func(key string, _ int, reason RemovalReason) {
+			removed = append(removed, reason)
+		}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Put("elephant", 1)
+	if ttl, _ := m.TTL("elephant"); ttl.Before(time.Now().Add(59 * time.Second)) {
+		t.Errorf("expected the default ttl, got %v", ttl)
+	}
+	m.Put("monkey", 2)
+	m.Put("tiger", 3)
+	if m.Len() != 2 || len(evicted) != 1 || evicted[0] != "elephant" {
+		t.Errorf("expected elephant to be evicted, got %v", evicted)
+	}
+	m.Delete("monkey")
+	if len(evicted) != 1 || len(removed) != 2 || removed[1] != RemovalDeleted {
+		t.Errorf("expected only the eviction to reach OnEvicted, got %v and %v", evicted, removed)
+	}
+}
+
+func TestBuilderValidation(t *testing.T) {
+	_, err := Builder[string, int]().
+		EvictionPolicy(EvictARC).
+		BufferedAccess().
+		DefaultTTL(time.Minute).
+		TTLProvider(func(string, int) time.Time { return time.Now() }).
+		NearExpiry(1.5, func(string, int, time.Time) {}).
+		CircuitBreaker(3, 0).
+		Build()
+	if err == nil {
+		t.Fatal("expected an error.")
+	}
+	for _, want := range []string{"eviction policy", "buffered access", "mutually exclusive", "near expiry", "circuit breaker"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+	if _, err := Builder[string, int]().MaxEntries(-1).Build(); err == nil {
+		t.Error("expected negative max entries to be rejected.")
+	}
+	if _, err := Builder[string, int]().Retry(RetryPolicy{}).Build(); err == nil {
+		t.Error("expected a retry policy without attempts to be rejected.")
+	}
+	if _, err := Builder[string, int]().ShardResizing(8, 16).Build(); err == nil {
+		t.Error("expected shrinking above grow to be rejected.")
+	}
+	if _, err := Builder[string, int]().With(WithLockSampling[string, int](-1)).Build(); err == nil {
+		t.Error("expected a negative lock sampling rate passed through With to be rejected.")
+	}
+}
+
+func TestBuilderOptions(t *testing.T) {
+	var errs []error
+	m, err := Builder[string, int]().
+		ErrorHook(func(err error) { errs = append(errs, err) }).
+		ReadOptimizedLocking().
+		With(WithRemovalListener(func(string, int, RemovalReason) { panic("boom") })).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.config.readLocking {
+		t.Error("expected read optimized locking")
+	}
+	m.Put("elephant", 1)
+	m.Delete("elephant")
+	if len(errs) != 1 {
+		t.Errorf("expected the listener's panic to reach the error hook, got %v", errs)
+	}
+}
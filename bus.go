@@ -0,0 +1,65 @@
+package expiringmap
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+type Invalidation[K comparable] struct {
+	Origin string     `json:"origin"`
+	Op     MutationOp `json:"op"`
+	Key    K          `json:"key"`
+}
+
+// Bus broadcasts invalidations between maps in different processes. Maps
+// ignore invalidations carrying their own origin.
+type Bus[K comparable] interface {
+	Publish(invalidation Invalidation[K]) error
+	Subscribe(fn func(Invalidation[K])) (unsubscribe func(), err error)
+}
+
+func newOrigin() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// startInvalidationBus reports the errors of bus to the error hook, a map
+// that can't publish or subscribe keeps working but its peers may go stale.
+func (m *ExpiringMap[K, V]) startInvalidationBus(bus Bus[K]) func() {
+	origin := m.config.origin
+	stop := m.streamMutations(false, true, func(mutation Mutation[K, V]) error {
+		// evicting a local copy says nothing about the peers' copies
+		if mutation.remote || mutation.Reason == RemovalEvicted {
+			return nil
+		}
+		switch mutation.Op {
+		case MutationSet, MutationDelete, MutationClear:
+			if err := bus.Publish(Invalidation[K]{Origin: origin, Op: mutation.Op, Key: mutation.Key}); err != nil {
+				m.reportError(fmt.Errorf("expiringmap: publishing invalidation: %w", err))
+			}
+		}
+		return nil
+	})
+	unsubscribe, err := bus.Subscribe(func(invalidation Invalidation[K]) {
+		if invalidation.Origin == origin {
+			return
+		}
+		switch invalidation.Op {
+		case MutationSet, MutationDelete:
+			m.delete(invalidation.Key, true)
+		case MutationClear:
+			m.clear(true)
+		}
+	})
+	if err != nil {
+		m.reportError(fmt.Errorf("expiringmap: subscribing to invalidations: %w", err))
+		unsubscribe = func() {}
+	}
+
+	return func() {
+		unsubscribe()
+		stop()
+	}
+}
@@ -0,0 +1,121 @@
+package expiringmap
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testBus[K comparable] struct {
+	mutex       sync.Mutex
+	subscribers []func(Invalidation[K])
+	published   chan Invalidation[K]
+}
+
+func newTestBus[K comparable]() *testBus[K] {
+	return &testBus[K]{published: make(chan Invalidation[K], 100)}
+}
+
+func (b *testBus[K]) Publish(invalidation Invalidation[K]) error {
+	b.mutex.Lock()
+	subscribers := b.subscribers
+	b.mutex.Unlock()
+	for _, fn := range subscribers {
+		fn(invalidation)
+	}
+	b.published <- invalidation
+	return nil
+}
+
+func (b *testBus[K]) Subscribe(fn func(Invalidation[K])) (func(), error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+	return func() {}, nil
+}
+
+func TestInvalidationBus(t *testing.T) {
+	bus := newTestBus[string]()
+	a := New[string, Animal](WithInvalidationBus[string, Animal](bus))
+	defer a.Close()
+	b := New[string, Animal](WithInvalidationBus[string, Animal](bus))
+	defer b.Close()
+
+	b.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	<-bus.published
+
+	a.Set("elephant", Animal{"big elephant"}, time.Now().Add(time.Minute))
+	invalidation := <-bus.published
+	if invalidation.Op != MutationSet || invalidation.Key != "elephant" {
+		t.Errorf("unexpected invalidation %+v", invalidation)
+	}
+	if b.Has("elephant") {
+		t.Error("peer should have dropped its stale copy.")
+	}
+	if !a.Has("elephant") {
+		t.Error("origin should keep its own value.")
+	}
+
+	select {
+	case invalidation := <-bus.published:
+		t.Errorf("applied invalidations shouldn't be republished, got %+v", invalidation)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestInvalidationBusClose(t *testing.T) {
+	bus := newTestBus[string]()
+	m := New[string, Animal](WithInvalidationBus[string, Animal](bus))
+	m.Close()
+
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	select {
+	case <-bus.published:
+		t.Error("closed map shouldn't publish.")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+type brokenBus[K comparable] struct{}
+
+var errBusDown = errors.New("bus down")
+
+func (brokenBus[K]) Publish(Invalidation[K]) error {
+	return errBusDown
+}
+
+func (brokenBus[K]) Subscribe(func(Invalidation[K])) (func(), error) {
+	return nil, errBusDown
+}
+
+func TestInvalidationBusErrors(t *testing.T) {
+	errs := make(chan error, 10)
+	m := New(
+		WithInvalidationBus[string, Animal](brokenBus[string]{}),
+		WithErrorHook[string, Animal](func(err error) { errs <- err }),
+	)
+	defer m.Close()
+	if err := <-errs; !errors.Is(err, errBusDown) {
+		t.Errorf("expected the subscribe error, got %v", err)
+	}
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errBusDown) {
+			t.Errorf("expected the publish error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the publish error to be reported")
+	}
+}
+
+func TestInvalidationBusOrigin(t *testing.T) {
+	bus := newTestBus[string]()
+	m := New(WithInvalidationBus[string, Animal](bus), WithLastWriteWins[string, Animal]("a"))
+	defer m.Close()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	if invalidation := <-bus.published; invalidation.Origin != "a" {
+		t.Errorf("expected the map's origin, got %q", invalidation.Origin)
+	}
+}
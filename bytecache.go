@@ -0,0 +1,73 @@
+package expiringmap
+
+import "time"
+
+// ByteCache holds byte slices within a budget of total bytes, evicting
+// according to the map's eviction policy once a Set goes over.
+type ByteCache[K comparable] struct {
+	// Copy makes Set store a copy of the slice and Get return one, so callers
+	// are free to reuse or modify their slices.
+	Copy bool
+
+	m ExpiringMap[K, []byte]
+}
+
+// NewByteCache panics unless maxBytes is positive, without a budget the map
+// would not track the bytes held at all.
+func NewByteCache[K comparable](maxBytes int64, options ...Option[K, []byte]) *ByteCache[K] {
+	if maxBytes <= 0 {
+		panic("expiringmap: byte cache budget must be positive")
+	}
+	options = append(options, WithMaxWeight(maxBytes, func(_ K, value []byte) int64 {
+		return int64(len(value))
+	}))
+	return &ByteCache[K]{m: New(options...)}
+}
+
+// Set reports false when value was refused, under EvictNone when it doesn't
+// fit.
+func (c *ByteCache[K]) Set(key K, value []byte, ttl time.Time) bool {
+	if c.Copy {
+		value = append([]byte(nil), value...)
+	}
+	_, stored := c.m.trySet(key, value, ttl)
+	return stored
+}
+
+func (c *ByteCache[K]) Get(key K) ([]byte, bool) {
+	value, ok := c.m.Get(key)
+	if ok && c.Copy {
+		value = append([]byte(nil), value...)
+	}
+	return value, ok
+}
+
+func (c *ByteCache[K]) Has(key K) bool {
+	return c.m.Has(key)
+}
+
+func (c *ByteCache[K]) Delete(key K) bool {
+	return c.m.Delete(key)
+}
+
+func (c *ByteCache[K]) Len() int {
+	return c.m.Len()
+}
+
+// Bytes is the total length of the slices held, including expired ones not
+// yet removed.
+func (c *ByteCache[K]) Bytes() int64 {
+	return c.m.weight.Load()
+}
+
+func (c *ByteCache[K]) Clear() {
+	c.m.Clear()
+}
+
+func (c *ByteCache[K]) Stats() Stats {
+	return c.m.Stats()
+}
+
+func (c *ByteCache[K]) Close() error {
+	return c.m.Close()
+}
@@ -0,0 +1,77 @@
+package expiringmap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestByteCache(t *testing.T) {
+	c := NewByteCache[string](10)
+	ttl := time.Now().Add(time.Minute)
+	c.Set("a", []byte("aaaa"), ttl)
+	c.Set("b", []byte("bbbb"), ttl)
+	if c.Bytes() != 8 {
+		t.Errorf("expected 8 bytes, got %d", c.Bytes())
+	}
+	c.Get("a")
+	c.Set("c", []byte("cccc"), ttl)
+	if c.Bytes() != 8 || c.Has("b") || !c.Has("a") {
+		t.Errorf("expected the least recently used to be evicted, got %d bytes", c.Bytes())
+	}
+	c.Set("a", []byte("a"), ttl)
+	if c.Bytes() != 5 {
+		t.Errorf("expected replacing to adjust the total, got %d", c.Bytes())
+	}
+	c.Delete("a")
+	if c.Bytes() != 4 {
+		t.Errorf("expected deleting to adjust the total, got %d", c.Bytes())
+	}
+	c.Clear()
+	if c.Bytes() != 0 {
+		t.Errorf("expected clearing to reset the total, got %d", c.Bytes())
+	}
+}
+
+func TestByteCacheCopy(t *testing.T) {
+	c := NewByteCache[string](100)
+	c.Copy = true
+	value := []byte("elephant")
+	c.Set("a", value, time.Now().Add(time.Minute))
+	value[0] = 'E'
+	got, _ := c.Get("a")
+	if !bytes.Equal(got, []byte("elephant")) {
+		t.Errorf("expected Set to copy, got %q", got)
+	}
+	got[0] = 'E'
+	if got, _ := c.Get("a"); !bytes.Equal(got, []byte("elephant")) {
+		t.Errorf("expected Get to copy, got %q", got)
+	}
+}
+
+func TestByteCacheNoEviction(t *testing.T) {
+	c := NewByteCache[string](5, WithEvictionPolicy[string, []byte](EvictNone))
+	ttl := time.Now().Add(time.Minute)
+	if !c.Set("a", []byte("aaa"), ttl) {
+		t.Error("expected a to fit.")
+	}
+	if c.Set("b", []byte("bbb"), ttl) {
+		t.Error("expected b not to fit.")
+	}
+	if !c.Set("c", []byte("cc"), ttl) || c.Bytes() != 5 {
+		t.Errorf("expected c to fit, got %d bytes", c.Bytes())
+	}
+}
+
+func TestByteCacheNoBudget(t *testing.T) {
+	for _, maxBytes := range []int64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected NewByteCache(%d) to panic", maxBytes)
+				}
+			}()
+			NewByteCache[string](maxBytes)
+		}()
+	}
+}
@@ -0,0 +1,57 @@
+package expiringmap
+
+import (
+	"strings"
+
+	"github.com/aicacia/go-expiringmap/internal/glob"
+)
+
+// Cache is an ExpiringMap keyed by string with string specific scans. Scans
+// visit every key, they are not backed by an ordered index.
+type Cache[V any] struct {
+	ExpiringMap[string, V]
+}
+
+func NewCache[V any](options ...Option[string, V]) Cache[V] {
+	return Cache[V]{New(options...)}
+}
+
+func (c Cache[V]) RangePrefix(prefix string, f func(key string, value V) bool) {
+	c.RangeWhere(func(key string) bool { return strings.HasPrefix(key, prefix) }, f)
+}
+
+func (c Cache[V]) KeysWithPrefix(prefix string) []string {
+	var keys []string
+	c.RangePrefix(prefix, func(key string, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (c Cache[V]) DeletePrefix(prefix string) int {
+	return c.deleteWhere(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// KeysMatching returns the keys matching a Redis style glob pattern, where *
+// matches any run of characters including /, ? any one character, [...] a
+// character class and \ escapes the next character.
+func (c Cache[V]) KeysMatching(pattern string) ([]string, error) {
+	if err := glob.Validate(pattern); err != nil {
+		return nil, err
+	}
+	var keys []string
+	c.RangeWhere(func(key string) bool { return glob.Match(pattern, key) }, func(key string, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys, nil
+}
+
+// DeleteGlob deletes the keys matching pattern, see KeysMatching.
+func (c Cache[V]) DeleteGlob(pattern string) (int, error) {
+	if err := glob.Validate(pattern); err != nil {
+		return 0, err
+	}
+	return c.deleteWhere(func(key string) bool { return glob.Match(pattern, key) }), nil
+}
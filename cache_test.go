@@ -0,0 +1,74 @@
+package expiringmap
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachePrefix(t *testing.T) {
+	c := NewCache[int]()
+	ttl := time.Now().Add(time.Minute)
+	c.Set("user:1", 1, ttl)
+	c.Set("user:2", 2, ttl)
+	c.Set("session:1", 3, ttl)
+	c.Set("user:3", 4, time.Now().Add(-time.Second))
+
+	keys := c.KeysWithPrefix("user:")
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "user:1" || keys[1] != "user:2" {
+		t.Errorf("expected the live user keys, got %v", keys)
+	}
+	if n := c.DeletePrefix("user:"); n != 2 {
+		t.Errorf("expected 2 deletes, got %d", n)
+	}
+	if c.Len() != 1 || !c.Has("session:1") {
+		t.Error("expected only the session to remain.")
+	}
+}
+
+func TestCacheGlob(t *testing.T) {
+	c := NewCache[int]()
+	ttl := time.Now().Add(time.Minute)
+	for _, key := range []string{"a/b/c", "a/x", "abc", "a?c", "b1", "b2", "bb", "héllo"} {
+		c.Set(key, 0, ttl)
+	}
+	for pattern, want := range map[string]int{
+		"a*":      4,
+		"a/*":     2,
+		"a?c":     2,
+		`a\?c`:    1,
+		"b[0-9]":  2,
+		"b[^0-9]": 1,
+		"h?llo":   1,
+		"*":       8,
+		"z*":      0,
+	} {
+		keys, err := c.KeysMatching(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != want {
+			t.Errorf("expected %d keys to match %q, got %v", want, pattern, keys)
+		}
+	}
+	c.Set(strings.Repeat("a", 64), 0, ttl)
+	start := time.Now()
+	if keys, _ := c.KeysMatching(strings.Repeat("a*", 32) + "b"); len(keys) != 0 {
+		t.Errorf("expected no keys to match, got %v", keys)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("expected repeated stars to match in linear time, took %v", time.Since(start))
+	}
+	c.Delete(strings.Repeat("a", 64))
+	if _, err := c.DeleteGlob("a[b"); err == nil {
+		t.Error("expected a malformed pattern to be rejected.")
+	}
+	if n, err := c.DeleteGlob("b?"); err != nil || n != 3 {
+		t.Errorf("expected 3 deletes, got %d %v", n, err)
+	}
+	if c.Len() != 5 {
+		t.Errorf("expected 5 keys left, got %d", c.Len())
+	}
+}
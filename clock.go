@@ -0,0 +1,86 @@
+package expiringmap
+
+import "time"
+
+// clockEvictor approximates LRU with a reference bit per key. Reads only set
+// the bit, the hand clears bits as it sweeps and evicts the first key found
+// without one. Victims are ranked across shards by when they were added.
+type clockEvictor[K comparable] struct {
+	ring    []*clockItem[K]
+	indexes map[K]*clockItem[K]
+	hand    int
+}
+
+type clockItem[K comparable] struct {
+	key   K
+	added int64
+	ref   bool
+	index int
+}
+
+func newClockEvictor[K comparable]() *clockEvictor[K] {
+	return &clockEvictor[K]{indexes: make(map[K]*clockItem[K])}
+}
+
+func (e *clockEvictor[K]) add(key K, ttl time.Time, now int64) {
+	if item, ok := e.indexes[key]; ok {
+		item.ref = true
+		return
+	}
+	item := &clockItem[K]{key: key, added: now, index: len(e.ring)}
+	e.indexes[key] = item
+	e.ring = append(e.ring, item)
+}
+
+func (e *clockEvictor[K]) access(key K, now int64) {
+	if item, ok := e.indexes[key]; ok {
+		item.ref = true
+	}
+}
+
+func (e *clockEvictor[K]) remove(key K) {
+	item, ok := e.indexes[key]
+	if !ok {
+		return
+	}
+	last := len(e.ring) - 1
+	e.ring[item.index] = e.ring[last]
+	e.ring[item.index].index = item.index
+	e.ring[last] = nil
+	e.ring = e.ring[:last]
+	delete(e.indexes, key)
+	if e.hand >= len(e.ring) {
+		e.hand = 0
+	}
+}
+
+// victim sweeps the hand on to the first key without a bit, clearing the bits
+// it passes, so it is only called on the shard being evicted from.
+func (e *clockEvictor[K]) victim() (K, int64, bool) {
+	if len(e.ring) == 0 {
+		return *new(K), 0, false
+	}
+	for {
+		item := e.ring[e.hand]
+		if !item.ref {
+			return item.key, item.added, true
+		}
+		item.ref = false
+		e.hand = (e.hand + 1) % len(e.ring)
+	}
+}
+
+// peek is the key victim would sweep to, leaving the hand and bits alone.
+func (e *clockEvictor[K]) peek() (K, int64, bool) {
+	if len(e.ring) == 0 {
+		return *new(K), 0, false
+	}
+	for i := range e.ring {
+		if item := e.ring[(e.hand+i)%len(e.ring)]; !item.ref {
+			return item.key, item.added, true
+		}
+	}
+	// every bit is set, the sweep clears them all and comes back round
+	item := e.ring[e.hand]
+	return item.key, item.added, true
+}
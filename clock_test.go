@@ -0,0 +1,65 @@
+package expiringmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClockEvictor(t *testing.T) {
+	e := newClockEvictor[string]()
+	e.add("elephant", NoExpiry, 1)
+	e.add("monkey", NoExpiry, 2)
+	e.add("tiger", NoExpiry, 3)
+	e.access("elephant", 4)
+
+	if key, _, _ := e.victim(); key != "monkey" {
+		t.Errorf("expected referenced keys to get a second chance, got %s", key)
+	}
+	e.remove("monkey")
+	if key, _, _ := e.victim(); key != "tiger" {
+		t.Errorf("expected tiger, got %s", key)
+	}
+	e.remove("tiger")
+	if key, _, _ := e.victim(); key != "elephant" {
+		t.Errorf("expected elephant once its bit was cleared, got %s", key)
+	}
+	e.remove("elephant")
+	if _, _, ok := e.victim(); ok {
+		t.Error("empty clock has no victim.")
+	}
+}
+
+func TestEvictClock(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, int](50),
+		WithEvictionPolicy[string, int](EvictClock),
+	)
+	for i := 0; i < 500; i++ {
+		m.Set(strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	if m.Len() != 50 {
+		t.Errorf("expected 50 entries, got %d", m.Len())
+	}
+}
+
+func TestClockEvictorPeek(t *testing.T) {
+	e := newClockEvictor[string]()
+	e.add("elephant", NoExpiry, 1)
+	e.add("monkey", NoExpiry, 2)
+	e.access("elephant", 3)
+
+	if key, _, _ := e.peek(); key != "monkey" {
+		t.Errorf("expected peek to pass over the referenced key, got %s", key)
+	}
+	if !e.indexes["elephant"].ref || e.hand != 0 {
+		t.Error("expected peek to leave the hand and bits alone.")
+	}
+	e.access("monkey", 4)
+	if key, _, _ := e.peek(); key != "elephant" {
+		t.Errorf("expected the key under the hand once every bit is set, got %s", key)
+	}
+	if key, _, _ := e.victim(); key != "elephant" {
+		t.Errorf("expected the sweep to agree with peek, got %s", key)
+	}
+}
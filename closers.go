@@ -0,0 +1,41 @@
+package expiringmap
+
+import "sync"
+
+type closers struct {
+	mutex sync.Mutex
+	fns   []*func()
+	once  sync.Once
+}
+
+// add registers fn to run on close, the returned func forgets it again.
+func (c *closers) add(fn func()) (remove func()) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	added := &fn
+	c.fns = append(c.fns, added)
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for i, f := range c.fns {
+			if f == added {
+				c.fns = append(c.fns[:i], c.fns[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// close runs the closers, latest first, without holding the mutex so they
+// may remove themselves. Concurrent calls wait for the first to finish.
+func (c *closers) close() {
+	c.once.Do(func() {
+		c.mutex.Lock()
+		fns := c.fns
+		c.fns = nil
+		c.mutex.Unlock()
+		for i := len(fns) - 1; i >= 0; i-- {
+			(*fns[i])()
+		}
+	})
+}
@@ -0,0 +1,303 @@
+// Command expiringmapctl inspects and converts snapshots and mutation logs
+// written by expiringmap.
+//
+//	expiringmapctl keys [-glob pattern] [-expired] file
+//	expiringmapctl ttl file
+//	expiringmapctl diff a b
+//	expiringmapctl convert [-glob pattern] [-format snapshot|jsonl|csv] [-gzip] [-out-key hex] [-o out] file
+//
+// Every command takes -key with the hex AES key of encrypted input, and reads
+// standard input for a file named -. Converting strips expired entries.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/internal/glob"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "expiringmapctl:", err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("usage: expiringmapctl keys|ttl|diff|convert [flags] file...")
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd := &command{stdin: stdin, stdout: stdout, now: time.Now()}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&cmd.key, "key", "", "hex AES key of encrypted input")
+	pattern := fs.String("glob", "*", "only keys matching pattern")
+	expired := fs.Bool("expired", false, "also list expired keys")
+	format := fs.String("format", "snapshot", "output format, snapshot, jsonl or csv")
+	compress := fs.Bool("gzip", false, "compress the output snapshot")
+	outKey := fs.String("out-key", "", "hex AES key to encrypt the output snapshot with")
+	out := fs.String("o", "-", "output file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if err := glob.Validate(*pattern); err != nil {
+		return err
+	}
+	cmd.glob = *pattern
+
+	switch args[0] {
+	case "keys":
+		if fs.NArg() != 1 {
+			return errUsage
+		}
+		return cmd.keys(fs.Arg(0), *expired)
+	case "ttl":
+		if fs.NArg() != 1 {
+			return errUsage
+		}
+		return cmd.ttl(fs.Arg(0))
+	case "diff":
+		if fs.NArg() != 2 {
+			return errUsage
+		}
+		return cmd.diff(fs.Arg(0), fs.Arg(1))
+	case "convert":
+		if fs.NArg() != 1 {
+			return errUsage
+		}
+		var options []expiringmap.SnapshotOption
+		if *compress {
+			options = append(options, expiringmap.WithCompression(expiringmap.Gzip))
+		}
+		if *outKey != "" {
+			key, err := hex.DecodeString(*outKey)
+			if err != nil {
+				return err
+			}
+			options = append(options, expiringmap.WithEncryptionKey(key))
+		}
+		return cmd.convert(fs.Arg(0), *out, *format, options)
+	default:
+		return errUsage
+	}
+}
+
+// key holds a map key as the JSON it was written as, so files are read
+// whatever their key type.
+type key string
+
+func (k key) MarshalJSON() ([]byte, error) {
+	return []byte(k), nil
+}
+
+func (k *key) UnmarshalJSON(data []byte) error {
+	*k = key(data)
+	return nil
+}
+
+// String unquotes string keys.
+func (k key) String() string {
+	var s string
+	if json.Unmarshal([]byte(k), &s) == nil {
+		return s
+	}
+	return string(k)
+}
+
+type mutation = expiringmap.Mutation[key, json.RawMessage]
+
+type command struct {
+	stdin  io.Reader
+	stdout io.Writer
+	now    time.Time
+	key    string
+	glob   string
+}
+
+// load replays the snapshot or log in name, keeping expired entries, and
+// returns the entries matching the glob sorted by key.
+func (c *command) load(name string) ([]mutation, error) {
+	r := c.stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var options []expiringmap.SnapshotOption
+	if c.key != "" {
+		k, err := hex.DecodeString(c.key)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, expiringmap.WithEncryptionKey(k))
+	}
+	sr, err := expiringmap.NewSnapshotReader(r, options...)
+	if err != nil {
+		return nil, err
+	}
+	dec := expiringmap.NewMutationDecoder[key, json.RawMessage](sr)
+	entries := make(map[key]mutation)
+	for {
+		m, err := dec.ReadMutation()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		switch m.Op {
+		case expiringmap.MutationSet:
+			entries[m.Key] = m
+		case expiringmap.MutationDelete, expiringmap.MutationExpired:
+			delete(entries, m.Key)
+		case expiringmap.MutationClear:
+			entries = make(map[key]mutation)
+		}
+	}
+	var matched []mutation
+	for k, m := range entries {
+		if glob.Match(c.glob, k.String()) {
+			matched = append(matched, m)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Key < matched[j].Key })
+	return matched, nil
+}
+
+// live loads name into a map, dropping expired entries.
+func (c *command) live(name string) (*expiringmap.ExpiringMap[key, json.RawMessage], error) {
+	entries, err := c.load(name)
+	if err != nil {
+		return nil, err
+	}
+	m := expiringmap.New[key, json.RawMessage]()
+	for _, e := range entries {
+		if !e.TTL.Before(c.now) {
+			m.Set(e.Key, e.Val, e.TTL)
+		}
+	}
+	return &m, nil
+}
+
+func (c *command) keys(name string, expired bool) error {
+	entries, err := c.load(name)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if !e.TTL.Before(expiringmap.NoExpiry) {
+			fmt.Fprintf(c.stdout, "%s\tnever\n", e.Key)
+		} else if ttl := e.TTL.Sub(c.now); ttl >= 0 {
+			fmt.Fprintf(c.stdout, "%s\t%s\n", e.Key, ttl.Round(time.Second))
+		} else if expired {
+			fmt.Fprintf(c.stdout, "%s\texpired\n", e.Key)
+		}
+	}
+	return nil
+}
+
+var ttlBuckets = []struct {
+	name string
+	max  time.Duration
+}{
+	{"expired", 0},
+	{"<1m", time.Minute},
+	{"<1h", time.Hour},
+	{"<1d", 24 * time.Hour},
+	{">=1d", 1<<63 - 1},
+	{"never", 0},
+}
+
+func (c *command) ttl(name string) error {
+	entries, err := c.load(name)
+	if err != nil {
+		return err
+	}
+	counts := make([]int, len(ttlBuckets))
+	for _, e := range entries {
+		if !e.TTL.Before(expiringmap.NoExpiry) {
+			counts[len(counts)-1]++
+			continue
+		}
+		// a ttl far enough out saturates, so the last timed bucket is <=
+		ttl := e.TTL.Sub(c.now)
+		for i, bucket := range ttlBuckets {
+			if ttl < bucket.max || i == len(ttlBuckets)-2 {
+				counts[i]++
+				break
+			}
+		}
+	}
+	for i, bucket := range ttlBuckets {
+		fmt.Fprintf(c.stdout, "%s\t%d\n", bucket.name, counts[i])
+	}
+	return nil
+}
+
+func (c *command) diff(a, b string) error {
+	ma, err := c.live(a)
+	if err != nil {
+		return err
+	}
+	mb, err := c.live(b)
+	if err != nil {
+		return err
+	}
+	onlyA, onlyB, changed := expiringmap.Diff(ma, mb, func(x, y json.RawMessage) bool { return bytes.Equal(x, y) })
+	for _, diff := range []struct {
+		prefix string
+		keys   []key
+	}{{"-", onlyA}, {"+", onlyB}, {"~", changed}} {
+		sort.Slice(diff.keys, func(i, j int) bool { return diff.keys[i] < diff.keys[j] })
+		for _, k := range diff.keys {
+			fmt.Fprintf(c.stdout, "%s %s\n", diff.prefix, k)
+		}
+	}
+	return nil
+}
+
+func (c *command) convert(name, out, format string, options []expiringmap.SnapshotOption) error {
+	m, err := c.live(name)
+	if err != nil {
+		return err
+	}
+	if out == "-" {
+		return write(m, c.stdout, format, options)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = write(m, f, format, options)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func write(m *expiringmap.ExpiringMap[key, json.RawMessage], w io.Writer, format string, options []expiringmap.SnapshotOption) error {
+	switch format {
+	case "snapshot":
+		return m.WriteSnapshot(w, options...)
+	case "jsonl":
+		return m.ExportJSONL(w)
+	case "csv":
+		return m.DumpCSV(w, func(v json.RawMessage) string { return string(v) })
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
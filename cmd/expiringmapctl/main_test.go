@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+// never marks an entry written with expiringmap.NoExpiry.
+const never = time.Duration(1<<63 - 1)
+
+func writeSnapshot(t *testing.T, name string, entries map[string]time.Duration) string {
+	m := expiringmap.New[string, string]()
+	for k, ttl := range entries {
+		if ttl == never {
+			m.Set(k, "value of "+k, expiringmap.NoExpiry)
+		} else {
+			m.Set(k, "value of "+k, time.Now().Add(ttl))
+		}
+	}
+	var buf bytes.Buffer
+	w, err := expiringmap.NewSnapshotWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := expiringmap.NewMutationEncoder[string, string](w)
+	stop := m.ReplicateTo(enc)
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	// a log can hold expired entries, unlike a snapshot
+	enc.WriteMutation(expiringmap.Mutation[string, string]{Op: expiringmap.MutationSet, Key: "stale", TTL: time.Now().Add(-time.Hour)})
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func ctl(t *testing.T, args ...string) string {
+	var out bytes.Buffer
+	if err := run(args, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestKeys(t *testing.T) {
+	file := writeSnapshot(t, "a", map[string]time.Duration{"user:1": time.Hour, "user:2": time.Minute, "session:1": 48 * time.Hour, "config/site": never})
+	if out := ctl(t, "keys", "-glob", "user:*", file); out != "user:1\t1h0m0s\nuser:2\t1m0s\n" {
+		t.Errorf("unexpected keys %q", out)
+	}
+	if out := ctl(t, "keys", "-expired", file); !strings.Contains(out, "stale\texpired") {
+		t.Errorf("expected the expired key to be listed, got %q", out)
+	}
+	if out := ctl(t, "keys", "-glob", "con*", file); out != "config/site\tnever\n" {
+		t.Errorf("expected * to match / and the key to never expire, got %q", out)
+	}
+	if out := ctl(t, "ttl", file); out != "expired\t1\n<1m\t1\n<1h\t1\n<1d\t0\n>=1d\t1\nnever\t1\n" {
+		t.Errorf("unexpected distribution %q", out)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := writeSnapshot(t, "a", map[string]time.Duration{"fern": time.Hour, "moss": time.Hour})
+	b := writeSnapshot(t, "b", map[string]time.Duration{"fern": time.Hour, "ivy": time.Hour})
+	if out := ctl(t, "diff", a, b); out != "- moss\n+ ivy\n" {
+		t.Errorf("unexpected diff %q", out)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	file := writeSnapshot(t, "a", map[string]time.Duration{"fern": time.Hour})
+	key := strings.Repeat("ab", 16)
+	sealed := filepath.Join(t.TempDir(), "sealed")
+	ctl(t, "convert", "-gzip", "-out-key", key, "-o", sealed, file)
+	if out := ctl(t, "keys", "-expired", "-key", key, sealed); out != "fern\t1h0m0s\n" {
+		t.Errorf("expected conversion to strip expired entries, got %q", out)
+	}
+	if out := ctl(t, "convert", "-format", "csv", file); !strings.HasPrefix(out, "key,expiry,value\nfern,") {
+		t.Errorf("unexpected csv %q", out)
+	}
+	if err := run([]string{"nope"}, nil, nil); err != errUsage {
+		t.Errorf("expected usage, got %v", err)
+	}
+}
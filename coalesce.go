@@ -0,0 +1,88 @@
+package expiringmap
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+type coalesced[K comparable] struct {
+	mutex sync.Mutex
+	timer *time.Timer
+	fn    func(K)
+	fired bool
+}
+
+// Coalescer debounces calls per key, the pending call for a key is kept in
+// the map until it runs so Pending reflects scheduled work.
+type Coalescer[K comparable] struct {
+	pending ExpiringMap[K, *coalesced[K]]
+}
+
+func NewCoalescer[K comparable]() *Coalescer[K] {
+	return &Coalescer[K]{
+		pending: New[K, *coalesced[K]](),
+	}
+}
+
+// Coalesce schedules fn to run with key once delay has passed without
+// another call to Coalesce for the same key. Repeated calls push the run
+// back and replace fn.
+func (c *Coalescer[K]) Coalesce(key K, delay time.Duration, fn func(K)) {
+	for {
+		e := &coalesced[K]{fn: fn}
+		e.mutex.Lock()
+		actual, loaded := c.pending.GetOrSet(key, e, time.Now().Add(delay))
+		if !loaded {
+			e.timer = time.AfterFunc(delay, func() { c.fire(key, e) })
+			e.mutex.Unlock()
+			return
+		}
+		e.mutex.Unlock()
+
+		actual.mutex.Lock()
+		if !actual.fired {
+			actual.fn = fn
+			actual.timer.Reset(delay)
+			actual.mutex.Unlock()
+			c.pending.Expire(key, time.Now().Add(delay))
+			return
+		}
+		actual.mutex.Unlock()
+		// the pending call is running and about to leave the map
+		runtime.Gosched()
+	}
+}
+
+func (c *Coalescer[K]) Cancel(key K) bool {
+	e, ok := c.pending.Get(key)
+	if !ok {
+		return false
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.fired {
+		return false
+	}
+	e.fired = true
+	e.timer.Stop()
+	c.pending.RemoveIf(key, func(other *coalesced[K]) bool { return other == e })
+	return true
+}
+
+func (c *Coalescer[K]) Pending() int {
+	return c.pending.Len()
+}
+
+func (c *Coalescer[K]) fire(key K, e *coalesced[K]) {
+	e.mutex.Lock()
+	if e.fired {
+		e.mutex.Unlock()
+		return
+	}
+	e.fired = true
+	fn := e.fn
+	e.mutex.Unlock()
+	c.pending.RemoveIf(key, func(other *coalesced[K]) bool { return other == e })
+	fn(key)
+}
@@ -0,0 +1,56 @@
+package expiringmap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesce(t *testing.T) {
+	c := NewCoalescer[string]()
+	var calls atomic.Int32
+	var last atomic.Value
+	fired := make(chan struct{}, 10)
+
+	for i := 0; i < 5; i++ {
+		n := i
+		c.Coalesce("elephant", 20*time.Millisecond, func(key string) {
+			calls.Add(1)
+			last.Store(n)
+			fired <- struct{}{}
+		})
+		time.Sleep(2 * time.Millisecond)
+	}
+	if c.Pending() != 1 {
+		t.Errorf("expected a single pending call, got %d", c.Pending())
+	}
+
+	<-fired
+	time.Sleep(30 * time.Millisecond)
+	if calls.Load() != 1 {
+		t.Errorf("expected triggers to be coalesced into one call, got %d", calls.Load())
+	}
+	if last.Load() != 4 {
+		t.Error("expected the latest fn to run.")
+	}
+	if c.Pending() != 0 {
+		t.Error("expected no pending calls after running.")
+	}
+}
+
+func TestCoalesceCancel(t *testing.T) {
+	c := NewCoalescer[string]()
+	var calls atomic.Int32
+
+	c.Coalesce("elephant", 10*time.Millisecond, func(string) { calls.Add(1) })
+	if !c.Cancel("elephant") {
+		t.Error("expected pending call to be cancelled.")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if calls.Load() != 0 {
+		t.Error("cancelled call shouldn't run.")
+	}
+	if c.Cancel("elephant") {
+		t.Error("nothing left to cancel.")
+	}
+}
@@ -0,0 +1,83 @@
+package expiringmap
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Coherence is a broker agnostic transport for invalidation messages, wire
+// Redis pub/sub, NATS or a gossip layer behind it and pass it to
+// NewCoherenceBus.
+type Coherence interface {
+	Publish(message []byte) error
+	Subscribe(fn func(message []byte)) (unsubscribe func(), err error)
+}
+
+type MemoryCoherence struct {
+	mutex       sync.RWMutex
+	next        uint64
+	subscribers map[uint64]func([]byte)
+}
+
+func NewMemoryCoherence() *MemoryCoherence {
+	return &MemoryCoherence{
+		subscribers: make(map[uint64]func([]byte)),
+	}
+}
+
+func (c *MemoryCoherence) Publish(message []byte) error {
+	c.mutex.RLock()
+	subscribers := make([]func([]byte), 0, len(c.subscribers))
+	for _, fn := range c.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	c.mutex.RUnlock()
+	for _, fn := range subscribers {
+		fn(message)
+	}
+	return nil
+}
+
+func (c *MemoryCoherence) Subscribe(fn func([]byte)) (func(), error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id := c.next
+	c.next += 1
+	c.subscribers[id] = fn
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.subscribers, id)
+	}, nil
+}
+
+type CoherenceBus[K comparable] struct {
+	coherence Coherence
+	// OnError is called with messages that could not be decoded.
+	OnError func(err error)
+}
+
+func NewCoherenceBus[K comparable](coherence Coherence) *CoherenceBus[K] {
+	return &CoherenceBus[K]{coherence: coherence}
+}
+
+func (b *CoherenceBus[K]) Publish(invalidation Invalidation[K]) error {
+	message, err := json.Marshal(&invalidation)
+	if err != nil {
+		return err
+	}
+	return b.coherence.Publish(message)
+}
+
+func (b *CoherenceBus[K]) Subscribe(fn func(Invalidation[K])) (func(), error) {
+	return b.coherence.Subscribe(func(message []byte) {
+		var invalidation Invalidation[K]
+		if err := json.Unmarshal(message, &invalidation); err != nil {
+			if b.OnError != nil {
+				b.OnError(err)
+			}
+			return
+		}
+		fn(invalidation)
+	})
+}
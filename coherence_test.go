@@ -0,0 +1,41 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoherenceBus(t *testing.T) {
+	coherence := NewMemoryCoherence()
+	a := New[string, Animal](WithInvalidationBus[string, Animal](NewCoherenceBus[string](coherence)))
+	defer a.Close()
+	b := New[string, Animal](WithInvalidationBus[string, Animal](NewCoherenceBus[string](coherence)))
+	defer b.Close()
+
+	b.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	a.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	a.Delete("elephant")
+
+	deadline := time.Now().Add(time.Second)
+	for b.Has("elephant") {
+		if time.Now().After(deadline) {
+			t.Fatal("peer never applied the invalidation.")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoherenceBusDecodeError(t *testing.T) {
+	coherence := NewMemoryCoherence()
+	bus := NewCoherenceBus[string](coherence)
+	var decodeErr error
+	bus.OnError = func(err error) { decodeErr = err }
+	bus.Subscribe(func(Invalidation[string]) {
+		t.Error("invalid messages shouldn't be delivered.")
+	})
+
+	coherence.Publish([]byte("not json"))
+	if decodeErr == nil {
+		t.Error("expected decode error to be reported.")
+	}
+}
@@ -0,0 +1,60 @@
+package expiringmap
+
+// Resolver picks the entry kept when both maps hold a key, a is from the
+// first map and b from the second.
+type Resolver[K comparable, V any] func(a, b Entry[K, V]) Entry[K, V]
+
+// KeepLater keeps the entry expiring last, a on ties.
+func KeepLater[K comparable, V any](a, b Entry[K, V]) Entry[K, V] {
+	if b.TTL.After(a.TTL) {
+		return b
+	}
+	return a
+}
+
+// KeepEarlier keeps the entry expiring first, a on ties.
+func KeepEarlier[K comparable, V any](a, b Entry[K, V]) Entry[K, V] {
+	if b.TTL.Before(a.TTL) {
+		return b
+	}
+	return a
+}
+
+// Union returns a new map with the live entries of a and b, resolving keys
+// held by both with resolve.
+func Union[K comparable, V any](a, b *ExpiringMap[K, V], resolve Resolver[K, V], options ...Option[K, V]) *ExpiringMap[K, V] {
+	out := New(options...)
+	entries := entriesByKey(a)
+	for _, e := range b.snapshot() {
+		if other, ok := entries[e.Key]; ok {
+			e = resolve(other, e)
+		}
+		entries[e.Key] = e
+	}
+	for _, e := range entries {
+		out.SetEntry(e)
+	}
+	return &out
+}
+
+// Intersect returns a new map with the keys live in both a and b, their
+// entries chosen by resolve.
+func Intersect[K comparable, V any](a, b *ExpiringMap[K, V], resolve Resolver[K, V], options ...Option[K, V]) *ExpiringMap[K, V] {
+	out := New(options...)
+	entries := entriesByKey(a)
+	for _, e := range b.snapshot() {
+		if other, ok := entries[e.Key]; ok {
+			out.SetEntry(resolve(other, e))
+		}
+	}
+	return &out
+}
+
+func entriesByKey[K comparable, V any](m *ExpiringMap[K, V]) map[K]Entry[K, V] {
+	snapshot := m.snapshot()
+	entries := make(map[K]Entry[K, V], len(snapshot))
+	for _, e := range snapshot {
+		entries[e.Key] = e
+	}
+	return entries
+}
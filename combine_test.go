@@ -0,0 +1,35 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnionIntersect(t *testing.T) {
+	soon, later := time.Now().Add(time.Minute), time.Now().Add(time.Hour)
+	a, b := New[string, int](), New[string, int]()
+	a.Set("both", 1, soon)
+	b.Set("both", 2, later)
+	a.Set("a", 1, soon)
+	b.Set("b", 1, soon)
+	b.Set("expired", 1, time.Now().Add(-time.Second))
+
+	union := Union(&a, &b, KeepLater[string, int])
+	if union.Len() != 3 {
+		t.Errorf("expected 3 keys, got %d", union.Len())
+	}
+	if v, _ := union.Get("both"); v != 2 {
+		t.Errorf("expected the later entry, got %d", v)
+	}
+	if ttl, _ := union.TTL("both"); !ttl.Equal(later) {
+		t.Errorf("expected the later ttl, got %v", ttl)
+	}
+
+	intersection := Intersect(&a, &b, KeepEarlier[string, int])
+	if intersection.Len() != 1 {
+		t.Errorf("expected only the shared key, got %d", intersection.Len())
+	}
+	if v, _ := intersection.Get("both"); v != 1 {
+		t.Errorf("expected the earlier entry, got %d", v)
+	}
+}
@@ -0,0 +1,33 @@
+package expiringmap
+
+// Diff compares the live entries of a and b, returning the keys only a holds,
+// those only b holds and those whose values differ by eq.
+func Diff[K comparable, V any](a, b *ExpiringMap[K, V], eq func(x, y V) bool) (onlyA, onlyB, changed []K) {
+	values := make(map[K]V)
+	a.Range(func(key K, value V) bool {
+		values[key] = value
+		return true
+	})
+	b.Range(func(key K, value V) bool {
+		if other, ok := values[key]; !ok {
+			onlyB = append(onlyB, key)
+		} else {
+			if !eq(other, value) {
+				changed = append(changed, key)
+			}
+			delete(values, key)
+		}
+		return true
+	})
+	for key := range values {
+		onlyA = append(onlyA, key)
+	}
+	return onlyA, onlyB, changed
+}
+
+// Equal reports whether a and b hold the same live keys with values equal by
+// eq, expiries aside.
+func Equal[K comparable, V any](a, b *ExpiringMap[K, V], eq func(x, y V) bool) bool {
+	onlyA, onlyB, changed := Diff(a, b, eq)
+	return len(onlyA) == 0 && len(onlyB) == 0 && len(changed) == 0
+}
@@ -0,0 +1,44 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	ttl := time.Now().Add(time.Minute)
+	a, b := New[string, int](), New[string, int]()
+	a.Set("same", 1, ttl)
+	b.Set("same", 1, ttl)
+	a.Set("changed", 1, ttl)
+	b.Set("changed", 2, ttl)
+	a.Set("a", 1, ttl)
+	b.Set("b", 1, ttl)
+	b.Set("expired", 1, time.Now().Add(-time.Second))
+
+	onlyA, onlyB, changed := Diff(&a, &b, func(x, y int) bool { return x == y })
+	if len(onlyA) != 1 || onlyA[0] != "a" {
+		t.Errorf("expected only a in a, got %v", onlyA)
+	}
+	if len(onlyB) != 1 || onlyB[0] != "b" {
+		t.Errorf("expected only b in b, got %v", onlyB)
+	}
+	if len(changed) != 1 || changed[0] != "changed" {
+		t.Errorf("expected changed to differ, got %v", changed)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	eq := func(x, y int) bool { return x == y }
+	a, b := New[string, int](), New[string, int]()
+	a.Set("elephant", 1, time.Now().Add(time.Minute))
+	b.Set("elephant", 1, time.Now().Add(time.Hour))
+	b.Set("expired", 1, time.Now().Add(-time.Second))
+	if !Equal(&a, &b, eq) {
+		t.Error("expected maps with the same live entries to be equal.")
+	}
+	b.Set("elephant", 2, time.Now().Add(time.Hour))
+	if Equal(&a, &b, eq) {
+		t.Error("expected differing values to be unequal.")
+	}
+}
@@ -0,0 +1,57 @@
+package expiringmap
+
+import (
+	"sort"
+	"time"
+)
+
+// ExpiringCounter records timestamped increments per key and forgets them
+// after retention, which bounds the windows CountWithin can answer.
+type ExpiringCounter[K comparable] struct {
+	m         ExpiringMap[K, []time.Time]
+	retention time.Duration
+}
+
+func NewCounter[K comparable](retention time.Duration) *ExpiringCounter[K] {
+	return &ExpiringCounter[K]{
+		m:         New[K, []time.Time](),
+		retention: retention,
+	}
+}
+
+// Incr trims and appends to key's increments in place under its shard's
+// lock. They are only ever appended, never moved, so a slice CountWithin got
+// earlier is left as it was.
+func (c *ExpiringCounter[K]) Incr(key K) int {
+	count := 0
+	c.m.compute(key, func(times []time.Time, ok bool) ([]time.Time, time.Time, bool) {
+		now := time.Now()
+		times = append(since(times, now.Add(-c.retention)), now)
+		count = len(times)
+		return times, now.Add(c.retention), true
+	})
+	return count
+}
+
+// CountWithin returns the number of increments of key in the last window.
+func (c *ExpiringCounter[K]) CountWithin(key K, window time.Duration) int {
+	times, ok := c.m.Get(key)
+	if !ok {
+		return 0
+	}
+	return len(since(times, time.Now().Add(-window)))
+}
+
+func (c *ExpiringCounter[K]) Reset(key K) bool {
+	return c.m.Delete(key)
+}
+
+// Len returns the number of keys incremented within the retention.
+func (c *ExpiringCounter[K]) Len() int {
+	return c.m.Len()
+}
+
+func since(times []time.Time, cutoff time.Time) []time.Time {
+	i := sort.Search(len(times), func(i int) bool { return !times[i].Before(cutoff) })
+	return times[i:]
+}
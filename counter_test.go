@@ -0,0 +1,91 @@
+package expiringmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter[string](time.Minute)
+	for i := 0; i < 3; i++ {
+		c.Incr("elephant")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := c.Incr("elephant"); n != 4 {
+		t.Errorf("expected 4 increments, got %d", n)
+	}
+
+	if n := c.CountWithin("elephant", time.Minute); n != 4 {
+		t.Errorf("expected 4 within a minute, got %d", n)
+	}
+	if n := c.CountWithin("elephant", 10*time.Millisecond); n != 1 {
+		t.Errorf("expected 1 within 10ms, got %d", n)
+	}
+	if n := c.CountWithin("monkey", time.Minute); n != 0 {
+		t.Errorf("expected no increments, got %d", n)
+	}
+	if !c.Reset("elephant") || c.CountWithin("elephant", time.Minute) != 0 {
+		t.Error("expected reset to clear increments.")
+	}
+}
+
+func TestCounterRetention(t *testing.T) {
+	c := NewCounter[string](10 * time.Millisecond)
+	c.Incr("elephant")
+	time.Sleep(20 * time.Millisecond)
+	if c.Len() != 0 {
+		t.Error("counter should expire after retention.")
+	}
+	c.Incr("elephant")
+	if n := c.Incr("elephant"); n != 2 {
+		t.Errorf("expected old increments to be pruned, got %d", n)
+	}
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	c := NewCounter[string](time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Incr("elephant")
+			}
+		}()
+	}
+	wg.Wait()
+	if n := c.CountWithin("elephant", time.Minute); n != 1000 {
+		t.Errorf("expected 1000 increments, got %d", n)
+	}
+}
+
+func TestCounterTrimWhileReading(t *testing.T) {
+	c := NewCounter[string](time.Millisecond)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if n := c.CountWithin("elephant", time.Millisecond); n < 0 {
+					t.Error("negative count")
+				}
+			}
+		}
+	}()
+	deadline := time.Now().Add(20 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		c.Incr("elephant")
+	}
+	close(done)
+	wg.Wait()
+	if n := c.Incr("elephant"); n <= 0 {
+		t.Errorf("expected the latest increment to be counted, got %d", n)
+	}
+}
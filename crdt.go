@@ -0,0 +1,61 @@
+package expiringmap
+
+import "time"
+
+// WithCRDT is WithLastWriteWins keeping a tombstone of each delete for
+// tombstoneTTL, so a delete beats any earlier write arriving after it. Maps
+// exchanging all their mutations, or merged with Merge, converge on the same
+// contents provided tombstones outlive the delay between them.
+func WithCRDT[K comparable, V any](origin string, tombstoneTTL time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.lww = true
+		c.origin = origin
+		c.tombstoneTTL = tombstoneTTL
+	}
+}
+
+// bury records a delete stamped stamp unless a later one is recorded.
+func (m *ExpiringMap[K, V]) bury(key K, stamp *Stamp) {
+	if m.tombstones == nil {
+		return
+	}
+	m.tombstones.compute(key, func(old Stamp, ok bool) (Stamp, time.Time, bool) {
+		if ok && stamp.Less(&old) {
+			return old, m.now().Add(m.config.tombstoneTTL), true
+		}
+		return *stamp, m.now().Add(m.config.tombstoneTTL), true
+	})
+}
+
+// buried reports whether key was deleted after stamp.
+func (m *ExpiringMap[K, V]) buried(key K, stamp *Stamp) bool {
+	if m.tombstones == nil {
+		return false
+	}
+	tombstone, ok := m.tombstones.Get(key)
+	return ok && stamp.Less(&tombstone)
+}
+
+// Merge folds the state of other into m, entry by entry and tombstone by
+// tombstone, keeping the later write for each key. Both maps must be in last
+// write wins mode, merging is commutative and idempotent.
+func (m *ExpiringMap[K, V]) Merge(other *ExpiringMap[K, V]) {
+	var mutations []Mutation[K, V]
+	now := other.now()
+	other.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if !item.expired(now) && item.stamp != nil && !owns.skip(key) {
+				mutations = append(mutations, Mutation[K, V]{Op: MutationSet, Key: key, Val: item.val, TTL: item.ttl, Stamp: item.stamp})
+			}
+		}
+	}, nil)
+	if other.tombstones != nil {
+		other.tombstones.Range(func(key K, stamp Stamp) bool {
+			mutations = append(mutations, Mutation[K, V]{Op: MutationDelete, Key: key, Stamp: &stamp})
+			return true
+		})
+	}
+	for _, mutation := range mutations {
+		m.ApplyMutation(mutation)
+	}
+}
@@ -0,0 +1,61 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCRDTTombstones(t *testing.T) {
+	a := New(WithCRDT[string, int]("a", time.Minute))
+	b := New(WithCRDT[string, int]("b", time.Minute))
+	var fromA []Mutation[string, int]
+	a.Subscribe(func(mutation Mutation[string, int]) { fromA = append(fromA, mutation) })
+	ttl := time.Now().Add(time.Minute)
+
+	a.Set("x", 1, ttl)
+	set := fromA[0]
+	a.Delete("x")
+	// the delete overtakes the set it removed
+	b.ApplyMutation(fromA[1])
+	b.ApplyMutation(set)
+	if _, ok := b.Get("x"); ok {
+		t.Error("expected the tombstone to discard the stale set")
+	}
+
+	// a write made after the delete still wins
+	b.Set("x", 2, ttl)
+	if v, _ := b.Get("x"); v != 2 {
+		t.Errorf("expected a later write to win over the tombstone, got %d", v)
+	}
+}
+
+func TestCRDTMerge(t *testing.T) {
+	a := New(WithCRDT[string, int]("a", time.Minute))
+	b := New(WithCRDT[string, int]("b", time.Minute))
+	ttl := time.Now().Add(time.Minute)
+
+	a.Set("x", 1, ttl)
+	a.Set("y", 1, ttl)
+	b.Merge(&a)
+	// concurrent writes and deletes on both sides
+	a.Set("x", 2, ttl)
+	b.Set("x", 3, ttl)
+	a.Delete("y")
+	b.Set("z", 1, ttl)
+	a.Merge(&b)
+	b.Merge(&a)
+	a.Merge(&b)
+
+	if !Equal(&a, &b, func(x, y int) bool { return x == y }) {
+		t.Errorf("expected merged maps to converge, got %d and %d entries", a.Len(), b.Len())
+	}
+	if v, _ := a.Get("x"); v != 3 {
+		t.Errorf("expected b's concurrent write to win, got %d", v)
+	}
+	if a.Has("y") || b.Has("y") {
+		t.Error("expected the delete to be merged")
+	}
+	if v, _ := b.Get("z"); v != 1 {
+		t.Errorf("expected z to be merged, got %d", v)
+	}
+}
@@ -0,0 +1,24 @@
+package expiringmap
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DumpCSV writes a key, expiry, value row for each live entry to w after a
+// header row, formatting values with valueFmt or fmt.Sprint when it is nil.
+// It is meant for inspection, keys are formatted with fmt.Sprint.
+func (m *ExpiringMap[K, V]) DumpCSV(w io.Writer, valueFmt func(V) string) error {
+	if valueFmt == nil {
+		valueFmt = func(v V) string { return fmt.Sprint(v) }
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"key", "expiry", "value"})
+	for _, e := range m.snapshot() {
+		cw.Write([]string{fmt.Sprint(e.Key), e.TTL.Format(time.RFC3339Nano), valueFmt(e.Val)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,29 @@
+package expiringmap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+func TestDumpCSV(t *testing.T) {
+	m := New[string, Plant]()
+	ttl := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	m.Set("fern, green", Plant{"fern"}, ttl)
+
+	var buf bytes.Buffer
+	if err := m.DumpCSV(&buf, func(p Plant) string { return p.Name }); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][0] != "key" {
+		t.Fatalf("expected a header and a row, got %v", rows)
+	}
+	if row := rows[1]; row[0] != "fern, green" || row[1] != "2030-01-02T03:04:05Z" || row[2] != "fern" {
+		t.Errorf("unexpected row %v", row)
+	}
+}
@@ -0,0 +1,10 @@
+package expiringmap
+
+import "time"
+
+// Dedupe reports whether key was already seen within the last window, and
+// records it if not. The check and the insert happen atomically so only one
+// of several concurrent callers with the same key sees false.
+func (m *ExpiringMap[K, V]) Dedupe(key K, window time.Duration) bool {
+	return !m.SetIfAbsent(key, *new(V), m.now().Add(window))
+}
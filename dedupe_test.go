@@ -0,0 +1,43 @@
+package expiringmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDedupe(t *testing.T) {
+	m := New[string, struct{}]()
+
+	if m.Dedupe("request-1", time.Minute) {
+		t.Error("first occurrence shouldn't be a duplicate.")
+	}
+	if !m.Dedupe("request-1", time.Minute) {
+		t.Error("second occurrence should be a duplicate.")
+	}
+	if m.Dedupe("request-2", -time.Minute) || m.Dedupe("request-2", time.Minute) {
+		t.Error("occurrences outside the window shouldn't be duplicates.")
+	}
+}
+
+func TestDedupeConcurrent(t *testing.T) {
+	m := New[string, struct{}]()
+	var first atomic.Int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !m.Dedupe("webhook", time.Minute) {
+				first.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if first.Load() != 1 {
+		t.Errorf("expected exactly one first occurrence, got %d", first.Load())
+	}
+}
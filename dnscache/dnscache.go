@@ -0,0 +1,125 @@
+package dnscache
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+const DefaultTTL = time.Minute
+
+// TTLLookuper is implemented by resolvers that know the TTL of the records
+// they return. The standard library resolver does not expose record TTLs, so
+// without one lookups are cached for Resolver.TTL.
+type TTLLookuper interface {
+	LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error)
+}
+
+type Resolver struct {
+	Resolver *net.Resolver
+	Lookuper TTLLookuper
+	// TTL is used when the record TTL is unknown and caps known record TTLs
+	// when MaxTTL is zero.
+	TTL    time.Duration
+	MaxTTL time.Duration
+	Dialer *net.Dialer
+	cache  *expiringmap.ExpiringMap[string, []net.IPAddr]
+}
+
+func New(cache *expiringmap.ExpiringMap[string, []net.IPAddr]) *Resolver {
+	return &Resolver{
+		TTL:   DefaultTTL,
+		cache: cache,
+	}
+}
+
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if addrs, ok := r.cache.Get(host); ok {
+		return addrs, nil
+	}
+	addrs, ttl, err := r.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 && len(addrs) != 0 {
+		r.cache.Set(host, addrs, time.Now().Add(ttl))
+	}
+	return addrs, nil
+}
+
+func (r *Resolver) Refresh(host string) bool {
+	return r.cache.Delete(host)
+}
+
+// DialContext resolves addr through the cache and dials the resulting
+// addresses in order, suitable for http.Transport.DialContext.
+func (r *Resolver) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := r.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	if net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	addrs, err := r.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var firstErr error
+	for _, ip := range addrs {
+		if !matchesNetwork(network, ip.IP) {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = &net.DNSError{Err: "no suitable address found", Name: host}
+	}
+	return nil, firstErr
+}
+
+func (r *Resolver) lookup(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	if r.Lookuper != nil {
+		addrs, ttl, err := r.Lookuper.LookupIPAddrTTL(ctx, host)
+		if err != nil {
+			return nil, 0, err
+		}
+		maxTTL := r.MaxTTL
+		if maxTTL <= 0 {
+			maxTTL = r.TTL
+		}
+		if maxTTL > 0 && ttl > maxTTL {
+			ttl = maxTTL
+		}
+		return addrs, ttl, nil
+	}
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	return addrs, r.TTL, err
+}
+
+func matchesNetwork(network string, ip net.IP) bool {
+	switch network {
+	case "tcp4", "udp4", "ip4":
+		return ip.To4() != nil
+	case "tcp6", "udp6", "ip6":
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,78 @@
+package dnscache
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+type testLookuper struct {
+	ip      string
+	ttl     time.Duration
+	lookups int
+}
+
+func (l *testLookuper) LookupIPAddrTTL(_ context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	l.lookups += 1
+	return []net.IPAddr{{IP: net.ParseIP(l.ip)}}, l.ttl, nil
+}
+
+func TestLookupIPAddrUsesRecordTTL(t *testing.T) {
+	cache := expiringmap.New[string, []net.IPAddr]()
+	lookuper := &testLookuper{ip: "127.0.0.1", ttl: 30 * time.Second}
+	r := New(&cache)
+	r.Lookuper = lookuper
+
+	for i := 0; i < 3; i++ {
+		addrs, err := r.LookupIPAddr(context.Background(), "elephant.internal")
+		if err != nil || len(addrs) != 1 {
+			t.Fatalf("unexpected lookup result %v %v", addrs, err)
+		}
+	}
+	if lookuper.lookups != 1 {
+		t.Errorf("expected a single lookup, got %d", lookuper.lookups)
+	}
+	ttl, _ := cache.TTL("elephant.internal")
+	if remaining := time.Until(ttl); remaining > 30*time.Second || remaining < 29*time.Second {
+		t.Errorf("expected record ttl to be used, got %v", remaining)
+	}
+}
+
+func TestLookupIPAddrCapsTTL(t *testing.T) {
+	cache := expiringmap.New[string, []net.IPAddr]()
+	r := New(&cache)
+	r.Lookuper = &testLookuper{ip: "127.0.0.1", ttl: time.Hour}
+
+	r.LookupIPAddr(context.Background(), "elephant.internal")
+	ttl, _ := cache.TTL("elephant.internal")
+	if time.Until(ttl) > DefaultTTL {
+		t.Error("record ttl should be capped.")
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("elephant"))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	cache := expiringmap.New[string, []net.IPAddr]()
+	r := New(&cache)
+	r.Lookuper = &testLookuper{ip: "127.0.0.1", ttl: time.Minute}
+	client := &http.Client{Transport: &http.Transport{DialContext: r.DialContext}}
+
+	res, err := client.Get("http://elephant.internal:" + port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,132 @@
+package expiringmap
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const sealChunkSize = 64 << 10
+
+var ErrSnapshotTampered = errors.New("expiringmap: snapshot tampered with or truncated")
+
+// sealWriter seals its input in chunks of up to sealChunkSize bytes, each
+// written as a header holding a final flag and the ciphertext length, the
+// nonce and the ciphertext. The header and chunk index are authenticated so
+// chunks can't be reordered, dropped or cut off.
+type sealWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	buf    []byte
+	index  uint64
+	closed bool
+	err    error
+}
+
+func newSealWriter(w io.Writer, aead cipher.AEAD) *sealWriter {
+	return &sealWriter{w: w, aead: aead, buf: make([]byte, 0, sealChunkSize)}
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, errors.New("expiringmap: write to closed snapshot writer")
+	}
+	n := 0
+	for s.err == nil && len(p) > 0 {
+		c := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+c]
+		p = p[c:]
+		n += c
+		if len(s.buf) == cap(s.buf) {
+			s.seal(false)
+		}
+	}
+	return n, s.err
+}
+
+func (s *sealWriter) Close() error {
+	if !s.closed && s.err == nil {
+		s.seal(true)
+	}
+	s.closed = true
+	return s.err
+}
+
+func (s *sealWriter) seal(final bool) {
+	header := make([]byte, 5, 5+s.aead.NonceSize()+len(s.buf)+s.aead.Overhead())
+	if final {
+		header[0] = 1
+	}
+	binary.BigEndian.PutUint32(header[1:], uint32(len(s.buf)+s.aead.Overhead()))
+	nonce := header[5 : 5+s.aead.NonceSize()]
+	if _, s.err = rand.Read(nonce); s.err != nil {
+		return
+	}
+	out := s.aead.Seal(header[:5+len(nonce)], nonce, s.buf, chunkData(header[:5], s.index))
+	_, s.err = s.w.Write(out)
+	s.buf = s.buf[:0]
+	s.index++
+}
+
+func chunkData(header []byte, index uint64) []byte {
+	data := make([]byte, 8, 8+len(header))
+	binary.BigEndian.PutUint64(data, index)
+	return append(data, header...)
+}
+
+type openReader struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+	final bool
+	err   error
+}
+
+func newOpenReader(r io.Reader, aead cipher.AEAD) *openReader {
+	return &openReader{r: bufio.NewReader(r), aead: aead}
+}
+
+func (o *openReader) Read(p []byte) (int, error) {
+	for len(o.buf) == 0 && o.err == nil {
+		if o.final {
+			o.err = io.EOF
+			break
+		}
+		o.open()
+	}
+	if len(o.buf) == 0 {
+		return 0, o.err
+	}
+	n := copy(p, o.buf)
+	o.buf = o.buf[n:]
+	return n, nil
+}
+
+func (o *openReader) open() {
+	header := make([]byte, 5+o.aead.NonceSize())
+	if _, err := io.ReadFull(o.r, header); err != nil {
+		o.err = ErrSnapshotTampered
+		return
+	}
+	size := binary.BigEndian.Uint32(header[1:5])
+	if header[0] > 1 || size < uint32(o.aead.Overhead()) || size > sealChunkSize+uint32(o.aead.Overhead()) {
+		o.err = ErrSnapshotTampered
+		return
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(o.r, sealed); err != nil {
+		o.err = ErrSnapshotTampered
+		return
+	}
+	plain, err := o.aead.Open(sealed[:0], header[5:], sealed, chunkData(header[:5], o.index))
+	if err != nil {
+		o.err = ErrSnapshotTampered
+		return
+	}
+	o.buf, o.final = plain, header[0] == 1
+	o.index++
+}
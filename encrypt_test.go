@@ -0,0 +1,48 @@
+package expiringmap
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEncryptedSnapshot(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	m := New[string, string]()
+	for i := 0; i < 2000; i++ {
+		m.Set(strconv.Itoa(i), "secret-token", time.Now().Add(time.Minute))
+	}
+
+	var buf bytes.Buffer
+	if err := m.WriteSnapshot(&buf, WithEncryptionKey(key)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() < 2*sealChunkSize {
+		t.Fatalf("expected the snapshot to span several chunks, got %d bytes", buf.Len())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret-token")) {
+		t.Fatal("expected values not to be written in plaintext")
+	}
+	sealed := buf.Bytes()
+
+	restored := New[string, string]()
+	if err := restored.ReadSnapshot(bytes.NewReader(sealed), WithEncryptionKey(key)); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != m.Len() {
+		t.Errorf("expected %d entries, got %d", m.Len(), restored.Len())
+	}
+
+	other := New[string, string]()
+	if err := other.ReadSnapshot(bytes.NewReader(sealed), WithEncryptionKey(make([]byte, 32))); !errors.Is(err, ErrSnapshotTampered) {
+		t.Errorf("expected the wrong key to be rejected, got %v", err)
+	}
+	if err := other.ReadSnapshot(bytes.NewReader(sealed[:len(sealed)-1]), WithEncryptionKey(key)); !errors.Is(err, ErrSnapshotTampered) {
+		t.Errorf("expected a truncated snapshot to be rejected, got %v", err)
+	}
+	if _, err := NewSnapshotWriter(&buf, WithEncryptionKey([]byte("short"))); err == nil {
+		t.Error("expected an invalid key to be rejected")
+	}
+}
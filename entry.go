@@ -0,0 +1,157 @@
+package expiringmap
+
+import "time"
+
+// Entry is a stored value with its metadata. Entries of a lower Priority are
+// evicted before any of a higher one, the policy choosing among entries of the
+// same Priority.
+type Entry[K comparable, V any] struct {
+	Key      K         `json:"key"`
+	Val      V         `json:"val"`
+	TTL      time.Time `json:"ttl"`
+	Created  time.Time `json:"created"`
+	Tags     []string  `json:"tags,omitempty"`
+	Priority int       `json:"priority,omitempty"`
+}
+
+type entryMeta[V any] struct {
+	tags     []string
+	priority int
+	// onRemoved is SetWithCallback's callback bound to the entry's key.
+	onRemoved func(value V, reason RemovalReason)
+}
+
+// KeysWithTag returns the live keys stored by SetEntry with tag among their
+// Tags.
+func (m *ExpiringMap[K, V]) KeysWithTag(tag string) []K {
+	var keys []K
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if !item.tagged(tag) || owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				keys = append(keys, key)
+			}
+		}
+	}, nil)
+	return keys
+}
+
+// DeleteTagged deletes every live entry with tag among its Tags, a shard at a
+// time, and returns how many it deleted.
+func (m *ExpiringMap[K, V]) DeleteTagged(tag string) int {
+	count := 0
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		now := m.now()
+		for key, item := range s.items {
+			if !item.tagged(tag) || owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				m.remove(s, key, item, RemovalDeleted, false)
+				count++
+			}
+		}
+	}, nil)
+	return count
+}
+
+func (item expiringMapVal[V]) tagged(tag string) bool {
+	if item.meta == nil {
+		return false
+	}
+	for _, t := range item.meta.tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// SetEntry stores e as is, a zero Created is set to now. It reports whether
+// the key is new like Set.
+func (m *ExpiringMap[K, V]) SetEntry(e Entry[K, V]) bool {
+	item := expiringMapVal[V]{val: e.Val, ttl: e.TTL}
+	if e.Created.IsZero() {
+		item.created = m.now().UnixNano()
+	} else {
+		item.created = e.Created.UnixNano()
+	}
+	if len(e.Tags) > 0 || e.Priority != 0 {
+		item.meta = &entryMeta[V]{tags: append([]string(nil), e.Tags...), priority: e.Priority}
+	}
+
+	s := m.lockShard(e.Key)
+	defer m.unlock(s)
+	isNew := true
+	if old, ok := s.items[e.Key]; ok {
+		if old.expired(m.now()) {
+			m.expire(s, e.Key, old)
+		} else {
+			isNew = false
+		}
+	}
+	return m.store(s, e.Key, item) && isNew
+}
+
+func (m *ExpiringMap[K, V]) GetEntry(key K) (Entry[K, V], bool) {
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := s.items[key]; ok {
+		if now := m.now(); item.expired(now) {
+			m.expire(s, key, item)
+		} else {
+			m.accessed(s, key, now)
+			m.hit(s)
+			e := newEntry(key, item)
+			e.Val = m.read(key, item)
+			return e, true
+		}
+	}
+	m.miss(s)
+	return Entry[K, V]{}, false
+}
+
+func newEntry[K comparable, V any](key K, item expiringMapVal[V]) Entry[K, V] {
+	e := Entry[K, V]{Key: key, Val: item.val, TTL: item.ttl, Created: time.Unix(0, item.created)}
+	if item.meta != nil {
+		e.Tags = append([]string(nil), item.meta.tags...)
+		e.Priority = item.meta.priority
+	}
+	return e
+}
+
+// RangeEntries is Range handing f each live entry with its expiry, creation
+// time and metadata.
+func (m *ExpiringMap[K, V]) RangeEntries(f func(e Entry[K, V]) bool) {
+	var entries []Entry[K, V]
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		now := m.now()
+		entries = entries[:0]
+		for key, item := range s.items {
+			if owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				e := newEntry(key, item)
+				e.Val = m.read(key, item)
+				entries = append(entries, e)
+			}
+		}
+	}, func() bool {
+		for _, e := range entries {
+			if !f(e) {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,90 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetEntry(t *testing.T) {
+	m := New[string, Animal]()
+	ttl := time.Now().Add(time.Minute)
+	created := time.Now().Add(-time.Hour)
+	tags := []string{"mammal", "large"}
+
+	if !m.SetEntry(Entry[string, Animal]{Key: "elephant", Val: Animal{"elephant"}, TTL: ttl, Created: created, Tags: tags, Priority: 5}) {
+		t.Error("expected a new key.")
+	}
+	tags[0] = "changed"
+
+	e, ok := m.GetEntry("elephant")
+	if !ok {
+		t.Fatal("expected entry.")
+	}
+	if e.Key != "elephant" || e.Val.name != "elephant" || !e.TTL.Equal(ttl) || !e.Created.Equal(created) || e.Priority != 5 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if len(e.Tags) != 2 || e.Tags[0] != "mammal" {
+		t.Errorf("tags should be copied, got %v", e.Tags)
+	}
+
+	if m.SetEntry(Entry[string, Animal]{Key: "elephant", Val: Animal{"elephant"}, TTL: ttl}) {
+		t.Error("expected an existing key.")
+	}
+	e, _ = m.GetEntry("elephant")
+	if e.Tags != nil || e.Priority != 0 || time.Since(e.Created) > time.Second {
+		t.Errorf("replacing an entry should reset its metadata, got %+v", e)
+	}
+}
+
+func TestGetEntryExpired(t *testing.T) {
+	m := New[string, Animal]()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(-time.Minute))
+	if _, ok := m.GetEntry("elephant"); ok {
+		t.Error("expired entries shouldn't be returned.")
+	}
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	m.Expire("monkey", time.Now().Add(time.Hour))
+	if e, ok := m.GetEntry("monkey"); !ok || time.Since(e.Created) > time.Second {
+		t.Error("expected created time to be kept.")
+	}
+}
+
+func TestRangeEntries(t *testing.T) {
+	m := New[string, Animal]()
+	ttl := time.Now().Add(time.Minute)
+	m.SetEntry(Entry[string, Animal]{Key: "elephant", Val: Animal{"elephant"}, TTL: ttl, Tags: []string{"large"}, Priority: 2})
+	m.Set("monkey", Animal{"monkey"}, ttl)
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(-time.Second))
+
+	entries := map[string]Entry[string, Animal]{}
+	m.RangeEntries(func(e Entry[string, Animal]) bool {
+		entries[e.Key] = e
+		return true
+	})
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 live entries, got %v", entries)
+	}
+	elephant := entries["elephant"]
+	if !elephant.TTL.Equal(ttl) || elephant.Created.IsZero() || elephant.Priority != 2 || len(elephant.Tags) != 1 {
+		t.Errorf("expected the entry's metadata, got %+v", elephant)
+	}
+}
+
+func TestTags(t *testing.T) {
+	m := New[string, Animal]()
+	ttl := time.Now().Add(time.Minute)
+	m.SetEntry(Entry[string, Animal]{Key: "elephant", Val: Animal{"elephant"}, TTL: ttl, Tags: []string{"mammal", "large"}})
+	m.SetEntry(Entry[string, Animal]{Key: "monkey", Val: Animal{"monkey"}, TTL: ttl, Tags: []string{"mammal"}})
+	m.SetEntry(Entry[string, Animal]{Key: "mammoth", Val: Animal{"mammoth"}, TTL: time.Now().Add(-time.Second), Tags: []string{"mammal"}})
+	m.Set("tiger", Animal{"tiger"}, ttl)
+
+	if keys := m.KeysWithTag("large"); len(keys) != 1 || keys[0] != "elephant" {
+		t.Errorf("expected elephant to be large, got %v", keys)
+	}
+	if n := m.DeleteTagged("mammal"); n != 2 {
+		t.Errorf("expected the 2 live mammals to be deleted, got %d", n)
+	}
+	if m.Len() != 1 || !m.Has("tiger") {
+		t.Errorf("expected only the untagged tiger to remain, got %d keys", m.Len())
+	}
+}
@@ -0,0 +1,443 @@
+package expiringmap
+
+import (
+	"container/heap"
+	"container/list"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+var ErrFull = errors.New("expiringmap: map is full")
+
+// EvictionPolicy chooses which entries make room once WithMaxEntries is
+// reached. The policies match Redis' maxmemory-policy, volatile policies only
+// evict entries with an expiry and let the map grow when there are none.
+type EvictionPolicy int
+
+const (
+	EvictLRU EvictionPolicy = iota
+	EvictRandom
+	EvictVolatileLRU
+	EvictVolatileRandom
+	EvictVolatileTTL
+	// EvictNone refuses new keys while the map is full.
+	EvictNone
+	// EvictARC adapts between recency and frequency, see arc.go.
+	EvictARC
+	// EvictClock approximates LRU with a bit per key, see clock.go.
+	EvictClock
+	// EvictSLRU protects keys read more than once from scans, see slru.go.
+	EvictSLRU
+)
+
+var evictionPolicyNames = map[EvictionPolicy]string{
+	EvictLRU:            "allkeys-lru",
+	EvictRandom:         "allkeys-random",
+	EvictVolatileLRU:    "volatile-lru",
+	EvictVolatileRandom: "volatile-random",
+	EvictVolatileTTL:    "volatile-ttl",
+	EvictNone:           "noeviction",
+	EvictARC:            "arc",
+	EvictClock:          "clock",
+	EvictSLRU:           "slru",
+}
+
+// ParseEvictionPolicy parses a Redis maxmemory-policy name.
+func ParseEvictionPolicy(name string) (EvictionPolicy, error) {
+	for policy, other := range evictionPolicyNames {
+		if other == name {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("expiringmap: unknown eviction policy %q", name)
+}
+
+func (p EvictionPolicy) String() string {
+	if name, ok := evictionPolicyNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (p EvictionPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *EvictionPolicy) UnmarshalText(text []byte) error {
+	policy, err := ParseEvictionPolicy(string(text))
+	if err == nil {
+		*p = policy
+	}
+	return err
+}
+
+// evictor tracks the keys of one shard and picks which to evict. Victims are
+// ranked so the lowest ranked victim across all shards is evicted first.
+type evictor[K comparable] interface {
+	add(key K, ttl time.Time, now int64)
+	access(key K, now int64)
+	remove(key K)
+	victim() (key K, rank int64, ok bool)
+}
+
+// peeker is an evictor whose victim has side effects, peek naming the same
+// victim without them so shards can be compared without disturbing those not
+// evicted from.
+type peeker[K comparable] interface {
+	peek() (key K, rank int64, ok bool)
+}
+
+func peek[K comparable](e evictor[K]) (K, int64, bool) {
+	if p, ok := e.(peeker[K]); ok {
+		return p.peek()
+	}
+	return e.victim()
+}
+
+// ghostEvictor is told which of its keys are evicted, before they are
+// removed, to remember them apart from keys deleted or expired.
+type ghostEvictor[K comparable] interface {
+	evicted(key K)
+}
+
+func (c *config[K, V]) newEvictor(shards int) evictor[K] {
+	switch c.evictionPolicy {
+	case EvictRandom:
+		return newRandomEvictor[K]()
+	case EvictVolatileLRU:
+		return &volatileEvictor[K]{newLRUEvictor[K]()}
+	case EvictVolatileRandom:
+		return &volatileEvictor[K]{newRandomEvictor[K]()}
+	case EvictVolatileTTL:
+		return newTTLEvictor[K]()
+	case EvictNone:
+		return noEvictor[K]{}
+	case EvictARC:
+		return newARCEvictor[K](c.shardCapacity(shards))
+	case EvictClock:
+		return newClockEvictor[K]()
+	case EvictSLRU:
+		return newSLRUEvictor[K](c.shardCapacity(shards))
+	default:
+		return newLRUEvictor[K]()
+	}
+}
+
+// shardCapacity is a shard's fair share of the map's capacity.
+func (c *config[K, V]) shardCapacity(shards int) int {
+	return (c.maxEntries + shards - 1) / shards
+}
+
+func (c *config[K, V]) bounded() bool {
+	return c.maxEntries > 0 || c.maxWeight > 0
+}
+
+// reserve counts a new key of weight, failing when the map is full and must
+// not evict.
+func (m *ExpiringMap[K, V]) reserve(weight int64) bool {
+	if m.config.evictionPolicy != EvictNone {
+		m.size.Add(1)
+		m.weight.Add(weight)
+		return true
+	}
+	if !reserve(m.weight, weight, m.config.maxWeight) {
+		return false
+	}
+	if !reserve(m.size, 1, int64(m.config.maxEntries)) {
+		m.weight.Add(-weight)
+		return false
+	}
+	return true
+}
+
+// reserve adds n to counter unless that takes it over a positive max.
+func reserve(counter *atomic.Int64, n, max int64) bool {
+	if max <= 0 {
+		counter.Add(n)
+		return true
+	}
+	for {
+		value := counter.Load()
+		if value+n > max {
+			return false
+		}
+		if counter.CompareAndSwap(value, value+n) {
+			return true
+		}
+	}
+}
+
+func (m *ExpiringMap[K, V]) overCapacity() bool {
+	if max := m.config.maxEntries; max > 0 && m.size.Load() > int64(max) {
+		return true
+	}
+	max := m.config.maxWeight
+	return max > 0 && m.weight.Load() > max
+}
+
+// unlock releases a shard after a write, evicting if the write took the map
+// over capacity.
+func (m *ExpiringMap[K, V]) unlock(s *shard[K, V]) {
+	entries := len(s.items)
+	s.unlock()
+	if m.overCapacity() {
+		m.evict()
+	}
+	if m.config.growAt > 0 {
+		m.checkResize(entries)
+	}
+}
+
+func (m *ExpiringMap[K, V]) evict() {
+	m.evicting.Lock()
+	defer m.evicting.Unlock()
+	for m.overCapacity() {
+		if _, ok := m.evictOne(); !ok {
+			return
+		}
+	}
+}
+
+// Evict removes up to n entries chosen by the eviction policy and returns how
+// many went, so memory can be shed ahead of the capacity. Maps without a
+// capacity evict the entries closest to expiry, EvictNone evicts nothing.
+func (m *ExpiringMap[K, V]) Evict(n int) int {
+	if !m.config.bounded() {
+		return len(m.popNextExpiring(n, RemovalEvicted))
+	}
+	m.evicting.Lock()
+	defer m.evicting.Unlock()
+	count := 0
+	for count < n {
+		evicted, ok := m.evictOne()
+		if !ok {
+			break
+		}
+		if evicted {
+			count++
+		}
+	}
+	return count
+}
+
+// evictOne removes the lowest ranked victim of the lowest priority across the
+// shards, evicted is false when it had already expired.
+func (m *ExpiringMap[K, V]) evictOne() (evicted, ok bool) {
+	var (
+		found    bool
+		best     *shard[K, V]
+		priority int
+		rank     int64
+	)
+	m.eachShard(func(s *shard[K, V], _ keyFilter[K]) {
+		_, p, r, ok := peekVictim(s.evictor)
+		if ok && (!found || p < priority || p == priority && r < rank) {
+			found, best, priority, rank = true, s, p, r
+		}
+	}, nil)
+	if !found {
+		return false, false
+	}
+	best.lock()
+	defer best.unlock()
+	if best.moved {
+		return false, true
+	}
+	if key, _, ok := best.evictor.victim(); ok {
+		if item := best.items[key]; item.expired(m.now()) {
+			m.expire(best, key, item)
+		} else {
+			if g, ok := best.evictor.(ghostEvictor[K]); ok {
+				g.evicted(key)
+			}
+			m.remove(best, key, item, RemovalEvicted, false)
+			return true, true
+		}
+	}
+	return false, true
+}
+
+type lruEvictor[K comparable] struct {
+	order    *list.List
+	elements map[K]*list.Element
+}
+
+type lruItem[K comparable] struct {
+	key  K
+	used int64
+}
+
+func newLRUEvictor[K comparable]() *lruEvictor[K] {
+	return &lruEvictor[K]{
+		order:    list.New(),
+		elements: make(map[K]*list.Element),
+	}
+}
+
+func (e *lruEvictor[K]) add(key K, ttl time.Time, now int64) {
+	if el, ok := e.elements[key]; ok {
+		el.Value.(*lruItem[K]).used = now
+		e.order.MoveToFront(el)
+		return
+	}
+	e.elements[key] = e.order.PushFront(&lruItem[K]{key, now})
+}
+
+func (e *lruEvictor[K]) access(key K, now int64) {
+	if el, ok := e.elements[key]; ok {
+		el.Value.(*lruItem[K]).used = now
+		e.order.MoveToFront(el)
+	}
+}
+
+func (e *lruEvictor[K]) remove(key K) {
+	if el, ok := e.elements[key]; ok {
+		e.order.Remove(el)
+		delete(e.elements, key)
+	}
+}
+
+func (e *lruEvictor[K]) victim() (K, int64, bool) {
+	el := e.order.Back()
+	if el == nil {
+		return *new(K), 0, false
+	}
+	item := el.Value.(*lruItem[K])
+	return item.key, item.used, true
+}
+
+// randomEvictor keeps no access order, victims are ranked randomly so the
+// evicted shard is random too.
+type randomEvictor[K comparable] struct {
+	keys    []K
+	indexes map[K]int
+}
+
+func newRandomEvictor[K comparable]() *randomEvictor[K] {
+	return &randomEvictor[K]{indexes: make(map[K]int)}
+}
+
+func (e *randomEvictor[K]) add(key K, ttl time.Time, now int64) {
+	if _, ok := e.indexes[key]; !ok {
+		e.indexes[key] = len(e.keys)
+		e.keys = append(e.keys, key)
+	}
+}
+
+func (e *randomEvictor[K]) access(key K, now int64) {}
+
+func (e *randomEvictor[K]) remove(key K) {
+	i, ok := e.indexes[key]
+	if !ok {
+		return
+	}
+	last := len(e.keys) - 1
+	e.keys[i] = e.keys[last]
+	e.indexes[e.keys[i]] = i
+	e.keys[last] = *new(K)
+	e.keys = e.keys[:last]
+	delete(e.indexes, key)
+}
+
+func (e *randomEvictor[K]) victim() (K, int64, bool) {
+	if len(e.keys) == 0 {
+		return *new(K), 0, false
+	}
+	return e.keys[rand.Intn(len(e.keys))], rand.Int63(), true
+}
+
+// volatileEvictor only offers keys with an expiry to the wrapped evictor.
+type volatileEvictor[K comparable] struct {
+	evictor[K]
+}
+
+func (e *volatileEvictor[K]) add(key K, ttl time.Time, now int64) {
+	if ttl.Equal(NoExpiry) {
+		e.evictor.remove(key)
+	} else {
+		e.evictor.add(key, ttl, now)
+	}
+}
+
+// ttlEvictor evicts the keys closest to expiring first.
+type ttlEvictor[K comparable] struct {
+	heap ttlHeap[K]
+}
+
+type ttlItem[K comparable] struct {
+	key   K
+	ttl   int64
+	index int
+}
+
+type ttlHeap[K comparable] struct {
+	items   []*ttlItem[K]
+	indexes map[K]*ttlItem[K]
+}
+
+func (h *ttlHeap[K]) Len() int           { return len(h.items) }
+func (h *ttlHeap[K]) Less(i, j int) bool { return h.items[i].ttl < h.items[j].ttl }
+func (h *ttlHeap[K]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+func (h *ttlHeap[K]) Push(x any) {
+	item := x.(*ttlItem[K])
+	item.index = len(h.items)
+	h.items = append(h.items, item)
+}
+func (h *ttlHeap[K]) Pop() any {
+	last := len(h.items) - 1
+	item := h.items[last]
+	h.items[last] = nil
+	h.items = h.items[:last]
+	return item
+}
+
+func newTTLEvictor[K comparable]() *ttlEvictor[K] {
+	return &ttlEvictor[K]{heap: ttlHeap[K]{indexes: make(map[K]*ttlItem[K])}}
+}
+
+func (e *ttlEvictor[K]) add(key K, ttl time.Time, now int64) {
+	if ttl.Equal(NoExpiry) {
+		e.remove(key)
+		return
+	}
+	if item, ok := e.heap.indexes[key]; ok {
+		item.ttl = ttl.UnixNano()
+		heap.Fix(&e.heap, item.index)
+		return
+	}
+	item := &ttlItem[K]{key: key, ttl: ttl.UnixNano()}
+	e.heap.indexes[key] = item
+	heap.Push(&e.heap, item)
+}
+
+func (e *ttlEvictor[K]) access(key K, now int64) {}
+
+func (e *ttlEvictor[K]) remove(key K) {
+	if item, ok := e.heap.indexes[key]; ok {
+		heap.Remove(&e.heap, item.index)
+		delete(e.heap.indexes, key)
+	}
+}
+
+func (e *ttlEvictor[K]) victim() (K, int64, bool) {
+	if len(e.heap.items) == 0 {
+		return *new(K), 0, false
+	}
+	item := e.heap.items[0]
+	return item.key, item.ttl, true
+}
+
+// noEvictor never offers a victim, the map refuses new keys instead.
+type noEvictor[K comparable] struct{}
+
+func (noEvictor[K]) add(key K, ttl time.Time, now int64) {}
+func (noEvictor[K]) access(key K, now int64)             {}
+func (noEvictor[K]) remove(key K)                        {}
+func (noEvictor[K]) victim() (K, int64, bool)            { return *new(K), 0, false }
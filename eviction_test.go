@@ -0,0 +1,225 @@
+package expiringmap
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaxEntriesLRU(t *testing.T) {
+	var evicted []string
+	m := New(
+		WithMaxEntries[string, Animal](2),
+		WithRemovalListener(func(key string, value Animal, reason RemovalReason) {
+			if reason == RemovalEvicted {
+				evicted = append(evicted, key)
+			}
+		}),
+	)
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	m.Get("elephant")
+	time.Sleep(time.Millisecond)
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+
+	if m.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", m.Len())
+	}
+	if m.Has("monkey") || !m.Has("elephant") || !m.Has("tiger") {
+		t.Error("expected the least recently used key to be evicted.")
+	}
+	if len(evicted) != 1 || evicted[0] != "monkey" {
+		t.Errorf("expected monkey to be evicted, got %v", evicted)
+	}
+	if m.Stats().Evicted != 1 {
+		t.Errorf("expected 1 eviction, got %d", m.Stats().Evicted)
+	}
+
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+	m.Delete("elephant")
+	m.Set("zebra", Animal{"zebra"}, time.Now().Add(time.Minute))
+	if m.Len() != 2 || m.Stats().Evicted != 1 {
+		t.Error("replacing and deleting shouldn't cause evictions.")
+	}
+	m.Clear()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	if m.Len() != 2 {
+		t.Error("clearing should free capacity.")
+	}
+}
+
+func TestMaxEntriesRandom(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, int](10),
+		WithEvictionPolicy[string, int](EvictRandom),
+	)
+	for i := 0; i < 100; i++ {
+		m.Set(strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	if m.Len() != 10 {
+		t.Errorf("expected 10 entries, got %d", m.Len())
+	}
+	if m.Stats().Evicted != 90 {
+		t.Errorf("expected 90 evictions, got %d", m.Stats().Evicted)
+	}
+}
+
+func TestMaxEntriesConcurrent(t *testing.T) {
+	m := New(WithMaxEntries[string, int](100))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				key := strconv.Itoa(i*1000 + j)
+				m.Set(key, j, time.Now().Add(time.Minute))
+				m.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if m.Len() > 100 {
+		t.Errorf("expected at most 100 entries, got %d", m.Len())
+	}
+}
+
+func TestEvictionPolicyNames(t *testing.T) {
+	for _, name := range []string{"allkeys-lru", "allkeys-random", "volatile-lru", "volatile-random", "volatile-ttl", "noeviction", "arc", "clock", "slru"} {
+		policy, err := ParseEvictionPolicy(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.String() != name {
+			t.Errorf("expected %s, got %s", name, policy)
+		}
+	}
+	if _, err := ParseEvictionPolicy("allkeys-lfu"); err == nil {
+		t.Error("expected unknown policy error.")
+	}
+}
+
+func TestEvictVolatile(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, Animal](2),
+		WithEvictionPolicy[string, Animal](EvictVolatileLRU),
+	)
+	m.Put("elephant", Animal{"elephant"})
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+	if !m.Has("elephant") || m.Has("monkey") || !m.Has("tiger") {
+		t.Error("expected only keys with an expiry to be evicted.")
+	}
+
+	m.Put("tiger", Animal{"tiger"})
+	m.Put("zebra", Animal{"zebra"})
+	if m.Len() != 3 {
+		t.Errorf("expected the map to grow without volatile keys, got %d", m.Len())
+	}
+
+	// restoring an entry may change the location but not the instant
+	m.Set("lion", Animal{"lion"}, NoExpiry.In(time.UTC))
+	if m.Len() != 4 {
+		t.Errorf("expected a round tripped no expiry to stay non volatile, got %d keys", m.Len())
+	}
+}
+
+func TestEvictVolatileTTL(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, Animal](2),
+		WithEvictionPolicy[string, Animal](EvictVolatileTTL),
+	)
+	now := time.Now()
+	m.Set("elephant", Animal{"elephant"}, now.Add(2*time.Minute))
+	m.Set("monkey", Animal{"monkey"}, now.Add(time.Minute))
+	m.Expire("monkey", now.Add(3*time.Minute))
+	m.Set("tiger", Animal{"tiger"}, now.Add(4*time.Minute))
+	if m.Has("elephant") || !m.Has("monkey") || !m.Has("tiger") {
+		t.Error("expected the soonest expiring key to be evicted.")
+	}
+}
+
+func TestEvictNone(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, Animal](1),
+		WithEvictionPolicy[string, Animal](EvictNone),
+	)
+	if err := m.TrySet("elephant", Animal{"elephant"}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.TrySet("monkey", Animal{"monkey"}, time.Now().Add(time.Minute)); err != ErrFull {
+		t.Errorf("expected ErrFull, got %v", err)
+	}
+	if m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute)) || m.Has("monkey") {
+		t.Error("new keys should be refused while full.")
+	}
+	if err := m.TrySet("elephant", Animal{"elephant"}, time.Now().Add(time.Hour)); err != nil {
+		t.Error("existing keys can still be updated.")
+	}
+	if m.Stats().Rejected != 2 {
+		t.Errorf("expected 2 rejections, got %d", m.Stats().Rejected)
+	}
+	m.Delete("elephant")
+	if err := m.TrySet("monkey", Animal{"monkey"}, time.Now().Add(time.Minute)); err != nil {
+		t.Error("deleting should make room.")
+	}
+}
+
+func TestEvict(t *testing.T) {
+	m := New(WithMaxEntries[int, int](10))
+	for i := 0; i < 5; i++ {
+		m.Set(i, i, time.Now().Add(time.Minute))
+	}
+	m.Get(0)
+	if n := m.Evict(3); n != 3 {
+		t.Errorf("expected 3 evictions, got %d", n)
+	}
+	if !m.Has(0) || m.Len() != 2 {
+		t.Error("expected the least recently used to go first.")
+	}
+	if n := m.Evict(5); n != 2 || m.Len() != 0 {
+		t.Errorf("expected the rest to be evicted, got %d", n)
+	}
+	if m.Stats().Evicted != 5 {
+		t.Errorf("expected 5 evictions counted, got %d", m.Stats().Evicted)
+	}
+}
+
+func TestEvictUnbounded(t *testing.T) {
+	m := New[int, int]()
+	m.Set(1, 1, time.Now().Add(time.Minute))
+	m.Set(2, 2, time.Now().Add(time.Second))
+	if n := m.Evict(1); n != 1 || !m.Has(1) {
+		t.Error("expected the entry closest to expiry to be evicted.")
+	}
+
+	none := New(WithMaxEntries[int, int](10), WithEvictionPolicy[int, int](EvictNone))
+	none.Set(1, 1, time.Now().Add(time.Minute))
+	if n := none.Evict(1); n != 0 {
+		t.Errorf("expected EvictNone not to evict, got %d", n)
+	}
+}
+
+func TestEvictByPriority(t *testing.T) {
+	for _, policy := range []EvictionPolicy{EvictLRU, EvictClock, EvictARC} {
+		m := New(WithMaxEntries[string, int](3), WithEvictionPolicy[string, int](policy))
+		ttl := time.Now().Add(time.Minute)
+		m.SetEntry(Entry[string, int]{Key: "queen", TTL: ttl, Priority: 2})
+		m.SetEntry(Entry[string, int]{Key: "knight", TTL: ttl, Priority: 1})
+		for i := 0; i < 10; i++ {
+			m.Set("pawn:"+strconv.Itoa(i), i, ttl)
+		}
+		if !m.Has("queen") || !m.Has("knight") || m.Len() != 3 {
+			t.Errorf("%v: expected the prioritized keys to outlast the rest, got %d keys", policy, m.Len())
+		}
+		m.SetEntry(Entry[string, int]{Key: "king", TTL: ttl, Priority: 3})
+		m.SetEntry(Entry[string, int]{Key: "rook", TTL: ttl, Priority: 3})
+		if m.Has("knight") || !m.Has("queen") {
+			t.Errorf("%v: expected the lowest priority to be evicted first", policy)
+		}
+	}
+}
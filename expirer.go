@@ -0,0 +1,39 @@
+package expiringmap
+
+import "time"
+
+// NoExpiry is the ttl of values that never expire, such as values stored by
+// Put without any way to derive a ttl. It is the latest time JSON can encode,
+// so snapshots and exports of such values round trip.
+var NoExpiry = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// Expirer is implemented by values that carry their own expiry.
+type Expirer interface {
+	ExpiresAt() time.Time
+}
+
+// Put stores value taking its ttl from the value's ExpiresAt when it
+// implements Expirer, then from the map's TTL provider. Values without either
+// never expire.
+func (m *ExpiringMap[K, V]) Put(key K, value V) bool {
+	return m.Set(key, value, m.ttlFor(key, value))
+}
+
+func (m *ExpiringMap[K, V]) ttlFor(key K, value V) time.Time {
+	if expirer, ok := any(value).(Expirer); ok {
+		return expirer.ExpiresAt()
+	}
+	if m.config.ttlProvider != nil {
+		return m.config.ttlProvider(key, value)
+	}
+	return NoExpiry
+}
+
+// setLoaded stores a loaded value, loaders returning a zero ttl leave it to
+// the value or the TTL provider.
+func (m *ExpiringMap[K, V]) setLoaded(key K, value V, ttl time.Time) {
+	if ttl.IsZero() {
+		ttl = m.ttlFor(key, value)
+	}
+	m.Set(key, value, ttl)
+}
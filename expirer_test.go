@@ -0,0 +1,90 @@
+package expiringmap
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+func (l *lease) ExpiresAt() time.Time {
+	return l.expires
+}
+
+func TestPutExpirer(t *testing.T) {
+	m := New[string, *lease]()
+	expires := time.Now().Add(10 * time.Millisecond)
+	m.Put("elephant", &lease{"keeper", expires})
+
+	if ttl, ok := m.TTL("elephant"); !ok || !ttl.Equal(expires) {
+		t.Errorf("expected ttl from the value, got %v", ttl)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if m.Has("elephant") {
+		t.Error("lease should have expired.")
+	}
+}
+
+func TestPutWithoutExpiry(t *testing.T) {
+	m := New[string, Animal]()
+	if !m.Put("elephant", Animal{"elephant"}) {
+		t.Error("expected a new key.")
+	}
+	if ttl, ok := m.TTL("elephant"); !ok || ttl.Before(time.Now().AddDate(100, 0, 0)) {
+		t.Errorf("values without an expiry shouldn't expire, got %v", ttl)
+	}
+
+	var buf bytes.Buffer
+	if err := m.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := New[string, Animal]()
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, ok := restored.TTL("elephant"); !ok || !ttl.Equal(NoExpiry) {
+		t.Errorf("expected NoExpiry to survive a snapshot, got %v %v", ttl, ok)
+	}
+}
+
+func TestTTLProvider(t *testing.T) {
+	now := time.Now()
+	m := New(WithTTLProvider(func(key string, value Animal) time.Time {
+		if key == "elephant" {
+			return now.Add(time.Hour)
+		}
+		return now.Add(time.Minute)
+	}))
+	m.Put("elephant", Animal{"elephant"})
+	m.Put("monkey", Animal{"monkey"})
+
+	if ttl, _ := m.TTL("elephant"); !ttl.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected provided ttl, got %v", ttl)
+	}
+	if ttl, _ := m.TTL("monkey"); !ttl.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected provided ttl, got %v", ttl)
+	}
+
+	m.GetOrLoad(context.Background(), "tiger", func(_ context.Context, key string) (Animal, time.Time, error) {
+		return Animal{key}, time.Time{}, nil
+	})
+	if ttl, _ := m.TTL("tiger"); !ttl.Equal(now.Add(time.Minute)) {
+		t.Errorf("loads without a ttl should use the provider, got %v", ttl)
+	}
+}
+
+func TestTTLProviderExpirer(t *testing.T) {
+	expires := time.Now().Add(time.Second)
+	m := New(WithTTLProvider(func(string, *lease) time.Time {
+		return time.Now().Add(time.Hour)
+	}))
+	m.Put("elephant", &lease{"keeper", expires})
+	if ttl, _ := m.TTL("elephant"); !ttl.Equal(expires) {
+		t.Error("the value's own expiry should win over the provider.")
+	}
+}
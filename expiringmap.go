@@ -1,55 +1,208 @@
 package expiringmap
 
 import (
+	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/aicacia/go-cmap"
+	"github.com/aicacia/go-expiringmap/internal/singleflight"
 )
 
+const shardCount = 32
+
 type expiringMapVal[V any] struct {
-	val V
-	ttl time.Time
+	val     V
+	ttl     time.Time
+	created int64
+	warn    *time.Timer
+	meta    *entryMeta[V]
+	weight  int64
+	sum     uint64
+	stamp   *Stamp
+}
+
+func (item expiringMapVal[V]) expired(now time.Time) bool {
+	return item.ttl.Before(now)
+}
+
+func (item expiringMapVal[V]) stop() {
+	if item.warn != nil {
+		item.warn.Stop()
+	}
+}
+
+type ExpiringMap[K comparable, V any] struct {
+	table       *atomic.Pointer[[]*shard[K, V]]
+	hash        func(K) uint64
+	config      config[K, V]
+	subscribers *subscribers[K, V]
+	closers     *closers
+	loads       *singleflight.Group[K, V]
+	breakers    *ExpiringMap[K, breaker]
+	tombstones  *ExpiringMap[K, Stamp]
+	stats       *stats
+	size        *atomic.Int64
+	clock       *atomic.Uint64
+	weight      *atomic.Int64
+	callbacks   *atomic.Bool
+	producers   *atomic.Int64
+	evicting    *sync.Mutex
+	resizing    *sync.Mutex
+	resize      *resizeState
+	accesses    chan access[K]
+	readHooks   *readHooks[K]
+	takers      *takers
+}
+
+func New[K comparable, V any](options ...Option[K, V]) ExpiringMap[K, V] {
+	var c config[K, V]
+	for _, option := range options {
+		option(&c)
+	}
+	shards := c.newShards(shardCount)
+	m := &ExpiringMap[K, V]{
+		table:       &atomic.Pointer[[]*shard[K, V]]{},
+		hash:        newHasher[K](),
+		config:      c,
+		subscribers: &subscribers[K, V]{},
+		closers:     &closers{},
+		loads:       &singleflight.Group[K, V]{},
+		stats:       &stats{},
+		size:        &atomic.Int64{},
+		clock:       &atomic.Uint64{},
+		weight:      &atomic.Int64{},
+		callbacks:   &atomic.Bool{},
+		producers:   &atomic.Int64{},
+		evicting:    &sync.Mutex{},
+		resizing:    &sync.Mutex{},
+		resize:      &resizeState{},
+		readHooks:   &readHooks[K]{},
+		takers:      newTakers(),
+	}
+	m.table.Store(&shards)
+	// recording reads straight into the eviction policy needs the lock
+	m.config.readLocking = c.readLocking && (!c.bounded() || c.bufferedAccess)
+	if (c.lww || c.bus != nil) && c.origin == "" {
+		m.config.origin = newOrigin()
+	}
+	if c.tombstoneTTL > 0 {
+		tombstones := New(WithClock[K, Stamp](c.clock))
+		m.tombstones = &tombstones
+	}
+	if c.replaySize > 0 {
+		m.subscribers.replay = make([]Mutation[K, V], c.replaySize)
+	}
+	if c.breakerFailures > 0 {
+		breakers := New(WithClock[K, breaker](c.clock))
+		m.breakers = &breakers
+	}
+	if c.bus != nil {
+		m.closers.add(m.startInvalidationBus(c.bus))
+	}
+	if c.bounded() && c.bufferedAccess {
+		m.accesses = make(chan access[K], accessBufferSize)
+		m.closers.add(m.startAccessBuffer())
+	}
+	return *m
 }
 
-type ExpiringMap[K, V any] struct {
-	items cmap.CMap[K, expiringMapVal[V]]
+// Close stops any background work started by the map's options.
+func (m *ExpiringMap[K, V]) Close() error {
+	m.closers.close()
+	return nil
 }
 
-func New[K, V any]() ExpiringMap[K, V] {
-	return ExpiringMap[K, V]{
-		items: cmap.New[K, expiringMapVal[V]](),
+func (m *ExpiringMap[K, V]) now() time.Time {
+	if m.config.clock != nil {
+		return m.config.clock()
 	}
+	return time.Now()
 }
 
 func (m *ExpiringMap[K, V]) SetIfAbsent(key K, value V, ttl time.Time) bool {
-	return m.items.SetIfAbsent(key, expiringMapVal[V]{value, ttl})
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	if item, ok := s.items[key]; ok {
+		if !item.expired(m.now()) {
+			return false
+		}
+		m.expire(s, key, item)
+	}
+	return m.set(s, key, value, ttl)
 }
 
+// Set stores value and reports whether key is new. With the EvictNone policy
+// a new key is not stored while the map is full, use TrySet to tell.
 func (m *ExpiringMap[K, V]) Set(key K, value V, ttl time.Time) bool {
-	return m.items.Set(key, expiringMapVal[V]{value, ttl})
+	isNew, stored := m.trySet(key, value, ttl)
+	return isNew && stored
 }
 
-func (m *ExpiringMap[K, V]) GetOrSet(key K, value V, ttl time.Time) V {
-	newItem := expiringMapVal[V]{value, ttl}
-	item, _ := m.items.LoadOrStore(key, newItem)
-	if item.ttl.Before(time.Now()) {
-		m.items.Set(key, newItem)
-		return value
+// TrySet is Set returning ErrFull when the map is full under EvictNone.
+func (m *ExpiringMap[K, V]) TrySet(key K, value V, ttl time.Time) error {
+	if _, stored := m.trySet(key, value, ttl); !stored {
+		return ErrFull
 	}
-	return item.val
+	return nil
 }
 
-func (m *ExpiringMap[K, V]) Has(key K) bool {
-	if item, ok := m.items.Get(key); ok {
-		if item.ttl.Before(time.Now()) {
-			m.items.Delete(key)
-			return false
+func (m *ExpiringMap[K, V]) trySet(key K, value V, ttl time.Time) (bool, bool) {
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	isNew := true
+	if item, ok := s.items[key]; ok {
+		if item.expired(m.now()) {
+			m.expire(s, key, item)
 		} else {
-			return true
+			isNew = false
 		}
-	} else {
-		return false
 	}
+	return isNew, m.set(s, key, value, ttl)
+}
+
+// GetOrSet returns the live value for key, or stores value when there is
+// none. loaded reports whether the value returned was already present.
+func (m *ExpiringMap[K, V]) GetOrSet(key K, value V, ttl time.Time) (actual V, loaded bool) {
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	if item, ok := s.items[key]; ok {
+		if now := m.now(); !item.expired(now) {
+			m.accessed(s, key, now)
+			m.hit(s)
+			return m.read(key, item), true
+		}
+		m.expire(s, key, item)
+	}
+	m.miss(s)
+	m.set(s, key, value, ttl)
+	return value, false
+}
+
+// GetOrSetFunc is GetOrSet building the value with fn only when key is
+// absent. fn runs while the key's shard is locked so it must not call back
+// into the map.
+func (m *ExpiringMap[K, V]) GetOrSetFunc(key K, fn func() (V, time.Time)) (actual V, loaded bool) {
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	if item, ok := s.items[key]; ok {
+		if now := m.now(); !item.expired(now) {
+			m.accessed(s, key, now)
+			m.hit(s)
+			return m.read(key, item), true
+		}
+		m.expire(s, key, item)
+	}
+	m.miss(s)
+	value, ttl := fn()
+	m.set(s, key, value, ttl)
+	return value, false
+}
+
+func (m *ExpiringMap[K, V]) Has(key K) bool {
+	_, ok := m.Get(key)
+	return ok
 }
 
 func (m *ExpiringMap[K, V]) IsEmpty() bool {
@@ -57,84 +210,378 @@ func (m *ExpiringMap[K, V]) IsEmpty() bool {
 }
 
 func (m *ExpiringMap[K, V]) Get(key K) (V, bool) {
-	if item, ok := m.items.Get(key); ok {
-		if item.ttl.Before(time.Now()) {
-			m.items.Delete(key)
+	if m.config.readLocking {
+		if value, ok, done := m.getShared(key); done {
+			return value, ok
+		}
+	}
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := s.items[key]; ok {
+		if now := m.now(); item.expired(now) {
+			m.expire(s, key, item)
 		} else {
-			return item.val, true
+			m.accessed(s, key, now)
+			m.hit(s)
+			return m.read(key, item), true
 		}
 	}
+	m.miss(s)
 	return *new(V), false
 }
 
+func (m *ExpiringMap[K, V]) TTL(key K) (time.Time, bool) {
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := s.items[key]; ok {
+		if item.expired(m.now()) {
+			m.expire(s, key, item)
+		} else {
+			return item.ttl, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (m *ExpiringMap[K, V]) Expire(key K, ttl time.Time) bool {
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := s.items[key]; ok {
+		if item.expired(m.now()) {
+			m.expire(s, key, item)
+		} else {
+			item.stop()
+			old := item.ttl
+			item.ttl = ttl
+			item.warn = m.warnAt(key, ttl)
+			if m.config.lww {
+				item.stamp = m.nextStamp()
+			}
+			s.items[key] = item
+			s.expiryReplaced(old, ttl)
+			m.takers.stored(ttl)
+			if s.evictor != nil {
+				m.track(s, key, item, m.now().UnixNano())
+			}
+			m.notify(Mutation[K, V]{Op: MutationSet, Key: key, Val: item.val, TTL: ttl, Stamp: item.stamp})
+			return true
+		}
+	}
+	return false
+}
+
 func (m *ExpiringMap[K, V]) Delete(key K) bool {
-	return m.items.Delete(key)
+	return m.delete(key, false)
 }
 
 func (m *ExpiringMap[K, V]) Remove(key K) bool {
-	return m.items.Remove(key)
+	return m.Delete(key)
+}
+
+// RemoveIf deletes key if its live value satisfies cond, checked while the
+// key's shard is locked so no Set can slip in between. cond must not call
+// back into the map.
+func (m *ExpiringMap[K, V]) RemoveIf(key K, cond func(V) bool) bool {
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := m.liveItem(s, key); ok && cond(item.val) {
+		m.remove(s, key, item, RemovalDeleted, false)
+		return true
+	}
+	return false
 }
 
 func (m *ExpiringMap[K, V]) Range(f func(key K, value V) bool) {
-	now := time.Now()
-	m.items.Range(func(key K, value expiringMapVal[V]) bool {
-		if value.ttl.Before(now) {
-			m.items.Delete(key)
-			return true
-		} else {
-			return f(key, value.val)
+	var entries []cmap.Entry[K, V]
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		entries = m.appendLive(entries[:0], s, owns)
+	}, func() bool {
+		for _, entry := range entries {
+			if !f(entry.Key, entry.Val) {
+				return false
+			}
 		}
+		return true
 	})
 }
 
-func (m *ExpiringMap[K, V]) Iter() chan cmap.Entry[K, V] {
-	ch := make(chan cmap.Entry[K, V])
-	go func() {
-		m.Range(func(key K, value V) bool {
-			ch <- cmap.Entry[K, V]{
-				Key: key,
-				Val: value,
+// rangeBatchSize is how many entries RangeCtx visits between checks of its
+// context.
+const rangeBatchSize = 256
+
+// RangeCtx is Range returning ctx's error once it is done, checked before
+// each shard and every rangeBatchSize entries.
+func (m *ExpiringMap[K, V]) RangeCtx(ctx context.Context, f func(key K, value V) bool) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	var entries []cmap.Entry[K, V]
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		entries = m.appendLive(entries[:0], s, owns)
+	}, func() bool {
+		for i, entry := range entries {
+			if i > 0 && i%rangeBatchSize == 0 {
+				if err = ctx.Err(); err != nil {
+					return false
+				}
 			}
-			return true
-		})
-		close(ch)
-	}()
-	return ch
+			if !f(entry.Key, entry.Val) {
+				return false
+			}
+		}
+		err = ctx.Err()
+		return err == nil
+	})
+	return err
+}
+
+// Deprecated: Iter leaks its goroutine unless drained, use Iterator.
+func (m *ExpiringMap[K, V]) Iter() chan cmap.Entry[K, V] {
+	return produce(m, "Iter", func(key K, value V) cmap.Entry[K, V] {
+		return cmap.Entry[K, V]{
+			Key: key,
+			Val: value,
+		}
+	})
 }
 
 func (m *ExpiringMap[K, V]) Keys() chan K {
-	ch := make(chan K)
-	go func() {
-		m.Range(func(key K, _ V) bool {
-			ch <- key
-			return true
-		})
-		close(ch)
-	}()
-	return ch
+	return produce(m, "Keys", func(key K, _ V) K { return key })
 }
 
 func (m *ExpiringMap[K, V]) Values() chan V {
-	ch := make(chan V)
-	go func() {
-		m.Range(func(_ K, value V) bool {
-			ch <- value
-			return true
-		})
-		close(ch)
-	}()
-	return ch
+	return produce(m, "Values", func(_ K, value V) V { return value })
 }
 
 func (m *ExpiringMap[K, V]) Len() int {
 	count := 0
-	m.Range(func(_ K, _ V) bool {
-		count += 1
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				count += 1
+			}
+		}
+	}, nil)
+	return count
+}
+
+func (m *ExpiringMap[K, V]) Clear() {
+	m.clear(false)
+}
+
+// appendLive appends the live entries of s, which must be locked.
+func (m *ExpiringMap[K, V]) appendLive(entries []cmap.Entry[K, V], s *shard[K, V], owns keyFilter[K]) []cmap.Entry[K, V] {
+	now := m.now()
+	if entries == nil {
+		entries = make([]cmap.Entry[K, V], 0, len(s.items))
+	}
+	for key, item := range s.items {
+		if owns.skip(key) {
+			continue
+		}
+		if item.expired(now) {
+			m.expire(s, key, item)
+		} else {
+			entries = append(entries, cmap.Entry[K, V]{Key: key, Val: m.read(key, item)})
+		}
+	}
+	return entries
+}
+
+// RangeWhere is Range over the keys matching match, which is checked before
+// the value or its expiry is looked at so filtered scans skip most of the
+// work. match runs while a shard is locked so it must not call back into the
+// map, f may.
+func (m *ExpiringMap[K, V]) RangeWhere(match func(K) bool, f func(key K, value V) bool) {
+	var entries []cmap.Entry[K, V]
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		now := m.now()
+		entries = entries[:0]
+		for key, item := range s.items {
+			if !match(key) || owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				entries = append(entries, cmap.Entry[K, V]{Key: key, Val: m.read(key, item)})
+			}
+		}
+	}, func() bool {
+		for _, entry := range entries {
+			if !f(entry.Key, entry.Val) {
+				return false
+			}
+		}
 		return true
 	})
+}
+
+// deleteWhere deletes the live keys matching match and returns how many.
+func (m *ExpiringMap[K, V]) deleteWhere(match func(K) bool) int {
+	count := 0
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		now := m.now()
+		for key, item := range s.items {
+			if !match(key) || owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				m.remove(s, key, item, RemovalDeleted, false)
+				count++
+			}
+		}
+	}, nil)
 	return count
 }
 
-func (m *ExpiringMap[K, V]) Clear() {
-	m.items.Clear()
+func (m *ExpiringMap[K, V]) delete(key K, remote bool) bool {
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := s.items[key]; ok {
+		if !item.expired(m.now()) {
+			m.remove(s, key, item, RemovalDeleted, remote)
+			return true
+		}
+		m.expire(s, key, item)
+	}
+	if !remote && m.config.bus != nil {
+		m.notify(Mutation[K, V]{Op: MutationDelete, Key: key, absent: true})
+	}
+	return false
+}
+
+// Compute calls fn with the live value for key, if any, and stores the value
+// and ttl it returns, or removes the key when it returns false, all while the
+// key's shard is locked so no other write can slip in between. fn must not
+// call back into the map.
+func (m *ExpiringMap[K, V]) Compute(key K, fn func(value V, ok bool) (V, time.Time, bool)) {
+	m.compute(key, fn)
+}
+
+func (m *ExpiringMap[K, V]) compute(key K, fn func(value V, ok bool) (V, time.Time, bool)) {
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	item, ok := s.items[key]
+	if ok && item.expired(m.now()) {
+		m.expire(s, key, item)
+		item, ok = expiringMapVal[V]{}, false
+	}
+	var current V
+	if ok {
+		current = m.read(key, item)
+	}
+	value, ttl, keep := fn(current, ok)
+	if keep {
+		m.set(s, key, value, ttl)
+	} else if ok {
+		m.remove(s, key, item, RemovalDeleted, false)
+	}
+}
+
+func (m *ExpiringMap[K, V]) clear(remote bool) {
+	shards := m.lockAll()
+	for _, s := range shards {
+		if len(m.config.removalListeners) > 0 || m.config.nearExpiry != nil || m.callbacks.Load() {
+			for key, item := range s.items {
+				item.stop()
+				m.removed(key, item, RemovalCleared)
+			}
+		} else {
+			m.stats.cleared.Add(uint64(len(s.items)))
+		}
+		if s.evictor != nil {
+			m.size.Add(-int64(len(s.items)))
+			for _, item := range s.items {
+				m.weight.Add(-item.weight)
+			}
+			s.evictor = m.config.newEvictor(len(shards))
+		}
+		s.items = make(map[K]expiringMapVal[V])
+	}
+	m.notify(Mutation[K, V]{Op: MutationClear, Reason: RemovalCleared, remote: remote})
+	unlockAll(shards)
+}
+
+// set, remove and expire must be called with the shard locked, mutations are
+// published while the lock is held so subscribers see them in order.
+func (m *ExpiringMap[K, V]) set(s *shard[K, V], key K, value V, ttl time.Time) bool {
+	return m.store(s, key, expiringMapVal[V]{val: value, ttl: ttl, created: m.now().UnixNano()})
+}
+
+// store reports false when a new key is refused because the map is full.
+func (m *ExpiringMap[K, V]) store(s *shard[K, V], key K, item expiringMapVal[V]) bool {
+	if m.config.weigher != nil {
+		item.weight = m.config.weigher(key, item.val)
+	}
+	if m.config.guard {
+		item.sum = checksum(item.val)
+	}
+	if m.config.lww && item.stamp == nil {
+		item.stamp = m.nextStamp()
+	}
+	old, replaced := s.items[key]
+	if replaced {
+		m.guard(key, old)
+		old.stop()
+		m.weight.Add(item.weight - old.weight)
+		m.removed(key, old, RemovalReplaced)
+	} else if s.evictor != nil && !m.reserve(item.weight) {
+		m.stats.rejected.Add(1)
+		return false
+	}
+	item.warn = m.warnAt(key, item.ttl)
+	s.items[key] = item
+	if replaced {
+		s.expiryReplaced(old.ttl, item.ttl)
+	} else {
+		s.expiryAdded(item.ttl)
+	}
+	m.takers.stored(item.ttl)
+	if s.evictor != nil {
+		m.track(s, key, item, item.created)
+	}
+	m.stats.sets.Add(1)
+	m.notify(Mutation[K, V]{Op: MutationSet, Key: key, Val: item.val, TTL: item.ttl, Stamp: item.stamp})
+	return true
+}
+
+func (m *ExpiringMap[K, V]) remove(s *shard[K, V], key K, item expiringMapVal[V], reason RemovalReason, remote bool) {
+	m.drop(s, key, item)
+	stamp := item.stamp
+	if m.config.lww && reason == RemovalDeleted {
+		stamp = m.nextStamp()
+		if !remote {
+			m.bury(key, stamp)
+		}
+	}
+	m.notify(Mutation[K, V]{Op: MutationDelete, Key: key, Val: item.val, TTL: item.ttl, Reason: reason, Stamp: stamp, remote: remote})
+	m.removed(key, item, reason)
+}
+
+func (m *ExpiringMap[K, V]) drop(s *shard[K, V], key K, item expiringMapVal[V]) {
+	m.guard(key, item)
+	delete(s.items, key)
+	s.expiryRemoved(item.ttl)
+	item.stop()
+	if s.evictor != nil {
+		s.evictor.remove(key)
+		m.size.Add(-1)
+		m.weight.Add(-item.weight)
+	}
+}
+
+func (m *ExpiringMap[K, V]) expire(s *shard[K, V], key K, item expiringMapVal[V]) {
+	m.drop(s, key, item)
+	m.notify(Mutation[K, V]{Op: MutationExpired, Key: key, Val: item.val, TTL: item.ttl, Reason: RemovalExpired, Stamp: item.stamp})
+	m.removed(key, item, RemovalExpired)
 }
@@ -1,6 +1,8 @@
 package expiringmap
 
 import (
+	"context"
+	"errors"
 	"sort"
 	"strconv"
 	"sync"
@@ -75,14 +77,14 @@ func TestGetOrSet(t *testing.T) {
 	m := New[string, Animal]()
 
 	// Set a missing element.
-	val := m.GetOrSet("Money", Animal{"elephant"}, time.Now().Add(time.Minute))
-	if val.name != "elephant" {
+	val, loaded := m.GetOrSet("Money", Animal{"elephant"}, time.Now().Add(time.Minute))
+	if val.name != "elephant" || loaded {
 		t.Error("default item was not inserted.")
 	}
 
 	// Set a missing element.
-	oldVal := m.GetOrSet("Money", Animal{"lion"}, time.Now().Add(time.Minute))
-	if oldVal.name != "elephant" {
+	oldVal, loaded := m.GetOrSet("Money", Animal{"lion"}, time.Now().Add(time.Minute))
+	if oldVal.name != "elephant" || !loaded {
 		t.Error("previous item was not returned")
 	}
 
@@ -91,6 +93,21 @@ func TestGetOrSet(t *testing.T) {
 	}
 }
 
+func TestGetOrSetFunc(t *testing.T) {
+	m := New[string, Animal]()
+	calls := 0
+	build := func() (Animal, time.Time) {
+		calls++
+		return Animal{"elephant"}, time.Now().Add(time.Minute)
+	}
+	if v, loaded := m.GetOrSetFunc("Money", build); v.name != "elephant" || loaded {
+		t.Errorf("expected the built value, got %v", v)
+	}
+	if v, loaded := m.GetOrSetFunc("Money", build); v.name != "elephant" || !loaded || calls != 1 {
+		t.Errorf("expected fn to run only while absent, ran %d times", calls)
+	}
+}
+
 func TestHas(t *testing.T) {
 	m := New[string, Animal]()
 
@@ -131,6 +148,101 @@ func TestRemove(t *testing.T) {
 
 	// Remove a none existing element.
 	m.Remove("noone")
+
+	m.Set("lion", Animal{"lion"}, time.Now().Add(-time.Second))
+	if m.Remove("lion") {
+		t.Error("Expecting removing an expired item to report false.")
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	m := New[string, int]()
+	m.Set("token", 1, time.Now().Add(time.Minute))
+	m.Set("stale", 1, time.Now().Add(-time.Second))
+
+	if m.RemoveIf("token", func(v int) bool { return v == 2 }) || !m.Has("token") {
+		t.Error("expected a failing condition to keep the key.")
+	}
+	if !m.RemoveIf("token", func(v int) bool { return v == 1 }) || m.Has("token") {
+		t.Error("expected a passing condition to remove the key.")
+	}
+	if m.RemoveIf("stale", func(int) bool { return true }) {
+		t.Error("expired keys shouldn't count as removed.")
+	}
+}
+
+func TestCompute(t *testing.T) {
+	m := New[string, int]()
+	ttl := time.Now().Add(time.Minute)
+	incr := func(n int, ok bool) (int, time.Time, bool) { return n + 1, ttl, true }
+	m.Compute("elephant", incr)
+	m.Compute("elephant", incr)
+	if n, _ := m.Get("elephant"); n != 2 {
+		t.Errorf("expected 2, got %d", n)
+	}
+	m.Set("expired", 5, time.Now().Add(-time.Minute))
+	m.Compute("expired", func(n int, ok bool) (int, time.Time, bool) {
+		if ok {
+			t.Error("expected an expired value to be missing")
+		}
+		return n, ttl, false
+	})
+	m.Compute("elephant", func(n int, ok bool) (int, time.Time, bool) { return 0, ttl, false })
+	if m.Len() != 0 {
+		t.Errorf("expected computing false to remove the key, got %d keys", m.Len())
+	}
+}
+
+func TestRangeWhere(t *testing.T) {
+	m := New[int, int]()
+	for i := 0; i < 10; i++ {
+		m.Set(i, i*i, time.Now().Add(time.Minute))
+	}
+	m.Set(20, 0, time.Now().Add(-time.Second))
+
+	sum := 0
+	m.RangeWhere(func(key int) bool { return key%2 == 0 }, func(_ int, value int) bool {
+		sum += value
+		return true
+	})
+	if sum != 0+4+16+36+64 {
+		t.Errorf("expected the even squares, got %d", sum)
+	}
+	visited := 0
+	m.RangeWhere(func(int) bool { return true }, func(int, int) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("expected f returning false to stop, visited %d", visited)
+	}
+}
+
+func TestRangeCtx(t *testing.T) {
+	m := New[int, int]()
+	for i := 0; i < 10000; i++ {
+		m.Set(i, i, time.Now().Add(time.Minute))
+	}
+	count := 0
+	if err := m.RangeCtx(context.Background(), func(int, int) bool {
+		count++
+		return true
+	}); err != nil || count != 10000 {
+		t.Errorf("expected every entry, got %d %v", count, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	count = 0
+	err := m.RangeCtx(ctx, func(int, int) bool {
+		count++
+		if count == 10 {
+			cancel()
+		}
+		return true
+	})
+	if !errors.Is(err, context.Canceled) || count > 10+rangeBatchSize {
+		t.Errorf("expected the scan to stop promptly, got %d %v", count, err)
+	}
 }
 
 func TestCount(t *testing.T) {
@@ -359,3 +471,58 @@ func TestExpire(t *testing.T) {
 		t.Error("map should be empty.")
 	}
 }
+
+func TestTTL(t *testing.T) {
+	m := New[string, Animal]()
+
+	if _, ok := m.TTL("elephant"); ok {
+		t.Error("missing element shouldn't have a ttl.")
+	}
+
+	ttl := time.Now().Add(time.Minute)
+	m.Set("elephant", Animal{"elephant"}, ttl)
+
+	got, ok := m.TTL("elephant")
+	if !ok {
+		t.Error("expecting element to have a ttl.")
+	}
+	if !got.Equal(ttl) {
+		t.Error("ttl was modified.")
+	}
+}
+
+func TestExpireKey(t *testing.T) {
+	m := New[string, Animal]()
+
+	if m.Expire("elephant", time.Now().Add(time.Minute)) {
+		t.Error("missing element shouldn't be expired.")
+	}
+
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+
+	if !m.Expire("elephant", time.Now().Add(-time.Minute)) {
+		t.Error("expecting Expire to update an existing element.")
+	}
+	if m.Has("elephant") {
+		t.Error("element should have expired.")
+	}
+}
+
+func TestValueCopier(t *testing.T) {
+	m := New(WithValueCopier[string, []int](func(v []int) []int {
+		return append([]int(nil), v...)
+	}))
+	m.Set("elephant", []int{1, 2}, time.Now().Add(time.Minute))
+
+	v, _ := m.Get("elephant")
+	v[0] = 9
+	e, _ := m.GetEntry("elephant")
+	e.Val[1] = 9
+	m.Range(func(_ string, v []int) bool {
+		v[0] = 9
+		return true
+	})
+	if v, _ := m.GetOrSet("elephant", nil, time.Now()); v[0] != 1 || v[1] != 2 {
+		t.Errorf("expected reads to return copies, got %v", v)
+	}
+}
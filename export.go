@@ -0,0 +1,61 @@
+package expiringmap
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ExportRecord[K comparable, V any] struct {
+	Seq  uint64     `json:"seq"`
+	Op   MutationOp `json:"op"`
+	Key  K          `json:"key"`
+	Val  V          `json:"val"`
+	TTL  time.Time  `json:"ttl"`
+	Time time.Time  `json:"time"`
+}
+
+type ExportSink[K comparable, V any] interface {
+	Export(record ExportRecord[K, V]) error
+}
+
+// Producer is the shape of a message queue producer such as a Kafka client.
+// Records are keyed by the map key so per-key ordering survives partitioning.
+type Producer interface {
+	Produce(key, value []byte) error
+}
+
+type producerSink[K comparable, V any] struct {
+	producer Producer
+}
+
+func NewProducerSink[K comparable, V any](producer Producer) ExportSink[K, V] {
+	return &producerSink[K, V]{producer: producer}
+}
+
+func (s *producerSink[K, V]) Export(record ExportRecord[K, V]) error {
+	key, err := json.Marshal(record.Key)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(&record)
+	if err != nil {
+		return err
+	}
+	return s.producer.Produce(key, value)
+}
+
+// ExportTo writes every subsequent mutation to sink stamped with its sequence
+// number, see Mutation.Seq. The returned stop function ends the export, waits
+// for pending records and returns the first sink error.
+func (m *ExpiringMap[K, V]) ExportTo(sink ExportSink[K, V]) func() error {
+	return m.stream(false, func(mutation Mutation[K, V]) error {
+		return sink.Export(ExportRecord[K, V]{
+			Seq:  mutation.Seq,
+			Op:   mutation.Op,
+			Key:  mutation.Key,
+			Val:  mutation.Val,
+			TTL:  mutation.TTL,
+			Time: time.Now(),
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package expiringmap
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testProducer struct {
+	keys   []string
+	values [][]byte
+	err    error
+}
+
+func (p *testProducer) Produce(key, value []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.keys = append(p.keys, string(key))
+	p.values = append(p.values, value)
+	return nil
+}
+
+func TestExportTo(t *testing.T) {
+	m := New[string, Plant]()
+	m.Set("fern", Plant{"fern"}, time.Now().Add(time.Minute))
+
+	producer := &testProducer{}
+	stop := m.ExportTo(NewProducerSink[string, Plant](producer))
+	m.Set("cactus", Plant{"cactus"}, time.Now().Add(time.Minute))
+	m.Delete("fern")
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(producer.values) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(producer.values))
+	}
+	if producer.keys[0] != `"cactus"` {
+		t.Errorf("expected record to be keyed by map key, got %s", producer.keys[0])
+	}
+	for i, value := range producer.values {
+		var record ExportRecord[string, Plant]
+		if err := json.Unmarshal(value, &record); err != nil {
+			t.Fatal(err)
+		}
+		// records carry the map's sequence numbers, fern's set was the first
+		if record.Seq != uint64(i+2) {
+			t.Errorf("expected sequence %d, got %d", i+2, record.Seq)
+		}
+	}
+}
+
+func TestExportToError(t *testing.T) {
+	m := New[string, Plant]()
+	failure := errors.New("broker unavailable")
+	stop := m.ExportTo(NewProducerSink[string, Plant](&testProducer{err: failure}))
+	m.Set("cactus", Plant{"cactus"}, time.Now().Add(time.Minute))
+
+	if err := stop(); !errors.Is(err, failure) {
+		t.Errorf("expected sink error, got %v", err)
+	}
+}
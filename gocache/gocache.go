@@ -0,0 +1,249 @@
+package gocache
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+const (
+	NoExpiration      time.Duration = -1
+	DefaultExpiration time.Duration = 0
+)
+
+type Item struct {
+	Object     interface{}
+	Expiration int64
+}
+
+func (item Item) Expired() bool {
+	return item.Expiration > 0 && time.Now().UnixNano() > item.Expiration
+}
+
+// Cache has the API of github.com/patrickmn/go-cache backed by an ExpiringMap.
+// Like go-cache, the janitor started by New stops once the Cache is garbage
+// collected.
+type Cache struct {
+	*cache
+}
+
+type cache struct {
+	defaultExpiration time.Duration
+	// mutex is held exclusively by read-modify-write operations so plain
+	// writes can't slip between their read and write.
+	mutex     sync.RWMutex
+	items     expiringmap.ExpiringMap[string, interface{}]
+	onEvicted atomic.Pointer[func(string, interface{})]
+	stop      chan struct{}
+}
+
+// New returns a Cache whose items expire after defaultExpiration unless given
+// their own, and deletes expired items every cleanupInterval when it is
+// positive.
+func New(defaultExpiration, cleanupInterval time.Duration) *Cache {
+	if defaultExpiration == 0 {
+		defaultExpiration = NoExpiration
+	}
+	c := &cache{defaultExpiration: defaultExpiration}
+	c.items = expiringmap.New(expiringmap.WithRemovalListener(func(key string, value interface{}, reason expiringmap.RemovalReason) {
+		if reason != expiringmap.RemovalExpired && reason != expiringmap.RemovalDeleted {
+			return
+		}
+		if fn := c.onEvicted.Load(); fn != nil {
+			(*fn)(key, value)
+		}
+	}))
+	C := &Cache{c}
+	if cleanupInterval > 0 {
+		c.stop = make(chan struct{})
+		go c.janitor(cleanupInterval)
+		runtime.SetFinalizer(C, func(C *Cache) { close(C.stop) })
+	}
+	return C
+}
+
+func (c *cache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *cache) ttl(d time.Duration) time.Time {
+	if d == DefaultExpiration {
+		d = c.defaultExpiration
+	}
+	if d < 0 {
+		return expiringmap.NoExpiry
+	}
+	return time.Now().Add(d)
+}
+
+func (c *cache) Set(k string, x interface{}, d time.Duration) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	c.items.Set(k, x, c.ttl(d))
+}
+
+func (c *cache) SetDefault(k string, x interface{}) {
+	c.Set(k, x, DefaultExpiration)
+}
+
+// Add sets k only if it isn't already present.
+func (c *cache) Add(k string, x interface{}, d time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, loaded := c.items.LoadOrStoreWithTTL(k, x, c.ttl(d)); loaded {
+		return fmt.Errorf("Item %s already exists", k)
+	}
+	return nil
+}
+
+// Replace sets k only if it is already present.
+func (c *cache) Replace(k string, x interface{}, d time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.items.Has(k) {
+		return fmt.Errorf("Item %s doesn't exist", k)
+	}
+	c.items.Set(k, x, c.ttl(d))
+	return nil
+}
+
+func (c *cache) Get(k string) (interface{}, bool) {
+	return c.items.Get(k)
+}
+
+// GetWithExpiration also returns when the item expires, the zero time if it
+// never does.
+func (c *cache) GetWithExpiration(k string) (interface{}, time.Time, bool) {
+	entry, ok := c.items.GetEntry(k)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	if entry.TTL.Equal(expiringmap.NoExpiry) {
+		return entry.Val, time.Time{}, true
+	}
+	return entry.Val, entry.TTL, true
+}
+
+func (c *cache) Delete(k string) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	c.items.Delete(k)
+}
+
+func (c *cache) DeleteExpired() {
+	c.items.Len()
+}
+
+func (c *cache) OnEvicted(f func(string, interface{})) {
+	if f == nil {
+		c.onEvicted.Store(nil)
+	} else {
+		c.onEvicted.Store(&f)
+	}
+}
+
+func (c *cache) Items() map[string]Item {
+	items := make(map[string]Item)
+	c.items.RangeByExpiry(func(key string, value interface{}, expiresAt time.Time) bool {
+		item := Item{Object: value}
+		if !expiresAt.Equal(expiringmap.NoExpiry) {
+			item.Expiration = expiresAt.UnixNano()
+		}
+		items[key] = item
+		return true
+	})
+	return items
+}
+
+func (c *cache) ItemCount() int {
+	return c.items.Len()
+}
+
+func (c *cache) Flush() {
+	c.items.Clear()
+}
+
+// Increment adds n to the number stored at k, which may be of any integer or
+// float type.
+func (c *cache) Increment(k string, n int64) error {
+	return c.update(k, func(x interface{}) (interface{}, bool) {
+		switch v := x.(type) {
+		case int:
+			return v + int(n), true
+		case int8:
+			return v + int8(n), true
+		case int16:
+			return v + int16(n), true
+		case int32:
+			return v + int32(n), true
+		case int64:
+			return v + n, true
+		case uint:
+			return v + uint(n), true
+		case uintptr:
+			return v + uintptr(n), true
+		case uint8:
+			return v + uint8(n), true
+		case uint16:
+			return v + uint16(n), true
+		case uint32:
+			return v + uint32(n), true
+		case uint64:
+			return v + uint64(n), true
+		case float32:
+			return v + float32(n), true
+		case float64:
+			return v + float64(n), true
+		}
+		return nil, false
+	}, "an integer")
+}
+
+func (c *cache) Decrement(k string, n int64) error {
+	return c.Increment(k, -n)
+}
+
+// IncrementFloat adds n to the float32 or float64 stored at k.
+func (c *cache) IncrementFloat(k string, n float64) error {
+	return c.update(k, func(x interface{}) (interface{}, bool) {
+		switch v := x.(type) {
+		case float32:
+			return v + float32(n), true
+		case float64:
+			return v + n, true
+		}
+		return nil, false
+	}, "a float32 or float64")
+}
+
+func (c *cache) DecrementFloat(k string, n float64) error {
+	return c.IncrementFloat(k, -n)
+}
+
+func (c *cache) update(k string, fn func(interface{}) (interface{}, bool), kind string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.items.GetEntry(k)
+	if !ok {
+		return fmt.Errorf("Item %s not found", k)
+	}
+	x, ok := fn(entry.Val)
+	if !ok {
+		return fmt.Errorf("The value for %s is not %s", k, kind)
+	}
+	c.items.Set(k, x, entry.TTL)
+	return nil
+}
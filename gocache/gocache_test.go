@@ -0,0 +1,120 @@
+package gocache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c := New(DefaultExpiration, 0)
+	c.Set("elephant", "big", DefaultExpiration)
+	c.Set("mouse", "small", 10*time.Millisecond)
+
+	if x, ok := c.Get("elephant"); !ok || x != "big" {
+		t.Errorf("expected big, got %v", x)
+	}
+	if _, expiration, ok := c.GetWithExpiration("elephant"); !ok || !expiration.IsZero() {
+		t.Errorf("expected no expiration, got %v", expiration)
+	}
+	if _, expiration, ok := c.GetWithExpiration("mouse"); !ok || expiration.IsZero() {
+		t.Error("expected mouse to expire.")
+	}
+	items := c.Items()
+	if len(items) != 2 || items["elephant"].Expiration != 0 || items["mouse"].Expired() {
+		t.Errorf("unexpected items %v", items)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := c.Get("mouse"); ok {
+		t.Error("mouse should have expired.")
+	}
+	if c.ItemCount() != 1 {
+		t.Errorf("expected 1 item, got %d", c.ItemCount())
+	}
+	c.Flush()
+	if c.ItemCount() != 0 {
+		t.Error("expected the cache to be flushed.")
+	}
+}
+
+func TestAddReplace(t *testing.T) {
+	c := New(time.Minute, 0)
+	if err := c.Replace("elephant", 1, DefaultExpiration); err == nil {
+		t.Error("expected replacing a missing item to fail.")
+	}
+	if err := c.Add("elephant", 1, DefaultExpiration); err != nil {
+		t.Error(err)
+	}
+	if err := c.Add("elephant", 2, DefaultExpiration); err == nil {
+		t.Error("expected adding an existing item to fail.")
+	}
+	if err := c.Replace("elephant", 3, DefaultExpiration); err != nil {
+		t.Error(err)
+	}
+	if x, _ := c.Get("elephant"); x != 3 {
+		t.Errorf("expected 3, got %v", x)
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	c := New(DefaultExpiration, 0)
+	c.Set("count", int32(1), time.Minute)
+	c.Set("ratio", 1.5, DefaultExpiration)
+	c.Set("name", "elephant", DefaultExpiration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Increment("count", 2)
+		}()
+	}
+	wg.Wait()
+	if x, _ := c.Get("count"); x != int32(201) {
+		t.Errorf("expected 201, got %v", x)
+	}
+	if _, expiration, _ := c.GetWithExpiration("count"); expiration.IsZero() {
+		t.Error("incrementing should keep the expiration.")
+	}
+	if err := c.Decrement("count", 1); err != nil {
+		t.Error(err)
+	}
+	if err := c.IncrementFloat("ratio", 0.5); err != nil {
+		t.Error(err)
+	}
+	if x, _ := c.Get("ratio"); x != 2.0 {
+		t.Errorf("expected 2, got %v", x)
+	}
+	if err := c.Increment("name", 1); err == nil {
+		t.Error("expected incrementing a string to fail.")
+	}
+	if err := c.IncrementFloat("count", 1); err == nil {
+		t.Error("expected incrementing an integer as a float to fail.")
+	}
+	if err := c.Increment("missing", 1); err == nil {
+		t.Error("expected incrementing a missing item to fail.")
+	}
+}
+
+func TestOnEvicted(t *testing.T) {
+	c := New(DefaultExpiration, 5*time.Millisecond)
+	var mutex sync.Mutex
+	evicted := map[string]interface{}{}
+	c.OnEvicted(func(k string, x interface{}) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		evicted[k] = x
+	})
+	c.Set("elephant", 1, DefaultExpiration)
+	c.Set("mouse", 2, time.Millisecond)
+	c.Set("elephant", 3, DefaultExpiration)
+	c.Delete("elephant")
+	time.Sleep(30 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(evicted) != 2 || evicted["elephant"] != 3 || evicted["mouse"] != 2 {
+		t.Errorf("expected deleted and expired items only, got %v", evicted)
+	}
+}
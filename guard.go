@@ -0,0 +1,120 @@
+package expiringmap
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// WithMutationGuard checksums values when they are stored and checks them
+// whenever they are read or leave the map, calling onMutation, or panicking
+// when it is nil, if a value changed in the meantime. It follows pointers,
+// slices and maps so it is meant for tests and development, not production.
+func WithMutationGuard[K comparable, V any](onMutation func(key K)) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.guard = true
+		c.onMutation = onMutation
+	}
+}
+
+func (m *ExpiringMap[K, V]) guard(key K, item expiringMapVal[V]) {
+	if !m.config.guard || checksum(item.val) == item.sum {
+		return
+	}
+	if m.config.onMutation != nil {
+		m.config.onMutation(key)
+		return
+	}
+	panic(fmt.Sprintf("expiringmap: value for %v was mutated after it was stored", key))
+}
+
+// read returns item's value for handing to a caller.
+func (m *ExpiringMap[K, V]) read(key K, item expiringMapVal[V]) V {
+	m.guard(key, item)
+	if m.config.copier != nil {
+		return m.config.copier(item.val)
+	}
+	return item.val
+}
+
+func checksum(v any) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	writeValue(&h, reflect.ValueOf(v), map[uintptr]bool{})
+	return h.Sum64()
+}
+
+func writeValue(h *maphash.Hash, v reflect.Value, seen map[uintptr]bool) {
+	if !v.IsValid() {
+		h.WriteByte(0)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint(h, math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		writeUint(h, math.Float64bits(real(v.Complex())))
+		writeUint(h, math.Float64bits(imag(v.Complex())))
+	case reflect.String:
+		h.WriteString(v.String())
+		h.WriteByte(0)
+	case reflect.Pointer:
+		if v.IsNil() || seen[v.Pointer()] {
+			h.WriteByte(0)
+			return
+		}
+		seen[v.Pointer()] = true
+		h.WriteByte(1)
+		writeValue(h, v.Elem(), seen)
+	case reflect.Interface:
+		writeValue(h, v.Elem(), seen)
+	case reflect.Slice, reflect.Array:
+		writeUint(h, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			writeValue(h, v.Index(i), seen)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			writeValue(h, v.Field(i), seen)
+		}
+	case reflect.Map:
+		// map order is random so entries are summed
+		writeUint(h, uint64(v.Len()))
+		sums := make([]uint64, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var entry maphash.Hash
+			entry.SetSeed(seed)
+			writeValue(&entry, iter.Key(), seen)
+			writeValue(&entry, iter.Value(), seen)
+			sums = append(sums, entry.Sum64())
+		}
+		sort.Slice(sums, func(i, j int) bool { return sums[i] < sums[j] })
+		for _, sum := range sums {
+			writeUint(h, sum)
+		}
+	default:
+		// funcs, channels and unsafe pointers are compared by identity
+		writeUint(h, uint64(v.Pointer()))
+	}
+}
+
+func writeUint(h *maphash.Hash, x uint64) {
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(x >> (8 * i))
+	}
+	h.Write(buf[:])
+}
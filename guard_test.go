@@ -0,0 +1,69 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+type herd struct {
+	names  []string
+	counts map[string]int
+	leader *Animal
+}
+
+func TestMutationGuard(t *testing.T) {
+	var mutated []string
+	m := New(WithMutationGuard[string, *herd](func(key string) {
+		mutated = append(mutated, key)
+	}))
+	ttl := time.Now().Add(time.Minute)
+	m.Set("elephants", &herd{names: []string{"a"}, counts: map[string]int{"a": 1, "b": 2}, leader: &Animal{"a"}}, ttl)
+	m.Set("monkeys", &herd{names: []string{"b"}}, ttl)
+	m.Set("tigers", &herd{counts: map[string]int{"c": 1}}, ttl)
+
+	m.Get("elephants")
+	m.Range(func(string, *herd) bool { return true })
+	if len(mutated) != 0 {
+		t.Fatalf("expected no mutations, got %v", mutated)
+	}
+
+	elephants, _ := m.Get("elephants")
+	elephants.leader.name = "b"
+	m.Get("elephants")
+	monkeys, _ := m.Get("monkeys")
+	monkeys.names[0] = "c"
+	m.Delete("monkeys")
+	tigers, _ := m.Get("tigers")
+	tigers.counts["c"] = 2
+	m.Set("tigers", tigers, ttl)
+	if len(mutated) != 3 || mutated[0] != "elephants" || mutated[1] != "monkeys" || mutated[2] != "tigers" {
+		t.Errorf("expected every mutation to be caught, got %v", mutated)
+	}
+}
+
+func TestMutationGuardPanics(t *testing.T) {
+	m := New(WithMutationGuard[string, []int](nil))
+	m.Set("elephant", []int{1}, time.Now().Add(time.Minute))
+	v, _ := m.Get("elephant")
+	v[0] = 2
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic.")
+		}
+	}()
+	m.Get("elephant")
+}
+
+func TestChecksumCycles(t *testing.T) {
+	type node struct {
+		next *node
+		val  int
+	}
+	a := &node{val: 1}
+	a.next = a
+	sum := checksum(a)
+	a.val = 2
+	if checksum(a) == sum {
+		t.Error("expected the checksum to change.")
+	}
+}
@@ -0,0 +1,214 @@
+package expiringmap
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+var seed = maphash.MakeSeed()
+
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func newHasher[K comparable]() func(K) uint64 {
+	var zero K
+	t := reflect.TypeOf(zero)
+	if t != nil {
+		switch t.Kind() {
+		case reflect.String:
+			return func(key K) uint64 {
+				return maphash.String(seed, *(*string)(unsafe.Pointer(&key)))
+			}
+		case reflect.Float32:
+			return func(key K) uint64 {
+				f := *(*float32)(unsafe.Pointer(&key))
+				if f == 0 {
+					f = 0
+				}
+				return mix(uint64(math.Float32bits(f)))
+			}
+		case reflect.Float64:
+			return func(key K) uint64 {
+				f := *(*float64)(unsafe.Pointer(&key))
+				if f == 0 {
+					f = 0
+				}
+				return mix(math.Float64bits(f))
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Uintptr, reflect.Bool, reflect.Pointer, reflect.Chan:
+			switch t.Size() {
+			case 1:
+				return func(key K) uint64 { return mix(uint64(*(*uint8)(unsafe.Pointer(&key)))) }
+			case 2:
+				return func(key K) uint64 { return mix(uint64(*(*uint16)(unsafe.Pointer(&key)))) }
+			case 4:
+				return func(key K) uint64 { return mix(uint64(*(*uint32)(unsafe.Pointer(&key)))) }
+			case 8:
+				return func(key K) uint64 { return mix(*(*uint64)(unsafe.Pointer(&key))) }
+			}
+		case reflect.Struct, reflect.Array:
+			var ops []hashOp
+			if collectHashOps(t, 0, &ops) {
+				return func(key K) uint64 {
+					return hashFields(unsafe.Pointer(&key), ops)
+				}
+			}
+		}
+	}
+	return func(key K) uint64 {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		writeKey(&h, reflect.ValueOf(&key).Elem())
+		return h.Sum64()
+	}
+}
+
+// writeKey hashes v so that keys equal under == hash equally, walking
+// interfaces by their dynamic type and hashing pointers by address as ==
+// compares them.
+func writeKey(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Int()))
+		h.Write(buf[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], v.Uint())
+		h.Write(buf[:])
+	case reflect.Float32, reflect.Float64:
+		writeFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeFloat(h, real(c))
+		writeFloat(h, imag(c))
+	case reflect.String:
+		h.WriteString(v.String())
+		h.WriteByte(0)
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		binary.LittleEndian.PutUint64(buf[:], uint64(v.Pointer()))
+		h.Write(buf[:])
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		e := v.Elem()
+		h.WriteString(e.Type().String())
+		h.WriteByte(0)
+		writeKey(h, e)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).Name != "_" {
+				writeKey(h, v.Field(i))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			writeKey(h, v.Index(i))
+		}
+	default:
+		// as a built in map would
+		panic("expiringmap: hash of unhashable type " + v.Type().String())
+	}
+}
+
+func writeFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		f = 0
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+	h.Write(buf[:])
+}
+
+const maxHashOps = 64
+
+// hashOp hashes one field of a struct or array key found at offset, kind is
+// String, Float32, Float64 or Uint8 for fixed size values.
+type hashOp struct {
+	offset uintptr
+	size   uintptr
+	kind   reflect.Kind
+}
+
+func collectHashOps(t reflect.Type, offset uintptr, ops *[]hashOp) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Float32, reflect.Float64:
+		*ops = append(*ops, hashOp{offset, t.Size(), t.Kind()})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr, reflect.Bool, reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		*ops = append(*ops, hashOp{offset, t.Size(), reflect.Uint8})
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			// == ignores blank fields
+			if f.Name == "_" {
+				continue
+			}
+			if !collectHashOps(f.Type, offset+f.Offset, ops) {
+				return false
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			if !collectHashOps(t.Elem(), offset+uintptr(i)*t.Elem().Size(), ops) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+	return len(*ops) <= maxHashOps
+}
+
+// hashFields hashes each field in place so struct keys don't allocate, field
+// bytes are written individually because padding may hold anything.
+func hashFields(p unsafe.Pointer, ops []hashOp) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	var buf [8]byte
+	for _, op := range ops {
+		field := unsafe.Add(p, op.offset)
+		switch op.kind {
+		case reflect.String:
+			h.WriteString(*(*string)(field))
+			h.WriteByte(0)
+		case reflect.Float32:
+			f := *(*float32)(field)
+			if f == 0 {
+				f = 0
+			}
+			binary.LittleEndian.PutUint32(buf[:], math.Float32bits(f))
+			h.Write(buf[:4])
+		case reflect.Float64:
+			f := *(*float64)(field)
+			if f == 0 {
+				f = 0
+			}
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+			h.Write(buf[:8])
+		default:
+			copy(buf[:], unsafe.Slice((*byte)(field), op.size))
+			h.Write(buf[:op.size])
+		}
+	}
+	return h.Sum64()
+}
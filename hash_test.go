@@ -0,0 +1,126 @@
+package expiringmap
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type animalName string
+
+type animalKey struct {
+	kind string
+	id   int
+}
+
+func TestHasherNamedTypes(t *testing.T) {
+	hash := newHasher[animalName]()
+	if hash("elephant") != hash(animalName("elephant")) {
+		t.Error("equal keys should hash equally.")
+	}
+	if hash("elephant") == hash("monkey") {
+		t.Error("different keys should hash differently.")
+	}
+}
+
+func TestHasherNegativeZero(t *testing.T) {
+	hash := newHasher[float64]()
+	if hash(0) != hash(math.Copysign(0, -1)) {
+		t.Error("0 and -0 are equal keys and should hash equally.")
+	}
+}
+
+func TestStructKeys(t *testing.T) {
+	m := New[animalKey, Animal]()
+	m.Set(animalKey{"elephant", 1}, Animal{"elephant"}, time.Now().Add(time.Minute))
+
+	if _, ok := m.Get(animalKey{"elephant", 1}); !ok {
+		t.Error("expecting struct key to be found.")
+	}
+	if _, ok := m.Get(animalKey{"elephant", 2}); ok {
+		t.Error("different struct key shouldn't be found.")
+	}
+}
+
+type zooKey struct {
+	zoo    string
+	animal animalKey
+	weight float64
+	pens   [2]uint16
+}
+
+func TestHasherStructFields(t *testing.T) {
+	hash := newHasher[zooKey]()
+	a := zooKey{"berlin", animalKey{"elephant", 1}, 0, [2]uint16{1, 2}}
+	b := zooKey{"berlin", animalKey{"elephant", 1}, math.Copysign(0, -1), [2]uint16{1, 2}}
+	if hash(a) != hash(b) {
+		t.Error("equal struct keys should hash equally.")
+	}
+	b.pens[1] = 3
+	if hash(a) == hash(b) {
+		t.Error("different struct keys should hash differently.")
+	}
+	if hash(zooKey{zoo: "ab", animal: animalKey{kind: "c"}}) == hash(zooKey{zoo: "a", animal: animalKey{kind: "bc"}}) {
+		t.Error("string fields should be delimited.")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { hash(a) }); allocs != 0 {
+		t.Errorf("expected struct keys to hash without allocating, got %v", allocs)
+	}
+}
+
+func TestHasherInterfaceFields(t *testing.T) {
+	type key struct {
+		id any
+	}
+	hash := newHasher[key]()
+	if hash(key{1}) != hash(key{1}) {
+		t.Error("struct keys with interface fields should hash equally.")
+	}
+	if hash(key{0.0}) != hash(key{math.Copysign(0, -1)}) {
+		t.Error("0 and -0 in interface fields are equal and should hash equally.")
+	}
+	if hash(key{1}) == hash(key{int64(1)}) {
+		t.Error("interface fields holding different types should hash differently.")
+	}
+}
+
+type named struct {
+	name *string
+}
+
+func (n named) String() string {
+	return *n.name
+}
+
+func TestHasherAgreesWithEquality(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	m := New[any, int]()
+	m.Set(0.0, 1, time.Now().Add(time.Minute))
+	if _, ok := m.Get(negZero); !ok {
+		t.Error("expected -0 to find the value stored under 0")
+	}
+	m.Set(complex(0, 0), 2, time.Now().Add(time.Minute))
+	if v, ok := m.Get(complex(negZero, negZero)); !ok || v != 2 {
+		t.Errorf("expected complex -0 to find 2, got %d %v", v, ok)
+	}
+
+	type wrapper struct {
+		A any
+	}
+	w := New[wrapper, int]()
+	w.Set(wrapper{0.0}, 1, time.Now().Add(time.Minute))
+	w.Set(wrapper{negZero}, 2, time.Now().Add(time.Minute))
+	if w.Len() != 1 {
+		t.Errorf("expected equal struct keys to be stored once, got %d", w.Len())
+	}
+
+	// a key's hash mustn't follow its String method or its pointers
+	s := "elephant"
+	key := named{&s}
+	n := New[any, int]()
+	n.Set(key, 1, time.Now().Add(time.Minute))
+	s = "monkey"
+	if _, ok := n.Get(key); !ok {
+		t.Error("expected a key whose pointer target changed to still be found")
+	}
+}
@@ -0,0 +1,93 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := make(cacheControl)
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			name, arg, _ := strings.Cut(directive, "=")
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+func (cc cacheControl) seconds(name string) (time.Duration, bool) {
+	arg, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// ExpiresAt returns when a response with header stops being fresh according
+// to its Cache-Control max-age (less any Age) or Expires header. ok is false
+// for responses that must not be cached.
+func ExpiresAt(header http.Header, now time.Time) (time.Time, bool) {
+	cc := parseCacheControl(header)
+	if cc.has("no-store") || cc.has("no-cache") {
+		return time.Time{}, false
+	}
+	if maxAge, ok := cc.seconds("max-age"); ok {
+		if age, err := strconv.ParseInt(header.Get("Age"), 10, 64); err == nil && age > 0 {
+			maxAge -= time.Duration(age) * time.Second
+		}
+		if maxAge <= 0 {
+			return time.Time{}, false
+		}
+		return now.Add(maxAge), true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil || !t.After(now) {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// SetExpiryHeaders renders the remaining lifetime of an entry expiring at ttl
+// as Cache-Control max-age and Expires headers.
+func SetExpiryHeaders(header http.Header, ttl time.Time, now time.Time) {
+	remaining := ttl.Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	var directives []string
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			name, _, _ := strings.Cut(directive, "=")
+			switch strings.ToLower(name) {
+			case "", "max-age":
+			default:
+				directives = append(directives, directive)
+			}
+		}
+	}
+	directives = append(directives, "max-age="+strconv.FormatInt(int64(remaining/time.Second), 10))
+	header.Set("Cache-Control", strings.Join(directives, ", "))
+	header.Set("Expires", ttl.UTC().Format(http.TimeFormat))
+}
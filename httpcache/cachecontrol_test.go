@@ -0,0 +1,72 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func TestExpiresAt(t *testing.T) {
+	now := time.Date(2024, 7, 25, 12, 0, 0, 0, time.UTC)
+
+	header := http.Header{}
+	header.Set("Cache-Control", "public, max-age=60")
+	header.Set("Age", "10")
+	if ttl, ok := ExpiresAt(header, now); !ok || !ttl.Equal(now.Add(50*time.Second)) {
+		t.Errorf("expected max-age less age, got %v %v", ttl, ok)
+	}
+
+	header = http.Header{}
+	header.Set("Expires", now.Add(time.Hour).Format(http.TimeFormat))
+	if ttl, ok := ExpiresAt(header, now); !ok || !ttl.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected Expires header, got %v %v", ttl, ok)
+	}
+
+	header = http.Header{}
+	header.Set("Cache-Control", "no-store, max-age=60")
+	if _, ok := ExpiresAt(header, now); ok {
+		t.Error("no-store responses shouldn't be cacheable.")
+	}
+
+	if _, ok := ExpiresAt(http.Header{}, now); ok {
+		t.Error("responses without freshness information shouldn't be cacheable.")
+	}
+}
+
+func TestSetExpiryHeaders(t *testing.T) {
+	now := time.Date(2024, 7, 25, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("Cache-Control", "public, max-age=3600")
+
+	SetExpiryHeaders(header, now.Add(90*time.Second), now)
+
+	if got := header.Get("Cache-Control"); got != "public, max-age=90" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+	if got := header.Get("Expires"); got != "Thu, 25 Jul 2024 12:01:30 GMT" {
+		t.Errorf("unexpected Expires %q", got)
+	}
+	if ttl, ok := ExpiresAt(header, now); !ok || !ttl.Equal(now.Add(90*time.Second)) {
+		t.Error("rendered headers should round trip.")
+	}
+}
+
+func TestMiddlewareExpiryHeaders(t *testing.T) {
+	cache := expiringmap.New[string, *Entry]()
+	m := NewMiddleware(&cache, time.Minute)
+	m.ExpiryHeaders = true
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("elephant"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, ok := ExpiresAt(w.Header(), time.Now()); !ok {
+		t.Errorf("expected cached response to carry its remaining ttl, got %q", w.Header().Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,181 @@
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+// Middleware caches handler responses keyed by method, path and query, and the
+// request headers named in the response's Vary header. Responses to requests
+// carrying an Authorization or Cookie header are only cached when they say
+// they may be shared, and bodies over MaxBodySize are not cached at all.
+type Middleware struct {
+	TTL         time.Duration
+	MaxBodySize int64
+	// Key derives the cache key for a request, requests for which it returns
+	// an empty string are not cached.
+	Key func(r *http.Request) string
+	// OnStore is called after a response has been cached.
+	OnStore func(key string, entry *Entry)
+	// ExpiryHeaders renders the remaining lifetime of cached responses as
+	// Cache-Control max-age and Expires headers.
+	ExpiryHeaders bool
+	cache         *expiringmap.ExpiringMap[string, *Entry]
+}
+
+func NewMiddleware(cache *expiringmap.ExpiringMap[string, *Entry], ttl time.Duration) *Middleware {
+	return &Middleware{
+		TTL:         ttl,
+		MaxBodySize: DefaultMaxBodySize,
+		Key:         RequestKey,
+		cache:       cache,
+	}
+}
+
+func RequestKey(r *http.Request) string {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return ""
+	}
+	key := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		key += "?" + r.URL.Query().Encode()
+	}
+	return key
+}
+
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := m.Key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if entry, ok := lookupEntry(m.cache, key, r.Header); ok {
+			m.writeEntry(w, key, entry)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK, limit: m.MaxBodySize}
+		next.ServeHTTP(rec, r)
+		if !rec.cacheable() {
+			return
+		}
+		private := r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+		if private && !sharedWithAuthorization(w.Header()) {
+			return
+		}
+		entry := &Entry{
+			StatusCode: rec.status,
+			Header:     w.Header().Clone(),
+			Body:       rec.body.Bytes(),
+			vary:       varyHeaders(w.Header()),
+		}
+		// the response has been written to the client already, so it only
+		// carries the expiry headers once served from the cache
+		storeEntry(m.cache, key, entry, r.Header, time.Now().Add(m.TTL))
+		if m.OnStore != nil {
+			m.OnStore(key, entry)
+		}
+	})
+}
+
+// Invalidate drops the cached response for r, every variant of it if it
+// varies.
+func (m *Middleware) Invalidate(r *http.Request) bool {
+	if key := m.Key(r); key != "" {
+		return m.invalidate(func(k string) bool { return k == key }) != 0
+	}
+	return false
+}
+
+// InvalidatePath drops every cached response for path regardless of method
+// or query.
+func (m *Middleware) InvalidatePath(path string) int {
+	return m.invalidate(func(key string) bool {
+		_, rest, _ := strings.Cut(key, " ")
+		return rest == path || strings.HasPrefix(rest, path+"?")
+	})
+}
+
+// invalidate deletes the entries whose key, less any Vary suffix, matches,
+// counting the responses but not the Vary markers.
+func (m *Middleware) invalidate(match func(key string) bool) int {
+	var keys []string
+	m.cache.Range(func(key string, _ *Entry) bool {
+		if base, _, _ := strings.Cut(key, "\x00"); match(base) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+	count := 0
+	for _, key := range keys {
+		entry, _ := m.cache.Get(key)
+		marker := entry != nil && len(entry.vary) != 0 && !strings.Contains(key, "\x00")
+		if m.cache.Delete(key) && !marker {
+			count += 1
+		}
+	}
+	return count
+}
+
+func (m *Middleware) writeEntry(w http.ResponseWriter, key string, entry *Entry) {
+	header := w.Header()
+	for name, values := range entry.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	if m.ExpiryHeaders {
+		if ttl, ok := m.cache.TTL(key); ok {
+			SetExpiryHeaders(header, ttl, time.Now())
+		}
+	}
+	w.WriteHeader(entry.StatusCode)
+	w.Write(entry.Body)
+}
+
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	// limit caps the body buffered, past it the body is dropped and the
+	// response is only passed through.
+	limit    int64
+	overflow bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	if !r.overflow {
+		if int64(r.body.Len())+int64(len(b)) > r.limit {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) cacheable() bool {
+	if r.overflow || !cacheableStatus(r.status) {
+		return false
+	}
+	header := r.Header()
+	if header.Get("Set-Cookie") != "" || header.Get("Vary") == "*" {
+		return false
+	}
+	cc := parseCacheControl(header)
+	return !cc.has("no-store") && !cc.has("private")
+}
@@ -0,0 +1,134 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func TestMiddleware(t *testing.T) {
+	cache := expiringmap.New[string, *Entry]()
+	m := NewMiddleware(&cache, time.Minute)
+	hits := 0
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits += 1
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "animal %s", r.URL.Query().Get("name"))
+	}))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	if w := serve(http.MethodGet, "/animals?name=elephant&b=1"); w.Body.String() != "animal elephant" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+	w := serve(http.MethodGet, "/animals?b=1&name=elephant")
+	if w.Body.String() != "animal elephant" || w.Header().Get("Content-Type") != "text/plain" {
+		t.Error("expected cached response with headers.")
+	}
+	if hits != 1 {
+		t.Errorf("expected a single handler call, got %d", hits)
+	}
+
+	serve(http.MethodPost, "/animals?name=elephant")
+	serve(http.MethodPost, "/animals?name=elephant")
+	if hits != 3 {
+		t.Error("POST requests shouldn't be cached.")
+	}
+
+	if n := m.InvalidatePath("/animals"); n != 1 {
+		t.Errorf("expected 1 invalidated response, got %d", n)
+	}
+	serve(http.MethodGet, "/animals?name=elephant&b=1")
+	if hits != 4 {
+		t.Error("expected invalidated response to be regenerated.")
+	}
+}
+
+func TestMiddlewareSkipsUncacheable(t *testing.T) {
+	cache := expiringmap.New[string, *Entry]()
+	m := NewMiddleware(&cache, time.Minute)
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "private")
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/private", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/error", nil))
+	if !cache.IsEmpty() {
+		t.Error("uncacheable responses shouldn't be stored.")
+	}
+}
+
+func TestMiddlewareVaryAndPrivate(t *testing.T) {
+	cache := expiringmap.New[string, *Entry]()
+	m := NewMiddleware(&cache, time.Minute)
+	m.MaxBodySize = 8
+	hits := 0
+	handler := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits += 1
+		switch r.URL.Path {
+		case "/vary":
+			w.Header().Set("Vary", "Accept-Language")
+			fmt.Fprintf(w, "hi %s", r.Header.Get("Accept-Language"))
+		case "/public":
+			w.Header().Set("Cache-Control", "public")
+			fmt.Fprint(w, "shared")
+		case "/large":
+			fmt.Fprint(w, "more than eight bytes")
+		default:
+			fmt.Fprintf(w, "for %s", r.Header.Get("Authorization"))
+		}
+	}))
+	serve := func(target string, header ...string) string {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	serve("/vary", "Accept-Language", "en")
+	if got := serve("/vary", "Accept-Language", "fr"); got != "hi fr" || hits != 2 {
+		t.Errorf("expected a varied header to miss, got %q after %d calls", got, hits)
+	}
+	if got := serve("/vary", "Accept-Language", "en"); got != "hi en" || hits != 2 {
+		t.Errorf("expected the matching variant, got %q after %d calls", got, hits)
+	}
+	if n := m.InvalidatePath("/vary"); n != 2 {
+		t.Errorf("expected 2 invalidated variants, got %d", n)
+	}
+
+	serve("/me", "Authorization", "alice")
+	if got := serve("/me", "Authorization", "bob"); got != "for bob" {
+		t.Errorf("expected an authorized response not to be shared, got %q", got)
+	}
+	serve("/me", "Cookie", "session=alice")
+	if !cache.IsEmpty() {
+		t.Error("expected responses to requests with credentials not to be stored.")
+	}
+	serve("/public", "Authorization", "alice")
+	if got := serve("/public"); got != "shared" || hits != 6 {
+		t.Errorf("expected a public response to be shared, got %q after %d calls", got, hits)
+	}
+
+	if got := serve("/large"); got != "more than eight bytes" {
+		t.Errorf("expected the large body to pass through, got %q", got)
+	}
+	if _, ok := cache.Get("GET /large"); ok {
+		t.Error("expected a body over MaxBodySize not to be stored.")
+	}
+}
@@ -0,0 +1,181 @@
+package httpcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+const DefaultMaxBodySize = 1 << 20
+
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	vary       []string
+}
+
+// Transport is an http.RoundTripper caching GET responses by URL and the
+// request headers named in the response's Vary header, for as long as the
+// response's max-age or Expires allow. Responses to requests carrying an
+// Authorization header are only cached when they say they may be shared.
+type Transport struct {
+	Transport   http.RoundTripper
+	MaxBodySize int64
+	cache       *expiringmap.ExpiringMap[string, *Entry]
+}
+
+func NewTransport(cache *expiringmap.ExpiringMap[string, *Entry], transport http.RoundTripper) *Transport {
+	return &Transport{
+		Transport:   transport,
+		MaxBodySize: DefaultMaxBodySize,
+		cache:       cache,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+	reqCC := parseCacheControl(req.Header)
+	bypass := reqCC.has("no-cache") || reqCC.has("no-store")
+
+	key := req.URL.String()
+	if !bypass {
+		if entry, ok := lookupEntry(t.cache, key, req.Header); ok {
+			return entry.response(req), nil
+		}
+	}
+
+	res, err := t.transport().RoundTrip(req)
+	if err != nil || reqCC.has("no-store") || !cacheableStatus(res.StatusCode) {
+		return res, err
+	}
+	if req.Header.Get("Authorization") != "" && !sharedWithAuthorization(res.Header) {
+		return res, nil
+	}
+	ttl, ok := ExpiresAt(res.Header, time.Now())
+	if !ok || res.Header.Get("Vary") == "*" {
+		return res, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, t.MaxBodySize+1))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.MaxBodySize {
+		res.Body = readCloser{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+		return res, nil
+	}
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	storeEntry(t.cache, key, &Entry{
+		StatusCode: res.StatusCode,
+		Header:     res.Header.Clone(),
+		Body:       body,
+		vary:       varyHeaders(res.Header),
+	}, req.Header, ttl)
+	return res, nil
+}
+
+func (t *Transport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// lookupEntry finds the response cached under key for a request with header,
+// following the Vary marker stored under key to the variant it selects.
+func lookupEntry(cache *expiringmap.ExpiringMap[string, *Entry], key string, header http.Header) (*Entry, bool) {
+	entry, ok := cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if len(entry.vary) == 0 {
+		return entry, true
+	}
+	return cache.Get(varyKey(key, entry.vary, header))
+}
+
+// storeEntry caches entry under key, or, when it varies, a marker naming the
+// varied headers under key and entry under the variant header selects.
+func storeEntry(cache *expiringmap.ExpiringMap[string, *Entry], key string, entry *Entry, header http.Header, ttl time.Time) {
+	if len(entry.vary) != 0 {
+		cache.Set(key, &Entry{vary: entry.vary}, ttl)
+		cache.Set(varyKey(key, entry.vary, header), entry, ttl)
+	} else {
+		cache.Set(key, entry, ttl)
+	}
+}
+
+func (e *Entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func cacheableStatus(status int) bool {
+	switch status {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusNoContent,
+		http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusNotFound,
+		http.StatusMethodNotAllowed, http.StatusGone, http.StatusRequestURITooLong,
+		http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// sharedWithAuthorization reports whether a response to a request with an
+// Authorization header may be stored by a shared cache, RFC 9111 section 3.5.
+func sharedWithAuthorization(header http.Header) bool {
+	cc := parseCacheControl(header)
+	return cc.has("public") || cc.has("must-revalidate") || cc.has("s-maxage")
+}
+
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func varyKey(key string, vary []string, header http.Header) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range vary {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(header.Values(name), ","))
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func newTestTransport(t *testing.T, handler http.HandlerFunc) (*http.Client, string, *atomic.Int64) {
+	var hits atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	cache := expiringmap.New[string, *Entry]()
+	return &http.Client{Transport: NewTransport(&cache, nil)}, server.URL, &hits
+}
+
+func get(t *testing.T, client *http.Client, url string, header ...string) string {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+	return string(body)
+}
+
+func TestTransportCachesMaxAge(t *testing.T) {
+	client, url, hits := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprint(w, "elephant")
+	})
+
+	for i := 0; i < 3; i++ {
+		if body := get(t, client, url); body != "elephant" {
+			t.Errorf("unexpected body %q", body)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected a single origin request, got %d", hits.Load())
+	}
+
+	get(t, client, url, "Cache-Control", "no-cache")
+	if hits.Load() != 2 {
+		t.Error("no-cache requests should bypass the cache.")
+	}
+}
+
+func TestTransportNoStore(t *testing.T) {
+	client, url, hits := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		fmt.Fprint(w, "elephant")
+	})
+
+	get(t, client, url)
+	get(t, client, url)
+	if hits.Load() != 2 {
+		t.Errorf("expected no-store responses to not be cached, got %d hits", hits.Load())
+	}
+}
+
+func TestTransportVary(t *testing.T) {
+	client, url, hits := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"))
+	})
+
+	if body := get(t, client, url, "Accept-Language", "en"); body != "en" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if body := get(t, client, url, "Accept-Language", "fr"); body != "fr" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if body := get(t, client, url, "Accept-Language", "en"); body != "en" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("expected one origin request per variant, got %d", hits.Load())
+	}
+}
+
+func TestTransportAuthorization(t *testing.T) {
+	client, url, hits := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/public" {
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	})
+
+	if body := get(t, client, url, "Authorization", "elephant"); body != "elephant" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if body := get(t, client, url, "Authorization", "monkey"); body != "monkey" {
+		t.Errorf("expected an authorized response to not be shared, got %q", body)
+	}
+	get(t, client, url+"/public", "Authorization", "elephant")
+	if body := get(t, client, url+"/public", "Authorization", "monkey"); body != "elephant" {
+		t.Errorf("expected a public response to be cached, got %q", body)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("expected 3 origin requests, got %d", hits.Load())
+	}
+}
+
+func TestTransportCachedStatus(t *testing.T) {
+	client, url, _ := newTestTransport(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	get(t, client, url)
+	res, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.Status != "404 Not Found" {
+		t.Errorf("expected the cached status line to match net/http, got %q", res.Status)
+	}
+}
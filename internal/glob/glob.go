@@ -0,0 +1,108 @@
+// Package glob matches Redis style glob patterns, where * matches any run of
+// characters including /, ? any one character, [...] a character class and \
+// escapes the next character.
+package glob
+
+import (
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validate reports a malformed pattern.
+func Validate(pattern string) error {
+	// path.Match reports malformed patterns the same way, / aside
+	if _, err := path.Match(strings.ReplaceAll(pattern, "/", "_"), ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Match reports whether s matches pattern. A * is matched by remembering
+// where it was and, on a later mismatch, retrying with it eating one more
+// rune, so matching takes O(len(pattern)*len(s)) rather than backtracking
+// exponentially. A malformed class never matches.
+func Match(pattern, s string) bool {
+	p, i := 0, 0
+	star, resume := -1, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				star, resume = p, i
+				p++
+				continue
+			case '?':
+				_, size := utf8.DecodeRuneInString(s[i:])
+				p, i = p+1, i+size
+				continue
+			case '[':
+				r, size := utf8.DecodeRuneInString(s[i:])
+				end, ok, closed := matchClass(pattern[p+1:], r)
+				if !closed {
+					return false
+				}
+				if ok {
+					p, i = p+1+end, i+size
+					continue
+				}
+			default:
+				c, n := pattern[p], 1
+				if c == '\\' && p+1 < len(pattern) {
+					c, n = pattern[p+1], 2
+				}
+				if s[i] == c {
+					p, i = p+n, i+1
+					continue
+				}
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[resume:])
+		resume += size
+		p, i = star+1, resume
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass matches r against the class at the start of pattern, just after
+// the [, returning the length of the class including the ] and whether the ]
+// was there at all.
+func matchClass(pattern string, r rune) (int, bool, bool) {
+	i := 0
+	negate := false
+	if i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!') {
+		negate = true
+		i++
+	}
+	matched := false
+	for first := true; i < len(pattern) && (first || pattern[i] != ']'); first = false {
+		lo, size := classChar(pattern[i:])
+		i += size
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size = classChar(pattern[i+1:])
+			i += 1 + size
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+	if i >= len(pattern) {
+		return 0, false, false
+	}
+	return i + 1, matched != negate, true
+}
+
+func classChar(pattern string) (rune, int) {
+	if pattern[0] == '\\' && len(pattern) > 1 {
+		r, size := utf8.DecodeRuneInString(pattern[1:])
+		return r, 1 + size
+	}
+	return utf8.DecodeRuneInString(pattern)
+}
@@ -0,0 +1,105 @@
+package singleflight
+
+import "sync"
+
+type call[V any] struct {
+	wg  sync.WaitGroup
+	val V
+	err error
+}
+
+// Group de-duplicates concurrent calls for the same key, callers arriving
+// while a call is in flight wait for and share its result.
+type Group[K comparable, V any] struct {
+	mutex sync.Mutex
+	calls map[K]*call[V]
+}
+
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (V, error, bool) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &call[V]{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mutex.Unlock()
+
+	defer func() {
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		c.wg.Done()
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// DoMany is Do for several keys at once. Keys already in flight wait for and
+// share those calls' results, fn is called once with the rest and returns
+// their values, any key it leaves out fails with missing.
+func (g *Group[K, V]) DoMany(keys []K, missing error, fn func(keys []K) (map[K]V, error)) (map[K]V, map[K]error) {
+	g.mutex.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	calls := make(map[K]*call[V], len(keys))
+	owned := make(map[K]*call[V])
+	var mine []K
+	for _, key := range keys {
+		if _, ok := calls[key]; ok {
+			continue
+		}
+		if c, ok := g.calls[key]; ok {
+			calls[key] = c
+			continue
+		}
+		c := &call[V]{}
+		c.wg.Add(1)
+		g.calls[key], calls[key], owned[key] = c, c, c
+		mine = append(mine, key)
+	}
+	g.mutex.Unlock()
+
+	if len(mine) > 0 {
+		func() {
+			defer func() {
+				g.mutex.Lock()
+				for key := range owned {
+					delete(g.calls, key)
+				}
+				g.mutex.Unlock()
+				for _, c := range owned {
+					c.wg.Done()
+				}
+			}()
+			values, err := fn(mine)
+			for key, c := range owned {
+				if err != nil {
+					c.err = err
+				} else if value, ok := values[key]; ok {
+					c.val = value
+				} else {
+					c.err = missing
+				}
+			}
+		}()
+	}
+
+	values := make(map[K]V, len(calls))
+	errs := make(map[K]error)
+	for key, c := range calls {
+		c.wg.Wait()
+		if c.err != nil {
+			errs[key] = c.err
+		} else {
+			values[key] = c.val
+		}
+	}
+	return values, errs
+}
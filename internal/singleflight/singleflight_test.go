@@ -0,0 +1,69 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	var g Group[string, int]
+	var calls atomic.Int32
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			v, err, _ := g.Do("elephant", func() (int, error) {
+				calls.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return 42, nil
+			})
+			if v != 42 || err != nil {
+				t.Errorf("unexpected result %d %v", v, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected a single call, got %d", calls.Load())
+	}
+}
+
+func TestDoMany(t *testing.T) {
+	var g Group[string, int]
+	missing := errors.New("missing")
+	inFlight, release := make(chan struct{}), make(chan struct{})
+	go g.Do("elephant", func() (int, error) {
+		close(inFlight)
+		<-release
+		return 1, nil
+	})
+	<-inFlight
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+
+	var loaded []string
+	values, errs := g.DoMany([]string{"elephant", "monkey", "tiger", "monkey"}, missing, func(keys []string) (map[string]int, error) {
+		loaded = keys
+		return map[string]int{"monkey": 2}, nil
+	})
+	if len(loaded) != 2 || loaded[0] != "monkey" || loaded[1] != "tiger" {
+		t.Errorf("expected only the keys not in flight to be loaded once each, got %v", loaded)
+	}
+	if values["elephant"] != 1 || values["monkey"] != 2 {
+		t.Errorf("expected the shared and loaded values, got %v", values)
+	}
+	if errs["tiger"] != missing || len(errs) != 1 {
+		t.Errorf("expected tiger to be missing, got %v", errs)
+	}
+}
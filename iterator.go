@@ -0,0 +1,70 @@
+package expiringmap
+
+import "github.com/aicacia/go-cmap"
+
+// Iterator walks the live entries of a map one shard at a time. Each shard is
+// snapshotted when the iterator reaches it, so it never holds a lock between
+// calls to Next and can be abandoned at any point.
+type Iterator[K comparable, V any] struct {
+	m       *ExpiringMap[K, V]
+	pending []pendingShard[K, V]
+	entries []cmap.Entry[K, V]
+	pos     int
+}
+
+// pendingShard is a shard the iterator has yet to reach, owns limits it to
+// the keys of the moved shard it was found under.
+type pendingShard[K comparable, V any] struct {
+	s    *shard[K, V]
+	owns keyFilter[K]
+}
+
+func (m *ExpiringMap[K, V]) Iterator() *Iterator[K, V] {
+	roots := m.roots()
+	pending := make([]pendingShard[K, V], len(roots))
+	for i, s := range roots {
+		pending[len(roots)-1-i] = pendingShard[K, V]{s: s}
+	}
+	return &Iterator[K, V]{m: m, pending: pending}
+}
+
+func (it *Iterator[K, V]) Next() (K, V, bool) {
+	for it.pos >= len(it.entries) {
+		if it.m == nil || len(it.pending) == 0 {
+			return *new(K), *new(V), false
+		}
+		var zero cmap.Entry[K, V]
+		for i := range it.entries {
+			it.entries[i] = zero
+		}
+		it.entries = it.entries[:0]
+		it.pos = 0
+		next := it.pending[len(it.pending)-1]
+		it.pending = it.pending[:len(it.pending)-1]
+		s := next.s
+		s.lock()
+		if s.moved {
+			owns := next.owns
+			inRange := func(key K) bool {
+				return s.owns(it.m.hash(key)) && !owns.skip(key)
+			}
+			for i := len(s.children) - 1; i >= 0; i-- {
+				it.pending = append(it.pending, pendingShard[K, V]{s.children[i], inRange})
+			}
+		} else {
+			it.entries = it.m.appendLive(it.entries, s, next.owns)
+		}
+		s.unlock()
+	}
+	entry := it.entries[it.pos]
+	it.pos += 1
+	return entry.Key, entry.Val, true
+}
+
+// Close ends iteration and releases the current snapshot.
+func (it *Iterator[K, V]) Close() {
+	it.m = nil
+	it.pending = nil
+	it.entries = nil
+	it.pos = 0
+}
@@ -0,0 +1,59 @@
+package expiringmap
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIteratorNext(t *testing.T) {
+	m := New[string, Animal]()
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		m.Set(key, Animal{key}, time.Now().Add(time.Minute))
+	}
+	m.Set("expired", Animal{"expired"}, time.Now().Add(-time.Minute))
+
+	it := m.Iterator()
+	defer it.Close()
+	var keys []string
+	for {
+		key, value, ok := it.Next()
+		if !ok {
+			break
+		}
+		if key != value.name {
+			t.Errorf("value doesn't match key %s", key)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) != 100 {
+		t.Fatalf("expected 100 keys, got %d", len(keys))
+	}
+	sort.Strings(keys)
+	for i := 1; i < len(keys); i++ {
+		if keys[i] == keys[i-1] {
+			t.Errorf("duplicate key %s", keys[i])
+		}
+	}
+	if _, _, ok := it.Next(); ok {
+		t.Error("exhausted iterator should stay exhausted.")
+	}
+}
+
+func TestIteratorClose(t *testing.T) {
+	m := New[string, Animal]()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+
+	it := m.Iterator()
+	if _, _, ok := it.Next(); !ok {
+		t.Fatal("expected an entry.")
+	}
+	it.Close()
+	if _, _, ok := it.Next(); ok {
+		t.Error("closed iterator shouldn't return entries.")
+	}
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+}
@@ -0,0 +1,79 @@
+package expiringmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ExportJSONL writes the live entries of the map to w as JSON, one Entry per
+// line. Entries are copied out a shard at a time, so memory use is bounded by
+// the largest shard rather than the map.
+func (m *ExpiringMap[K, V]) ExportJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	var entries []Entry[K, V]
+	var err error
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		now := m.now()
+		for key, item := range s.items {
+			if !item.expired(now) && !owns.skip(key) {
+				item.val = m.read(key, item)
+				entries = append(entries, newEntry(key, item))
+			}
+		}
+	}, func() bool {
+		for i := range entries {
+			if err = enc.Encode(&entries[i]); err != nil {
+				return false
+			}
+		}
+		entries = entries[:0]
+		return true
+	})
+	return err
+}
+
+// LineError reports a line ImportJSONL couldn't decode, lines count from 1.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("expiringmap: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ImportJSONL sets the entries read from r, one JSON Entry per line as
+// written by ExportJSONL, skipping blank lines and expired entries. Lines
+// that fail to decode are skipped and reported together as LineErrors once r
+// is exhausted. It returns the number of entries set.
+func (m *ExpiringMap[K, V]) ImportJSONL(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	var errs []error
+	imported := 0
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e Entry[K, V]
+			if uerr := json.Unmarshal(line, &e); uerr != nil {
+				errs = append(errs, &LineError{Line: n, Err: uerr})
+			} else if !e.TTL.Before(m.now()) {
+				m.SetEntry(e)
+				imported++
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				errs = append(errs, err)
+			}
+			return imported, errors.Join(errs...)
+		}
+	}
+}
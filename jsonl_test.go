@@ -0,0 +1,76 @@
+package expiringmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportJSONL(t *testing.T) {
+	m := New[string, Plant]()
+	ttl := time.Now().Add(time.Minute).Round(0)
+	m.Set("fern", Plant{"fern"}, ttl)
+	m.Set("cactus", Plant{"cactus"}, ttl)
+	m.Set("moss", Plant{"moss"}, time.Now().Add(-time.Minute))
+
+	var buf bytes.Buffer
+	if err := m.ExportJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Entry[string, Plant]
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Key != e.Val.Name || !e.TTL.Equal(ttl) {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		seen[e.Key] = true
+	}
+	if len(seen) != 2 || !seen["fern"] || !seen["cactus"] {
+		t.Errorf("expected the live entries one per line, got %v", seen)
+	}
+}
+
+func TestImportJSONL(t *testing.T) {
+	ttl := time.Now().Add(time.Minute).Format(time.RFC3339Nano)
+	stale := time.Now().Add(-time.Minute).Format(time.RFC3339Nano)
+	input := `{"key":"fern","val":{"name":"fern"},"ttl":"` + ttl + `"}
+not json
+
+{"key":"moss","val":{"name":"moss"},"ttl":"` + stale + `"}
+{"key":"cactus","val":{"name":"cactus"},"ttl":"` + ttl + `"}
+{"key":"ivy","val":"ivy","ttl":"` + ttl + `"}`
+
+	m := New[string, Plant]()
+	n, err := m.ImportJSONL(strings.NewReader(input))
+	if n != 2 || m.Len() != 2 || !m.Has("fern") || !m.Has("cactus") {
+		t.Errorf("expected the 2 live entries to be imported, got %d", n)
+	}
+	var lines []int
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			lines = append(lines, lineErr.Line)
+		}
+	}
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 6 {
+		t.Errorf("expected lines 2 and 6 to be reported, got %v", lines)
+	}
+
+	// an export imports back as it was
+	var buf bytes.Buffer
+	if err := m.ExportJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := New[string, Plant]()
+	if n, err := restored.ImportJSONL(&buf); n != 2 || err != nil {
+		t.Errorf("expected the export to import, got %d %v", n, err)
+	}
+}
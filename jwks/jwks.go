@@ -0,0 +1,248 @@
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/httpcache"
+	"github.com/aicacia/go-expiringmap/internal/singleflight"
+)
+
+const (
+	DefaultRefreshInterval    = time.Hour
+	DefaultMinRefreshInterval = time.Minute
+)
+
+var ErrKeyNotFound = errors.New("jwks: key not found")
+
+type Key struct {
+	KeyID     string
+	Algorithm string
+	Use       string
+	PublicKey crypto.PublicKey
+}
+
+type Set struct {
+	Keys    []Key
+	Fetched time.Time
+}
+
+func (s *Set) Key(kid string) (Key, bool) {
+	for _, key := range s.Keys {
+		if key.KeyID == kid {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Cache fetches JWKS documents and keeps them for as long as their
+// Cache-Control or Expires headers allow, falling back to RefreshInterval.
+// Concurrent refreshes of the same document share a single request.
+type Cache struct {
+	Client             *http.Client
+	RefreshInterval    time.Duration
+	MinRefreshInterval time.Duration
+	cache              *expiringmap.ExpiringMap[string, *Set]
+	group              singleflight.Group[string, *Set]
+}
+
+func New(cache *expiringmap.ExpiringMap[string, *Set]) *Cache {
+	return &Cache{
+		RefreshInterval:    DefaultRefreshInterval,
+		MinRefreshInterval: DefaultMinRefreshInterval,
+		cache:              cache,
+	}
+}
+
+func (c *Cache) Get(ctx context.Context, url string) (*Set, error) {
+	if set, ok := c.cache.Get(url); ok {
+		return set, nil
+	}
+	return c.refresh(ctx, url)
+}
+
+// Key returns the key with kid from the document at url. Unknown key ids
+// trigger a refresh, at most once per MinRefreshInterval, to pick up rotated
+// keys.
+func (c *Cache) Key(ctx context.Context, url, kid string) (Key, error) {
+	set, err := c.Get(ctx, url)
+	if err != nil {
+		return Key{}, err
+	}
+	if key, ok := set.Key(kid); ok {
+		return key, nil
+	}
+	if time.Since(set.Fetched) < c.MinRefreshInterval {
+		return Key{}, ErrKeyNotFound
+	}
+	if set, err = c.refresh(ctx, url); err != nil {
+		return Key{}, err
+	}
+	if key, ok := set.Key(kid); ok {
+		return key, nil
+	}
+	return Key{}, ErrKeyNotFound
+}
+
+func (c *Cache) refresh(ctx context.Context, url string) (*Set, error) {
+	set, err, _ := c.group.Do(url, func() (*Set, error) {
+		set, ttl, err := c.fetch(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		c.cache.Set(url, set, ttl)
+		return set, nil
+	})
+	return set, err
+}
+
+func (c *Cache) fetch(ctx context.Context, url string) (*Set, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("jwks: fetching %s: %s", url, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	set, err := Parse(body)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := time.Now()
+	set.Fetched = now
+	ttl, ok := httpcache.ExpiresAt(res.Header, now)
+	if !ok {
+		ttl = now.Add(c.RefreshInterval)
+	}
+	return set, ttl, nil
+}
+
+type jsonKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Parse decodes a JWKS document, keys of unsupported types are skipped.
+func Parse(data []byte) (*Set, error) {
+	var doc struct {
+		Keys []jsonKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	set := &Set{Keys: make([]Key, 0, len(doc.Keys))}
+	for _, jk := range doc.Keys {
+		publicKey, err := jk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", jk.Kid, err)
+		}
+		if publicKey == nil {
+			continue
+		}
+		set.Keys = append(set.Keys, Key{
+			KeyID:     jk.Kid,
+			Algorithm: jk.Alg,
+			Use:       jk.Use,
+			PublicKey: publicKey,
+		})
+	}
+	return set, nil
+}
+
+func (jk *jsonKey) publicKey() (crypto.PublicKey, error) {
+	switch jk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jk.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBigInt(jk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jk.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jk.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
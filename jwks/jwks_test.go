@@ -0,0 +1,114 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTestServer(t *testing.T, kids *atomic.Value) (string, *atomic.Int32) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		w.Header().Set("Cache-Control", "max-age=300")
+		fmt.Fprintf(w, `{"keys":[
+			{"kty":"RSA","kid":%q,"alg":"RS256","n":%q,"e":%q},
+			{"kty":"EC","kid":"ec","crv":"P-256","x":%q,"y":%q},
+			{"kty":"oct","kid":"secret","k":"c2VjcmV0"}
+		]}`,
+			kids.Load().(string),
+			b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+			b64(ecKey.X.Bytes()), b64(ecKey.Y.Bytes()))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &fetches
+}
+
+func TestCacheKey(t *testing.T) {
+	var kids atomic.Value
+	kids.Store("rsa-1")
+	url, fetches := newTestServer(t, &kids)
+
+	cache := expiringmap.New[string, *Set]()
+	c := New(&cache)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := c.Key(context.Background(), url, "rsa-1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, ok := key.PublicKey.(*rsa.PublicKey); !ok {
+				t.Error("expected an rsa public key.")
+			}
+		}()
+	}
+	wg.Wait()
+	if fetches.Load() != 1 {
+		t.Errorf("expected a single fetch, got %d", fetches.Load())
+	}
+
+	key, err := c.Key(context.Background(), url, "ec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := key.PublicKey.(*ecdsa.PublicKey); !ok {
+		t.Error("expected an ecdsa public key.")
+	}
+	if ttl, _ := cache.TTL(url); time.Until(ttl) < 299*time.Second {
+		t.Error("expected ttl to follow Cache-Control.")
+	}
+}
+
+func TestCacheKeyRotation(t *testing.T) {
+	var kids atomic.Value
+	kids.Store("rsa-1")
+	url, fetches := newTestServer(t, &kids)
+
+	cache := expiringmap.New[string, *Set]()
+	c := New(&cache)
+	c.MinRefreshInterval = 0
+
+	if _, err := c.Key(context.Background(), url, "rsa-1"); err != nil {
+		t.Fatal(err)
+	}
+	kids.Store("rsa-2")
+	if _, err := c.Key(context.Background(), url, "rsa-2"); err != nil {
+		t.Fatal(err)
+	}
+	if fetches.Load() != 2 {
+		t.Errorf("expected unknown key id to refresh, got %d fetches", fetches.Load())
+	}
+
+	c.MinRefreshInterval = time.Hour
+	if _, err := c.Key(context.Background(), url, "missing"); err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if fetches.Load() != 2 {
+		t.Error("refreshes should be rate limited.")
+	}
+}
@@ -0,0 +1,92 @@
+package expiringmap
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LeakError reports the goroutine feeding the channel returned by Iter, Keys
+// or Values waiting on its reader for longer than the leak patience, Stack is
+// where the channel was created.
+type LeakError struct {
+	Method string
+	Stack  string
+}
+
+func (e *LeakError) Error() string {
+	return fmt.Sprintf("expiringmap: %s channel abandoned, created at\n%s", e.Method, e.Stack)
+}
+
+// WithLeakDetection is meant for tests. Once the goroutine feeding a channel
+// returned by Iter, Keys or Values has waited patience for its reader, it
+// calls onLeak with a LeakError naming where the channel was made, or panics
+// with it when onLeak is nil. The goroutine keeps waiting afterwards.
+func WithLeakDetection[K comparable, V any](patience time.Duration, onLeak func(err error)) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.leakPatience = patience
+		c.onLeak = onLeak
+	}
+}
+
+// Producers returns the number of goroutines feeding channels returned by
+// Iter, Keys or Values that are yet to be drained.
+func (m *ExpiringMap[K, V]) Producers() int {
+	return int(m.producers.Load())
+}
+
+// produce feeds the live entries, converted by item, to a channel from a new
+// goroutine.
+func produce[K comparable, V any, T any](m *ExpiringMap[K, V], method string, item func(K, V) T) chan T {
+	ch := make(chan T)
+	patience := m.config.leakPatience
+	var stack string
+	if patience > 0 {
+		stack = callers(3)
+	}
+	m.producers.Add(1)
+	go func() {
+		defer m.producers.Add(-1)
+		defer close(ch)
+		reported := false
+		m.Range(func(key K, value V) bool {
+			v := item(key, value)
+			if patience <= 0 || reported {
+				ch <- v
+				return true
+			}
+			timer := time.NewTimer(patience)
+			defer timer.Stop()
+			select {
+			case ch <- v:
+			case <-timer.C:
+				reported = true
+				m.leaked(&LeakError{Method: method, Stack: stack})
+				ch <- v
+			}
+			return true
+		})
+	}()
+	return ch
+}
+
+func (m *ExpiringMap[K, V]) leaked(err *LeakError) {
+	if m.config.onLeak == nil {
+		panic(err)
+	}
+	m.config.onLeak(err)
+}
+
+func callers(skip int) string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(skip, pcs)])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return b.String()
+		}
+	}
+}
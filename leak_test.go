@@ -0,0 +1,41 @@
+package expiringmap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLeakDetection(t *testing.T) {
+	leaks := make(chan error, 1)
+	m := New(WithLeakDetection[string, int](10*time.Millisecond, func(err error) { leaks <- err }))
+	m.Set("a", 1, time.Now().Add(time.Minute))
+	m.Set("b", 2, time.Now().Add(time.Minute))
+
+	for range m.Keys() {
+	}
+	abandoned := m.Values()
+	<-abandoned
+	var leak *LeakError
+	select {
+	case err := <-leaks:
+		if !errors.As(err, &leak) || leak.Method != "Values" || !strings.Contains(leak.Stack, "TestLeakDetection") {
+			t.Errorf("expected the abandoned Values channel to be reported, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the leak to be reported")
+	}
+	if n := m.Producers(); n != 1 {
+		t.Errorf("expected 1 outstanding producer, got %d", n)
+	}
+	// draining late still ends the producer
+	for range abandoned {
+	}
+	for i := 0; m.Producers() != 0 && i < 100; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := m.Producers(); n != 0 {
+		t.Errorf("expected no outstanding producers, got %d", n)
+	}
+}
@@ -0,0 +1,212 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("expiringmap: circuit open")
+
+// Loader returns the value for a missing key and when it expires.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, time.Time, error)
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	err       error
+}
+
+// GetOrLoad returns the value for key, calling loader and storing its result
+// on a miss. Concurrent misses for the same key share a single load, also
+// with GetManyOrLoad, which fails with ErrNotLoaded when its loader left the
+// key out.
+func (m *ExpiringMap[K, V]) GetOrLoad(ctx context.Context, key K, loader Loader[K, V]) (V, error) {
+	if value, ok := m.Get(key); ok {
+		return value, nil
+	}
+	value, err, _ := m.loads.Do(key, func() (V, error) {
+		if value, ok := m.Get(key); ok {
+			return value, nil
+		}
+		if err := m.checkBreaker(key); err != nil {
+			return *new(V), err
+		}
+		ctx, span := m.startSpan(ctx, "expiringmap.load")
+		span.SetAttribute("keys", 1)
+		start := time.Now()
+		value, ttl, err := m.load(ctx, key, loader)
+		m.stats.loaded(start)
+		endSpan(span, err)
+		m.recordLoad(key, err)
+		if err != nil {
+			return *new(V), err
+		}
+		m.setLoaded(key, value, ttl)
+		return value, nil
+	})
+	return value, err
+}
+
+// GetManyOrLoad returns the live values for keys, loading all the misses in
+// a single loader call and storing what it returns. Like GetOrLoad, misses
+// already being loaded share that load, keys with an open circuit aren't
+// loaded and failed loads are retried. Keys the loader leaves out are missing
+// from the result, and on a loader error the hits are returned with it.
+func (m *ExpiringMap[K, V]) GetManyOrLoad(ctx context.Context, keys []K, loader BatchLoader[K, V]) (map[K]V, error) {
+	values := make(map[K]V, len(keys))
+	seen := make(map[K]bool)
+	var (
+		misses []K
+		errs   []error
+	)
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if value, ok := m.Get(key); ok {
+			values[key] = value
+		} else if err := m.checkBreaker(key); err != nil {
+			errs = append(errs, err)
+		} else {
+			misses = append(misses, key)
+		}
+	}
+	if len(misses) == 0 {
+		return values, joinErrors(errs)
+	}
+	loaded, failed := m.loads.DoMany(misses, ErrNotLoaded, func(misses []K) (map[K]V, error) {
+		values := make(map[K]V, len(misses))
+		var load []K
+		for _, key := range misses {
+			if value, ok := m.Get(key); ok {
+				values[key] = value
+			} else {
+				load = append(load, key)
+			}
+		}
+		if len(load) == 0 {
+			return values, nil
+		}
+		loaded, ttls, err := m.loadMany(ctx, "expiringmap.load_many", load, loader)
+		for _, key := range load {
+			if err != nil {
+				m.recordLoad(key, err)
+			} else if value, ok := loaded[key]; ok {
+				m.recordLoad(key, nil)
+				m.setLoaded(key, value, ttls[key])
+				values[key] = value
+			}
+		}
+		return values, err
+	})
+	for key, value := range loaded {
+		values[key] = value
+	}
+	for _, err := range failed {
+		if err != ErrNotLoaded && !containsError(errs, err) {
+			errs = append(errs, err)
+		}
+	}
+	return values, joinErrors(errs)
+}
+
+func containsError(errs []error, err error) bool {
+	for _, e := range errs {
+		if e == err {
+			return true
+		}
+	}
+	return false
+}
+
+// joinErrors leaves a lone error as it is so callers can compare it.
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// loadMany calls loader for keys in a span named name, retrying it and
+// counting the load in Stats.
+func (m *ExpiringMap[K, V]) loadMany(ctx context.Context, name string, keys []K, loader BatchLoader[K, V]) (map[K]V, map[K]time.Time, error) {
+	ctx, span := m.startSpan(ctx, name)
+	span.SetAttribute("keys", len(keys))
+	start := time.Now()
+	var (
+		values map[K]V
+		ttls   map[K]time.Time
+	)
+	err := m.retrying(ctx, func() (err error) {
+		values, ttls, err = loader.LoadMany(ctx, keys)
+		return err
+	})
+	m.stats.loaded(start)
+	endSpan(span, err)
+	return values, ttls, err
+}
+
+func (m *ExpiringMap[K, V]) load(ctx context.Context, key K, loader Loader[K, V]) (V, time.Time, error) {
+	var (
+		value V
+		ttl   time.Time
+	)
+	err := m.retrying(ctx, func() (err error) {
+		value, ttl, err = loader(ctx, key)
+		return err
+	})
+	return value, ttl, err
+}
+
+// retrying calls attempt until it succeeds or the retry policy gives up.
+func (m *ExpiringMap[K, V]) retrying(ctx context.Context, attempt func() error) error {
+	policy := m.config.retry
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || policy == nil || n >= policy.Attempts || !policy.retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(policy.backoff(n))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (m *ExpiringMap[K, V]) checkBreaker(key K) error {
+	if m.breakers == nil {
+		return nil
+	}
+	if b, ok := m.breakers.Get(key); ok && m.now().Before(b.openUntil) {
+		return fmt.Errorf("%w: %w", ErrCircuitOpen, b.err)
+	}
+	return nil
+}
+
+// recordLoad counts consecutive failures, a failure while half open reopens
+// the breaker straight away since the count is kept for another cooldown.
+func (m *ExpiringMap[K, V]) recordLoad(key K, err error) {
+	if m.breakers == nil {
+		return
+	}
+	if err == nil {
+		m.breakers.Delete(key)
+		return
+	}
+	failures, cooldown := m.config.breakerFailures, m.config.breakerCooldown
+	m.breakers.compute(key, func(b breaker, ok bool) (breaker, time.Time, bool) {
+		now := m.now()
+		b.failures += 1
+		b.err = err
+		if b.failures >= failures {
+			b.openUntil = now.Add(cooldown)
+		}
+		return b, now.Add(2 * cooldown), true
+	})
+}
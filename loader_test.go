@@ -0,0 +1,207 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	m := New[string, Animal]()
+	var calls atomic.Int32
+	loader := func(ctx context.Context, key string) (Animal, time.Time, error) {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return Animal{key}, time.Now().Add(time.Minute), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := m.GetOrLoad(context.Background(), "elephant", loader)
+			if err != nil || value.name != "elephant" {
+				t.Errorf("unexpected load %v %v", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("expected a single load, got %d", calls.Load())
+	}
+	if !m.Has("elephant") {
+		t.Error("loaded value should be stored.")
+	}
+}
+
+func TestGetOrLoadError(t *testing.T) {
+	m := New[string, Animal]()
+	errDown := errors.New("down")
+	_, err := m.GetOrLoad(context.Background(), "elephant", func(context.Context, string) (Animal, time.Time, error) {
+		return Animal{}, time.Time{}, errDown
+	})
+	if err != errDown {
+		t.Errorf("expected loader error, got %v", err)
+	}
+	if m.Has("elephant") {
+		t.Error("failed loads shouldn't be stored.")
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	m := New(WithCircuitBreaker[string, Animal](2, 20*time.Millisecond))
+	errDown := errors.New("down")
+	var calls atomic.Int32
+	failing := func(context.Context, string) (Animal, time.Time, error) {
+		calls.Add(1)
+		return Animal{}, time.Time{}, errDown
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.GetOrLoad(context.Background(), "elephant", failing); err != errDown {
+			t.Errorf("expected loader error, got %v", err)
+		}
+	}
+	_, err := m.GetOrLoad(context.Background(), "elephant", failing)
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, errDown) {
+		t.Errorf("expected open circuit wrapping the last error, got %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("loader shouldn't be called while open, got %d calls", calls.Load())
+	}
+	if _, err := m.GetOrLoad(context.Background(), "monkey", failing); err != errDown {
+		t.Error("breakers should be per key.")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	if _, err := m.GetOrLoad(context.Background(), "elephant", failing); err != errDown {
+		t.Errorf("expected a trial load after cooldown, got %v", err)
+	}
+	if _, err := m.GetOrLoad(context.Background(), "elephant", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Error("a failed trial should reopen the circuit.")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+	value, err := m.GetOrLoad(context.Background(), "elephant", func(_ context.Context, key string) (Animal, time.Time, error) {
+		return Animal{key}, time.Now().Add(time.Minute), nil
+	})
+	if err != nil || value.name != "elephant" {
+		t.Errorf("expected recovery, got %v %v", value, err)
+	}
+	m.Delete("elephant")
+	if _, err := m.GetOrLoad(context.Background(), "elephant", failing); err != errDown {
+		t.Error("a success should close the circuit.")
+	}
+}
+
+func TestGetManyOrLoad(t *testing.T) {
+	m := New[string, int]()
+	m.Set("a", 1, time.Now().Add(time.Minute))
+	var batches [][]string
+	loader := BatchLoaderFunc[string, int](func(ctx context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		batches = append(batches, keys)
+		values := map[string]int{}
+		for _, key := range keys {
+			if key != "missing" {
+				values[key] = len(key) * 10
+			}
+		}
+		return values, nil, nil
+	})
+
+	values, err := m.GetManyOrLoad(context.Background(), []string{"a", "bb", "ccc", "bb", "missing"}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || values["a"] != 1 || values["bb"] != 20 || values["ccc"] != 30 {
+		t.Errorf("unexpected values %v", values)
+	}
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Errorf("expected the misses to be loaded once in one batch, got %v", batches)
+	}
+	if !m.Has("ccc") || m.Has("missing") {
+		t.Error("expected the loaded values to be cached.")
+	}
+	if _, err := m.GetManyOrLoad(context.Background(), []string{"a", "bb"}, loader); err != nil || len(batches) != 1 {
+		t.Error("expected cached keys not to be loaded.")
+	}
+
+	failed := errors.New("failed")
+	values, err = m.GetManyOrLoad(context.Background(), []string{"a", "d"}, BatchLoaderFunc[string, int](func(context.Context, []string) (map[string]int, map[string]time.Time, error) {
+		return nil, nil, failed
+	}))
+	if !errors.Is(err, failed) || len(values) != 1 {
+		t.Errorf("expected the hits with the error, got %v %v", values, err)
+	}
+}
+
+func TestGetManyOrLoadSharesLoads(t *testing.T) {
+	m := New(
+		WithCircuitBreaker[string, int](1, time.Minute),
+		WithRetry[string, int](RetryPolicy{Attempts: 2}),
+	)
+	inFlight, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.GetOrLoad(context.Background(), "elephant", func(context.Context, string) (int, time.Time, error) {
+			close(inFlight)
+			<-release
+			return 1, time.Now().Add(time.Minute), nil
+		})
+	}()
+	<-inFlight
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+
+	var batches [][]string
+	attempts := 0
+	values, err := m.GetManyOrLoad(context.Background(), []string{"elephant", "monkey"}, BatchLoaderFunc[string, int](func(ctx context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		batches = append(batches, keys)
+		if attempts++; attempts == 1 {
+			return nil, nil, errors.New("flaky")
+		}
+		return map[string]int{"monkey": 2}, nil, nil
+	}))
+	<-done
+	if err != nil || values["elephant"] != 1 || values["monkey"] != 2 {
+		t.Errorf("expected the shared and retried loads, got %v %v", values, err)
+	}
+	if len(batches) != 2 || len(batches[1]) != 1 || batches[1][0] != "monkey" {
+		t.Errorf("expected only monkey to be loaded, retried once, got %v", batches)
+	}
+
+	failed := errors.New("failed")
+	m.GetManyOrLoad(context.Background(), []string{"tiger"}, BatchLoaderFunc[string, int](func(context.Context, []string) (map[string]int, map[string]time.Time, error) {
+		return nil, nil, failed
+	}))
+	_, err = m.GetManyOrLoad(context.Background(), []string{"tiger"}, BatchLoaderFunc[string, int](func(context.Context, []string) (map[string]int, map[string]time.Time, error) {
+		t.Error("expected an open circuit to skip the loader")
+		return nil, nil, nil
+	}))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the circuit to be open, got %v", err)
+	}
+}
+
+func TestCircuitBreakerClock(t *testing.T) {
+	now := time.Now()
+	m := New(WithCircuitBreaker[string, Animal](1, time.Minute), WithClock[string, Animal](func() time.Time { return now }))
+	failing := func(context.Context, string) (Animal, time.Time, error) {
+		return Animal{}, time.Time{}, errors.New("down")
+	}
+	m.GetOrLoad(context.Background(), "elephant", failing)
+	if _, err := m.GetOrLoad(context.Background(), "elephant", failing); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected an open circuit, got %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := m.GetOrLoad(context.Background(), "elephant", failing); errors.Is(err, ErrCircuitOpen) {
+		t.Error("expected the cooldown to follow the map's clock.")
+	}
+}
@@ -0,0 +1,73 @@
+package expiringmap
+
+// Stamp is a Lamport timestamp, ties between maps are broken by origin so
+// every map orders the same writes the same way.
+type Stamp struct {
+	Clock  uint64 `json:"clock"`
+	Origin string `json:"origin"`
+}
+
+func (s *Stamp) Less(other *Stamp) bool {
+	if s.Clock != other.Clock {
+		return s.Clock < other.Clock
+	}
+	return s.Origin < other.Origin
+}
+
+// WithLastWriteWins stamps every write so that ApplyMutation keeps, for each
+// key, whichever write was stamped last and discards stale ones, letting
+// several maps replicate to each other. origin must differ between maps, a
+// random one is used when it is empty. Without tombstones a delete can't
+// outlive its key, so a stale set arriving after it is applied.
+func WithLastWriteWins[K comparable, V any](origin string) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.lww = true
+		c.origin = origin
+	}
+}
+
+func (m *ExpiringMap[K, V]) nextStamp() *Stamp {
+	return &Stamp{Clock: m.clock.Add(1), Origin: m.config.origin}
+}
+
+// witness moves the clock past stamp so later local writes win over it.
+func (m *ExpiringMap[K, V]) witness(stamp *Stamp) {
+	for {
+		clock := m.clock.Load()
+		if clock >= stamp.Clock || m.clock.CompareAndSwap(clock, stamp.Clock) {
+			return
+		}
+	}
+}
+
+// applyStamped applies mutation unless the key holds a later write. Expiries
+// and evictions carry the stamp of the write they removed, so they only
+// remove that write or an earlier one.
+func (m *ExpiringMap[K, V]) applyStamped(mutation Mutation[K, V]) bool {
+	m.witness(mutation.Stamp)
+	key := mutation.Key
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	item, ok := m.liveItem(s, key)
+	if mutation.Op == MutationSet {
+		if ok && !item.stamp.Less(mutation.Stamp) || m.buried(key, mutation.Stamp) {
+			return false
+		}
+		m.store(s, key, expiringMapVal[V]{val: mutation.Val, ttl: mutation.TTL, created: m.now().UnixNano(), stamp: mutation.Stamp})
+		return true
+	}
+	if mutation.Op == MutationDelete {
+		m.bury(key, mutation.Stamp)
+	}
+	if !ok || mutation.Stamp.Less(item.stamp) {
+		return false
+	}
+	m.drop(s, key, item)
+	reason := mutation.Reason
+	if reason == RemovalNone {
+		reason = RemovalDeleted
+	}
+	m.notify(Mutation[K, V]{Op: mutation.Op, Key: key, Val: item.val, TTL: item.ttl, Reason: reason, Stamp: mutation.Stamp})
+	m.removed(key, item, reason)
+	return true
+}
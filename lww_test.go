@@ -0,0 +1,79 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+// exchange applies every mutation a and b have published since the last
+// exchange to the other.
+func exchange[K comparable, V any](a, b *ExpiringMap[K, V], fromA, fromB *[]Mutation[K, V]) {
+	pendingA, pendingB := *fromA, *fromB
+	*fromA, *fromB = nil, nil
+	for _, mutation := range pendingA {
+		b.ApplyMutation(mutation)
+	}
+	for _, mutation := range pendingB {
+		a.ApplyMutation(mutation)
+	}
+}
+
+func TestLastWriteWins(t *testing.T) {
+	a := New(WithLastWriteWins[string, int]("a"))
+	b := New(WithLastWriteWins[string, int]("b"))
+	var fromA, fromB []Mutation[string, int]
+	a.Subscribe(func(mutation Mutation[string, int]) { fromA = append(fromA, mutation) })
+	b.Subscribe(func(mutation Mutation[string, int]) { fromB = append(fromB, mutation) })
+	ttl := time.Now().Add(time.Minute)
+
+	// concurrent writes with the same clock are ordered by origin
+	a.Set("x", 1, ttl)
+	b.Set("x", 2, ttl)
+	exchange(&a, &b, &fromA, &fromB)
+	if va, _ := a.Get("x"); va != 2 {
+		t.Errorf("expected b's write to win on a, got %d", va)
+	}
+	if vb, _ := b.Get("x"); vb != 2 {
+		t.Errorf("expected b's write to win on b, got %d", vb)
+	}
+
+	// a later write wins whichever map made it
+	a.Set("x", 3, ttl)
+	exchange(&a, &b, &fromA, &fromB)
+	if vb, _ := b.Get("x"); vb != 3 {
+		t.Errorf("expected the later write, got %d", vb)
+	}
+
+	// stale writes are discarded
+	stale := Mutation[string, int]{Op: MutationSet, Key: "x", Val: 0, TTL: ttl, Stamp: &Stamp{Clock: 1, Origin: "z"}}
+	a.ApplyMutation(stale)
+	if va, _ := a.Get("x"); va != 3 {
+		t.Errorf("expected the stale write to be discarded, got %d", va)
+	}
+
+	b.Delete("x")
+	exchange(&a, &b, &fromA, &fromB)
+	if a.Has("x") || b.Has("x") {
+		t.Error("expected the delete to win.")
+	}
+}
+
+func TestLastWriteWinsExpiry(t *testing.T) {
+	m := New(WithLastWriteWins[string, int]("a"))
+	ttl := time.Now().Add(time.Minute)
+	m.ApplyMutation(Mutation[string, int]{Op: MutationSet, Key: "x", Val: 1, TTL: ttl, Stamp: &Stamp{Clock: 10, Origin: "b"}})
+
+	m.ApplyMutation(Mutation[string, int]{Op: MutationExpired, Key: "x", Stamp: &Stamp{Clock: 9, Origin: "b"}})
+	if !m.Has("x") {
+		t.Error("expiring an earlier write shouldn't remove a later one.")
+	}
+	m.ApplyMutation(Mutation[string, int]{Op: MutationExpired, Key: "x", Stamp: &Stamp{Clock: 10, Origin: "b"}})
+	if m.Has("x") {
+		t.Error("expected the expired write to be removed.")
+	}
+
+	m.Set("y", 1, ttl)
+	if m.clock.Load() != 11 {
+		t.Errorf("expected local writes to follow the witnessed clock, got %d", m.clock.Load())
+	}
+}
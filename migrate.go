@@ -0,0 +1,94 @@
+package expiringmap
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SnapshotVersion is the version of the snapshot format written. Version 0
+// is the headerless format of snapshots written before versioning.
+const SnapshotVersion = 1
+
+const snapshotFormat = "expiringmap"
+
+type snapshotHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// Seq is the sequence number of the map's latest mutation when the
+	// snapshot was taken, every mutation up to it is reflected.
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+func writeSnapshotHeader(w io.Writer, seq uint64) error {
+	return json.NewEncoder(w).Encode(snapshotHeader{Format: snapshotFormat, Version: SnapshotVersion, Seq: seq})
+}
+
+// Migration rewrites a record of a snapshot written at some version into its
+// form at the next version.
+type Migration func(record json.RawMessage) (json.RawMessage, error)
+
+// WithMigration registers migrate to upgrade records of snapshots written at
+// version. Records of snapshots written by later versions are decoded as they
+// are, ignoring fields this version doesn't know.
+func WithMigration(version int, migrate Migration) SnapshotOption {
+	return func(c *snapshotConfig) {
+		if c.migrations == nil {
+			c.migrations = make(map[int]Migration)
+		}
+		c.migrations[version] = migrate
+	}
+}
+
+// migratingReader reads a snapshot's header and yields its records, one per
+// line, migrated to SnapshotVersion.
+type migratingReader struct {
+	dec        *json.Decoder
+	migrations map[int]Migration
+	seq        *uint64
+	version    int
+	started    bool
+	buf        []byte
+	err        error
+}
+
+func newMigratingReader(r io.Reader, c *snapshotConfig) *migratingReader {
+	return &migratingReader{dec: json.NewDecoder(r), migrations: c.migrations, seq: c.readSeq}
+}
+
+func (m *migratingReader) Read(p []byte) (int, error) {
+	for len(m.buf) == 0 && m.err == nil {
+		m.next()
+	}
+	if len(m.buf) == 0 {
+		return 0, m.err
+	}
+	n := copy(p, m.buf)
+	m.buf = m.buf[n:]
+	return n, nil
+}
+
+func (m *migratingReader) next() {
+	var record json.RawMessage
+	if m.err = m.dec.Decode(&record); m.err != nil {
+		return
+	}
+	if !m.started {
+		m.started = true
+		var header snapshotHeader
+		if json.Unmarshal(record, &header) == nil && header.Format == snapshotFormat {
+			m.version = header.Version
+			if m.seq != nil {
+				*m.seq = header.Seq
+			}
+			return
+		}
+	}
+	for version := m.version; version < SnapshotVersion; version++ {
+		if migrate := m.migrations[version]; migrate != nil {
+			if record, m.err = migrate(record); m.err != nil {
+				return
+			}
+		}
+	}
+	m.buf = append(record, '\n')
+}
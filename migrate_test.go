@@ -0,0 +1,57 @@
+package expiringmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotHeader(t *testing.T) {
+	m := New[string, Plant]()
+	m.Set("fern", Plant{"fern"}, time.Now().Add(time.Minute))
+	var buf bytes.Buffer
+	if err := m.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `{"format":"expiringmap","version":1,"seq":1}`) {
+		t.Errorf("expected the snapshot to begin with its version and sequence, got %q", buf.String())
+	}
+}
+
+func TestSnapshotMigration(t *testing.T) {
+	ttl := time.Now().Add(time.Minute).Format(time.RFC3339Nano)
+	// version 0 snapshots had no header, suppose they named the value "value"
+	legacy := `{"op":"set","key":"fern","value":{"name":"fern"},"ttl":"` + ttl + `"}` + "\n"
+	rename := func(record json.RawMessage) (json.RawMessage, error) {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(record, &fields); err != nil {
+			return nil, err
+		}
+		fields["val"] = fields["value"]
+		delete(fields, "value")
+		return json.Marshal(fields)
+	}
+
+	m := New[string, Plant]()
+	if err := m.ReadSnapshot(strings.NewReader(legacy), WithMigration(0, rename)); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Get("fern"); v.Name != "fern" {
+		t.Errorf("expected the legacy record to be migrated, got %+v", v)
+	}
+
+	// a current snapshot isn't migrated, a later one is decoded as it is
+	for _, version := range []string{"1", "2"} {
+		snapshot := `{"format":"expiringmap","version":` + version + `}` + "\n" +
+			`{"op":"set","key":"moss","val":{"name":"moss"},"ttl":"` + ttl + `","future":true}` + "\n"
+		if err := m.ReadSnapshot(strings.NewReader(snapshot), WithMigration(0, rename)); err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := m.Get("moss"); v.Name != "moss" {
+			t.Errorf("expected version %s to be decoded, got %+v", version, v)
+		}
+		m.Delete("moss")
+	}
+}
@@ -0,0 +1,183 @@
+package expiringmap
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	nanos atomic.Int64
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{}
+	c.nanos.Store(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano())
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return time.Unix(0, c.nanos.Load())
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.nanos.Add(int64(d))
+}
+
+// model is the single threaded reference the map is checked against.
+type model struct {
+	clock *fakeClock
+	items map[int]modelItem
+}
+
+type modelItem struct {
+	val int
+	ttl time.Time
+}
+
+func (m *model) live(key int) (modelItem, bool) {
+	item, ok := m.items[key]
+	if ok && item.ttl.Before(m.clock.Now()) {
+		delete(m.items, key)
+		return modelItem{}, false
+	}
+	return item, ok
+}
+
+type modelOp func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error
+
+var modelOps = []modelOp{
+	func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error {
+		val, ttl := r.Int(), ref.clock.Now().Add(time.Duration(r.Intn(100))*time.Millisecond)
+		_, existed := ref.live(key)
+		ref.items[key] = modelItem{val, ttl}
+		if isNew := m.Set(key, val, ttl); isNew == existed {
+			return fmt.Errorf("Set(%d) reported new=%v", key, isNew)
+		}
+		return nil
+	},
+	func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error {
+		val, ttl := r.Int(), ref.clock.Now().Add(time.Duration(r.Intn(100))*time.Millisecond)
+		_, existed := ref.live(key)
+		if !existed {
+			ref.items[key] = modelItem{val, ttl}
+		}
+		if set := m.SetIfAbsent(key, val, ttl); set == existed {
+			return fmt.Errorf("SetIfAbsent(%d) reported set=%v", key, set)
+		}
+		return nil
+	},
+	func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error {
+		expected, ok := ref.live(key)
+		if val, found := m.Get(key); found != ok || val != expected.val {
+			return fmt.Errorf("Get(%d) = %d, %v expected %d, %v", key, val, found, expected.val, ok)
+		}
+		return nil
+	},
+	func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error {
+		expected, ok := ref.live(key)
+		if ttl, found := m.TTL(key); found != ok || !ttl.Equal(expected.ttl) {
+			return fmt.Errorf("TTL(%d) = %v, %v expected %v, %v", key, ttl, found, expected.ttl, ok)
+		}
+		return nil
+	},
+	func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error {
+		_, ok := ref.live(key)
+		delete(ref.items, key)
+		// Delete also reports expired entries nothing has purged yet
+		if deleted := m.Delete(key); ok && !deleted {
+			return fmt.Errorf("Delete(%d) = false expected true", key)
+		}
+		return nil
+	},
+	func(r *rand.Rand, m *ExpiringMap[int, int], ref *model, key int) error {
+		ttl := ref.clock.Now().Add(time.Duration(r.Intn(100)) * time.Millisecond)
+		item, ok := ref.live(key)
+		if ok {
+			ref.items[key] = modelItem{item.val, ttl}
+		}
+		if expired := m.Expire(key, ttl); expired != ok {
+			return fmt.Errorf("Expire(%d) = %v expected %v", key, expired, ok)
+		}
+		return nil
+	},
+}
+
+// runModel applies n random operations to keys and checks every result
+// against a model, advancing the clock as it goes.
+func runModel(r *rand.Rand, m *ExpiringMap[int, int], ref *model, keys []int, n int, advance func()) error {
+	for i := 0; i < n; i++ {
+		key := keys[r.Intn(len(keys))]
+		if err := modelOps[r.Intn(len(modelOps))](r, m, ref, key); err != nil {
+			return fmt.Errorf("op %d: %w", i, err)
+		}
+		if advance != nil && r.Intn(10) == 0 {
+			advance()
+		}
+	}
+	return nil
+}
+
+func TestModel(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		clock := newFakeClock()
+		m := New(WithClock[int, int](clock.Now))
+		ref := &model{clock: clock, items: make(map[int]modelItem)}
+		keys := []int{0, 1, 2, 3, 4, 5, 6, 7}
+
+		err := runModel(r, &m, ref, keys, 2000, func() {
+			clock.Advance(time.Duration(r.Intn(20)) * time.Millisecond)
+		})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		expected := 0
+		for _, key := range keys {
+			if _, ok := ref.live(key); ok {
+				expected += 1
+			}
+		}
+		if m.Len() != expected {
+			t.Fatalf("seed %d: Len() = %d expected %d", seed, m.Len(), expected)
+		}
+	}
+}
+
+// TestModelConcurrent gives each worker its own keys and model, so every
+// result is still deterministic while the workers share shards and the clock
+// moves between rounds.
+func TestModelConcurrent(t *testing.T) {
+	const workers = 8
+	clock := newFakeClock()
+	m := New(WithClock[int, int](clock.Now))
+
+	refs := make([]*model, workers)
+	rands := make([]*rand.Rand, workers)
+	for w := range refs {
+		refs[w] = &model{clock: clock, items: make(map[int]modelItem)}
+		rands[w] = rand.New(rand.NewSource(int64(w)))
+	}
+	for round := 0; round < 50; round++ {
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				keys := make([]int, 16)
+				for i := range keys {
+					keys[i] = w*100 + i
+				}
+				if err := runModel(rands[w], &m, refs[w], keys, 200, nil); err != nil {
+					t.Errorf("round %d worker %d: %v", round, w, err)
+				}
+			}(w)
+		}
+		wg.Wait()
+		clock.Advance(time.Duration(rands[0].Intn(30)) * time.Millisecond)
+	}
+}
@@ -0,0 +1,94 @@
+package expiringmap
+
+import "time"
+
+// ExpiringMultiMap maps each key to a set of values that expire individually.
+// A key expires once its last value does.
+type ExpiringMultiMap[K comparable, V comparable] struct {
+	m ExpiringMap[K, map[V]time.Time]
+}
+
+func NewMultiMap[K comparable, V comparable]() *ExpiringMultiMap[K, V] {
+	return &ExpiringMultiMap[K, V]{
+		m: New[K, map[V]time.Time](),
+	}
+}
+
+// Add adds value to key or refreshes its ttl if already present.
+func (mm *ExpiringMultiMap[K, V]) Add(key K, value V, ttl time.Time) {
+	mm.m.compute(key, func(values map[V]time.Time, ok bool) (map[V]time.Time, time.Time, bool) {
+		next := prune(values, time.Now())
+		next[value] = ttl
+		return next, latest(next), true
+	})
+}
+
+func (mm *ExpiringMultiMap[K, V]) RemoveValue(key K, value V) bool {
+	removed := false
+	mm.m.compute(key, func(values map[V]time.Time, ok bool) (map[V]time.Time, time.Time, bool) {
+		if !ok {
+			return nil, time.Time{}, false
+		}
+		next := prune(values, time.Now())
+		if _, removed = next[value]; removed {
+			delete(next, value)
+		}
+		return next, latest(next), len(next) > 0
+	})
+	return removed
+}
+
+func (mm *ExpiringMultiMap[K, V]) GetAll(key K) []V {
+	values, ok := mm.m.Get(key)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	result := make([]V, 0, len(values))
+	for value, ttl := range values {
+		if !ttl.Before(now) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func (mm *ExpiringMultiMap[K, V]) Has(key K, value V) bool {
+	values, ok := mm.m.Get(key)
+	if !ok {
+		return false
+	}
+	ttl, ok := values[value]
+	return ok && !ttl.Before(time.Now())
+}
+
+func (mm *ExpiringMultiMap[K, V]) Delete(key K) bool {
+	return mm.m.Delete(key)
+}
+
+// Len returns the number of keys with at least one live value.
+func (mm *ExpiringMultiMap[K, V]) Len() int {
+	return mm.m.Len()
+}
+
+// prune copies values without the expired ones, stored sets are never
+// modified so readers can use them without holding the shard lock.
+func prune[V comparable](values map[V]time.Time, now time.Time) map[V]time.Time {
+	next := make(map[V]time.Time, len(values)+1)
+	for value, ttl := range values {
+		if !ttl.Before(now) {
+			next[value] = ttl
+		}
+	}
+	return next
+}
+
+func latest[V comparable](values map[V]time.Time) time.Time {
+	var max time.Time
+	for _, ttl := range values {
+		if ttl.After(max) {
+			max = ttl
+		}
+	}
+	return max
+}
@@ -0,0 +1,60 @@
+package expiringmap
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMultiMap(t *testing.T) {
+	mm := NewMultiMap[string, string]()
+	mm.Add("zoo", "elephant", time.Now().Add(time.Minute))
+	mm.Add("zoo", "monkey", time.Now().Add(time.Minute))
+	mm.Add("zoo", "tiger", time.Now().Add(10*time.Millisecond))
+	mm.Add("farm", "cow", time.Now().Add(time.Minute))
+
+	values := mm.GetAll("zoo")
+	sort.Strings(values)
+	if len(values) != 3 || values[0] != "elephant" || values[1] != "monkey" || values[2] != "tiger" {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if mm.Has("zoo", "tiger") {
+		t.Error("tiger should have expired.")
+	}
+	if len(mm.GetAll("zoo")) != 2 {
+		t.Error("expected expired values to be skipped.")
+	}
+
+	if !mm.RemoveValue("zoo", "monkey") {
+		t.Error("expected monkey to be removed.")
+	}
+	if mm.RemoveValue("zoo", "monkey") {
+		t.Error("monkey was already removed.")
+	}
+	if !mm.RemoveValue("zoo", "elephant") {
+		t.Error("expected elephant to be removed.")
+	}
+	if mm.GetAll("zoo") != nil {
+		t.Error("key should be gone once its last value is removed.")
+	}
+	if mm.Len() != 1 {
+		t.Errorf("expected 1 key, got %d", mm.Len())
+	}
+}
+
+func TestMultiMapKeyExpiry(t *testing.T) {
+	mm := NewMultiMap[string, string]()
+	mm.Add("zoo", "elephant", time.Now().Add(10*time.Millisecond))
+	mm.Add("zoo", "monkey", time.Now().Add(20*time.Millisecond))
+
+	time.Sleep(15 * time.Millisecond)
+	if mm.Len() != 1 {
+		t.Error("key should live while a value does.")
+	}
+	time.Sleep(10 * time.Millisecond)
+	if mm.Len() != 0 {
+		t.Error("key should expire with its last value.")
+	}
+}
@@ -0,0 +1,309 @@
+package expiringmap
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const streamBufferSize = 1024
+
+type MutationOp int
+
+const (
+	MutationSet MutationOp = iota
+	MutationDelete
+	MutationExpired
+	MutationClear
+)
+
+func (op MutationOp) String() string {
+	switch op {
+	case MutationSet:
+		return "set"
+	case MutationDelete:
+		return "delete"
+	case MutationExpired:
+		return "expired"
+	case MutationClear:
+		return "clear"
+	default:
+		return "unknown"
+	}
+}
+
+func (op MutationOp) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+func (op *MutationOp) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "set":
+		*op = MutationSet
+	case "delete":
+		*op = MutationDelete
+	case "expired":
+		*op = MutationExpired
+	case "clear":
+		*op = MutationClear
+	default:
+		return fmt.Errorf("expiringmap: unknown mutation op %q", text)
+	}
+	return nil
+}
+
+type Mutation[K comparable, V any] struct {
+	Op  MutationOp `json:"op"`
+	Key K          `json:"key"`
+	Val V          `json:"val"`
+	TTL time.Time  `json:"ttl"`
+	// Reason is why the key was removed, it is RemovalNone for sets.
+	Reason RemovalReason `json:"reason,omitempty"`
+	// Seq numbers the map's mutations from 1, subscribers see them in
+	// increasing order. Mutations replaying the map's contents carry the
+	// sequence number they reflect.
+	Seq uint64 `json:"seq,omitempty"`
+	// Stamp orders writes to a key across maps in last write wins mode.
+	Stamp *Stamp `json:"stamp,omitempty"`
+
+	remote bool
+	absent bool
+}
+
+type subscriber[K comparable, V any] struct {
+	fn func(Mutation[K, V])
+}
+
+type subscribers[K comparable, V any] struct {
+	mutex sync.Mutex
+	list  atomic.Pointer[[]*subscriber[K, V]]
+
+	seq atomic.Uint64
+
+	// while anyone is subscribed, or with a replay buffer, mutations are
+	// numbered, recorded and published one at a time under ordered so
+	// sequence numbers arrive in order and a replaying subscriber sees no gap.
+	ordered    sync.Mutex
+	replay     []Mutation[K, V]
+	replayNext int
+	replayFull bool
+}
+
+func (s *subscribers[K, V]) record(mutation Mutation[K, V]) {
+	s.replay[s.replayNext] = mutation
+	s.replayNext++
+	if s.replayNext == len(s.replay) {
+		s.replayNext, s.replayFull = 0, true
+	}
+}
+
+func (s *subscribers[K, V]) recorded() []Mutation[K, V] {
+	if !s.replayFull {
+		return s.replay[:s.replayNext]
+	}
+	return append(append([]Mutation[K, V](nil), s.replay[s.replayNext:]...), s.replay[:s.replayNext]...)
+}
+
+type subscribeConfig struct {
+	async  bool
+	buffer int
+}
+
+type SubscribeOption func(*subscribeConfig)
+
+// WithAsyncDelivery hands mutations to the subscriber on its own goroutine,
+// in order, through a buffer of size mutations so a slow subscriber never
+// holds up writers. Mutations published while the buffer is full are dropped
+// and counted by Stats.
+func WithAsyncDelivery(size int) SubscribeOption {
+	return func(c *subscribeConfig) {
+		c.async = true
+		c.buffer = size
+	}
+}
+
+// Subscribe registers fn to be called after every mutation of the map. By
+// default fn is called on the goroutine performing the mutation while the
+// key's shard is locked, so it must not block or call back into the map.
+func (m *ExpiringMap[K, V]) Subscribe(fn func(Mutation[K, V]), options ...SubscribeOption) func() {
+	var c subscribeConfig
+	for _, option := range options {
+		option(&c)
+	}
+	if c.async {
+		return m.subscribeAsync(fn, c.buffer)
+	}
+	return m.subscribe(func(mutation Mutation[K, V]) {
+		if !mutation.absent {
+			fn(mutation)
+		}
+	})
+}
+
+func (m *ExpiringMap[K, V]) subscribeAsync(fn func(Mutation[K, V]), size int) func() {
+	ch := make(chan Mutation[K, V], size)
+	go func() {
+		sub := &subscriber[K, V]{fn: fn}
+		for mutation := range ch {
+			m.publish(sub, mutation)
+		}
+	}()
+	unsubscribe := m.subscribe(func(mutation Mutation[K, V]) {
+		if mutation.absent {
+			return
+		}
+		select {
+		case ch <- mutation:
+		default:
+			m.stats.droppedMutations.Add(1)
+		}
+	})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			unsubscribe()
+			unlockAll(m.lockAll())
+			close(ch)
+		})
+	}
+	forget := m.closers.add(stop)
+	return func() {
+		stop()
+		forget()
+	}
+}
+
+// SubscribeWithReplay is Subscribe first calling fn with the mutations held
+// by the replay buffer, oldest first, so a subscriber attaching late can
+// catch up. Without WithReplayBuffer it is Subscribe.
+func (m *ExpiringMap[K, V]) SubscribeWithReplay(fn func(Mutation[K, V])) func() {
+	s := m.subscribers
+	if s.replay == nil {
+		return m.Subscribe(fn)
+	}
+	s.ordered.Lock()
+	defer s.ordered.Unlock()
+	replayed := &subscriber[K, V]{fn: fn}
+	for _, mutation := range s.recorded() {
+		m.publish(replayed, mutation)
+	}
+	return m.Subscribe(fn)
+}
+
+func (m *ExpiringMap[K, V]) subscribe(fn func(Mutation[K, V])) func() {
+	s := m.subscribers
+	sub := &subscriber[K, V]{fn: fn}
+	s.mutex.Lock()
+	var list []*subscriber[K, V]
+	if old := s.list.Load(); old != nil {
+		list = append(list, *old...)
+	}
+	list = append(list, sub)
+	s.list.Store(&list)
+	s.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			old := *s.list.Load()
+			list := make([]*subscriber[K, V], 0, len(old))
+			for _, other := range old {
+				if other != sub {
+					list = append(list, other)
+				}
+			}
+			s.list.Store(&list)
+		})
+	}
+}
+
+// Seq returns the sequence number of the map's latest mutation.
+func (m *ExpiringMap[K, V]) Seq() uint64 {
+	return m.subscribers.seq.Load()
+}
+
+func (m *ExpiringMap[K, V]) notify(mutation Mutation[K, V]) {
+	s := m.subscribers
+	list := s.list.Load()
+	if (list == nil || len(*list) == 0) && s.replay == nil {
+		if !mutation.absent {
+			s.seq.Add(1)
+		}
+		return
+	}
+	s.ordered.Lock()
+	defer s.ordered.Unlock()
+	if !mutation.absent {
+		mutation.Seq = s.seq.Add(1)
+		if s.replay != nil {
+			s.record(mutation)
+		}
+	}
+	if list == nil {
+		return
+	}
+	for _, sub := range *list {
+		m.publish(sub, mutation)
+	}
+}
+
+func (m *ExpiringMap[K, V]) publish(sub *subscriber[K, V], mutation Mutation[K, V]) {
+	defer m.recoverCallback("subscriber")
+	sub.fn(mutation)
+}
+
+// stream hands mutations to write from a single goroutine in the order they
+// were applied, optionally preceded by the current contents of the map. Once
+// write fails the remaining mutations are discarded. The returned stop
+// function waits for pending mutations and returns the first write error.
+func (m *ExpiringMap[K, V]) stream(initial bool, write func(Mutation[K, V]) error) func() error {
+	return m.streamMutations(initial, false, write)
+}
+
+// streamMutations with absent set also receives deletes of keys the map did
+// not hold, which only matter to peers that may still hold them.
+func (m *ExpiringMap[K, V]) streamMutations(initial, absent bool, write func(Mutation[K, V]) error) func() error {
+	ch := make(chan Mutation[K, V], streamBufferSize)
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		for mutation := range ch {
+			if err == nil {
+				err = write(mutation)
+			}
+		}
+	}()
+
+	unsubscribe := m.subscribe(func(mutation Mutation[K, V]) {
+		if absent || !mutation.absent {
+			ch <- mutation
+		}
+	})
+	if initial {
+		now := m.now()
+		m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+			for key, item := range s.items {
+				if !item.expired(now) && !owns.skip(key) {
+					ch <- Mutation[K, V]{Op: MutationSet, Key: key, Val: item.val, TTL: item.ttl, Seq: m.Seq(), Stamp: item.stamp}
+				}
+			}
+		}, nil)
+	}
+
+	var once sync.Once
+	return func() error {
+		once.Do(func() {
+			unsubscribe()
+			// mutations are published with a shard locked, so cycling every
+			// lock guarantees no publish still holds a reference to ch.
+			unlockAll(m.lockAll())
+			close(ch)
+			<-done
+		})
+		return err
+	}
+}
@@ -0,0 +1,172 @@
+package expiringmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	m := New[string, Animal]()
+
+	var mutations []Mutation[string, Animal]
+	unsubscribe := m.Subscribe(func(mutation Mutation[string, Animal]) {
+		mutations = append(mutations, mutation)
+	})
+
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(-time.Minute))
+	m.Delete("elephant")
+	m.Delete("elephant")
+	m.Has("monkey")
+	m.Clear()
+
+	expected := []MutationOp{MutationSet, MutationSet, MutationDelete, MutationExpired, MutationClear}
+	if len(mutations) != len(expected) {
+		t.Fatalf("expected %d mutations, got %d", len(expected), len(mutations))
+	}
+	for i, op := range expected {
+		if mutations[i].Op != op {
+			t.Errorf("expected mutation %d to be %s, got %s", i, op, mutations[i].Op)
+		}
+	}
+	if mutations[0].Key != "elephant" || mutations[0].Val.name != "elephant" {
+		t.Error("set mutation should carry key and value.")
+	}
+
+	unsubscribe()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	if len(mutations) != len(expected) {
+		t.Error("unsubscribed callback should not be called.")
+	}
+}
+
+func TestSubscribeWithReplay(t *testing.T) {
+	m := New(WithReplayBuffer[int, int](3))
+	for i := 0; i < 5; i++ {
+		m.Set(i, i, time.Now().Add(time.Minute))
+	}
+	m.Delete(100)
+
+	var keys []int
+	unsubscribe := m.SubscribeWithReplay(func(mutation Mutation[int, int]) {
+		keys = append(keys, mutation.Key)
+	})
+	m.Set(5, 5, time.Now().Add(time.Minute))
+	unsubscribe()
+	if len(keys) != 4 || keys[0] != 2 || keys[1] != 3 || keys[2] != 4 || keys[3] != 5 {
+		t.Errorf("expected the last 3 mutations then live ones, got %v", keys)
+	}
+
+	plain := New[int, int]()
+	plain.Set(1, 1, time.Now().Add(time.Minute))
+	count := 0
+	defer plain.SubscribeWithReplay(func(Mutation[int, int]) { count++ })()
+	if count != 0 {
+		t.Error("expected nothing to replay without a buffer.")
+	}
+}
+
+func TestMutationSeq(t *testing.T) {
+	m := New[int, int]()
+	m.Set(0, 0, time.Now().Add(time.Minute))
+	if m.Seq() != 1 {
+		t.Errorf("expected seq 1, got %d", m.Seq())
+	}
+
+	var mutex sync.Mutex
+	var seqs []uint64
+	unsubscribe := m.Subscribe(func(mutation Mutation[int, int]) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		seqs = append(seqs, mutation.Seq)
+	})
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				m.Set(g*100+i, i, time.Now().Add(time.Minute))
+			}
+		}(g)
+	}
+	wg.Wait()
+	m.Delete(-1)
+	unsubscribe()
+
+	if len(seqs) != 400 {
+		t.Fatalf("expected 400 mutations, got %d", len(seqs))
+	}
+	for i, seq := range seqs {
+		if seq != uint64(i+2) {
+			t.Fatalf("expected seq %d, got %d", i+2, seq)
+		}
+	}
+}
+
+func TestReplicateSeq(t *testing.T) {
+	m := New[int, int]()
+	m.Set(1, 1, time.Now().Add(time.Minute))
+	m.Set(2, 2, time.Now().Add(time.Minute))
+
+	var mutex sync.Mutex
+	var seqs []uint64
+	stop := m.stream(true, func(mutation Mutation[int, int]) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		seqs = append(seqs, mutation.Seq)
+		return nil
+	})
+	m.Set(3, 3, time.Now().Add(time.Minute))
+	stop()
+	if len(seqs) != 3 || seqs[0] != 2 || seqs[1] != 2 || seqs[2] != 3 {
+		t.Errorf("expected the contents as of seq 2 then seq 3, got %v", seqs)
+	}
+}
+
+func TestSubscribeAsync(t *testing.T) {
+	m := New[string, int]()
+	started, release := make(chan struct{}, 16), make(chan struct{})
+	received := make(chan int, 16)
+	unsubscribe := m.Subscribe(func(mutation Mutation[string, int]) {
+		started <- struct{}{}
+		<-release
+		received <- mutation.Val
+	}, WithAsyncDelivery(2))
+	defer unsubscribe()
+
+	// the subscriber holds the first mutation and buffers the next two, the
+	// writer isn't held up by the rest
+	m.Set("a", 0, time.Now().Add(time.Minute))
+	<-started
+	for i := 1; i < 5; i++ {
+		m.Set("a", i, time.Now().Add(time.Minute))
+	}
+	close(release)
+	for _, expected := range []int{0, 1, 2} {
+		if v := <-received; v != expected {
+			t.Errorf("expected mutations in order, got %d for %d", v, expected)
+		}
+	}
+	if dropped := m.Stats().DroppedMutations; dropped != 2 {
+		t.Errorf("expected 2 dropped mutations, got %d", dropped)
+	}
+}
+
+func TestSubscribeAsyncUnsubscribe(t *testing.T) {
+	m := New[string, int]()
+	defer m.Close()
+	before := len(m.closers.fns)
+	for i := 0; i < 100; i++ {
+		unsubscribe := m.Subscribe(func(Mutation[string, int]) {}, WithAsyncDelivery(1))
+		unsubscribe()
+		unsubscribe()
+	}
+	if n := len(m.closers.fns); n != before {
+		t.Errorf("expected unsubscribing to forget the subscription, got %d closers", n-before)
+	}
+	kept := m.Subscribe(func(Mutation[string, int]) {}, WithAsyncDelivery(1))
+	m.Close()
+	kept()
+}
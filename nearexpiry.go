@@ -0,0 +1,26 @@
+package expiringmap
+
+import "time"
+
+// warnAt schedules the near expiry callback for an entry being stored with
+// ttl, it must be called with the key's shard locked.
+func (m *ExpiringMap[K, V]) warnAt(key K, ttl time.Time) *time.Timer {
+	if m.config.nearExpiry == nil {
+		return nil
+	}
+	lifetime := ttl.Sub(m.now())
+	if lifetime <= 0 {
+		return nil
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Duration(float64(lifetime)*m.config.nearExpiryFraction), func() {
+		s := m.lockShard(key)
+		item, ok := s.items[key]
+		s.unlock()
+		if ok && item.warn == timer {
+			defer m.recoverCallback("near expiry callback")
+			m.config.nearExpiry(key, item.val, item.ttl)
+		}
+	})
+	return timer
+}
@@ -0,0 +1,60 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNearExpiry(t *testing.T) {
+	warned := make(chan string, 10)
+	m := New(WithNearExpiry(0.5, func(key string, value Animal, ttl time.Time) {
+		if time.Until(ttl) <= 0 {
+			t.Error("warning should come before expiry.")
+		}
+		warned <- key
+	}))
+
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(40*time.Millisecond))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(40*time.Millisecond))
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+	m.Delete("monkey")
+
+	select {
+	case key := <-warned:
+		if key != "elephant" {
+			t.Errorf("expected elephant, got %s", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a near expiry warning.")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	select {
+	case key := <-warned:
+		t.Errorf("unexpected warning for %s", key)
+	default:
+	}
+}
+
+func TestNearExpiryReset(t *testing.T) {
+	warned := make(chan string, 10)
+	m := New(WithNearExpiry(0.5, func(key string, value Animal, ttl time.Time) {
+		warned <- value.name
+	}))
+
+	m.Set("elephant", Animal{"grey"}, time.Now().Add(20*time.Millisecond))
+	m.Set("elephant", Animal{"pink"}, time.Now().Add(60*time.Millisecond))
+
+	start := time.Now()
+	select {
+	case name := <-warned:
+		if name != "pink" {
+			t.Errorf("replaced values shouldn't warn, got %s", name)
+		}
+		if time.Since(start) < 20*time.Millisecond {
+			t.Error("replacing should start a new lifetime.")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a near expiry warning.")
+	}
+}
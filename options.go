@@ -0,0 +1,175 @@
+package expiringmap
+
+import "time"
+
+type Option[K comparable, V any] func(*config[K, V])
+
+type config[K comparable, V any] struct {
+	bus Bus[K]
+
+	breakerFailures int
+	breakerCooldown time.Duration
+
+	retry *RetryPolicy
+
+	warmBatchSize   int
+	warmParallelism int
+
+	removalListeners []func(K, V, RemovalReason)
+
+	nearExpiry         func(K, V, time.Time)
+	nearExpiryFraction float64
+
+	ttlProvider func(K, V) time.Time
+
+	maxEntries     int
+	maxWeight      int64
+	weigher        func(K, V) int64
+	evictionPolicy EvictionPolicy
+	bufferedAccess bool
+	readLocking    bool
+
+	clock func() time.Time
+
+	copier func(V) V
+
+	guard      bool
+	onMutation func(K)
+
+	replaySize int
+
+	lww          bool
+	origin       string
+	tombstoneTTL time.Duration
+
+	tracer Tracer
+
+	errorHook func(error)
+
+	growAt, shrinkAt int
+
+	lockSampling int
+
+	leakPatience time.Duration
+	onLeak       func(error)
+}
+
+// WithInvalidationBus drops a key whenever another map on bus sets or deletes
+// it. Errors of the bus go to the error hook, see WithErrorHook.
+func WithInvalidationBus[K comparable, V any](bus Bus[K]) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.bus = bus
+	}
+}
+
+// WithCircuitBreaker makes GetOrLoad fail fast with ErrCircuitOpen for
+// cooldown once the loader has failed failures times in a row for a key.
+func WithCircuitBreaker[K comparable, V any](failures int, cooldown time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.breakerFailures = failures
+		c.breakerCooldown = cooldown
+	}
+}
+
+// WithRetry retries failed loads by GetOrLoad, GetManyOrLoad and Refresher
+// according to policy.
+func WithRetry[K comparable, V any](policy RetryPolicy) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.retry = &policy
+	}
+}
+
+// WithWarmLimits sets how many keys Warm passes to each loader call and how
+// many calls run at once, 100 and 4 by default.
+func WithWarmLimits[K comparable, V any](batchSize, parallelism int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.warmBatchSize = batchSize
+		c.warmParallelism = parallelism
+	}
+}
+
+// WithRemovalListener calls fn whenever a value leaves the map. Like
+// Subscribe, fn runs while the key's shard is locked so it must not block or
+// call back into the map.
+func WithRemovalListener[K comparable, V any](fn func(key K, value V, reason RemovalReason)) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.removalListeners = append(c.removalListeners, fn)
+	}
+}
+
+// WithNearExpiry calls fn once an entry has lived fraction of its lifetime,
+// on its own goroutine, with the entry's expiry. Setting or re-expiring a key
+// starts a new lifetime.
+func WithNearExpiry[K comparable, V any](fraction float64, fn func(key K, value V, ttl time.Time)) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.nearExpiry = fn
+		c.nearExpiryFraction = fraction
+	}
+}
+
+// WithTTLProvider sets the ttl of values stored without one, by Put or by
+// loaders returning a zero ttl, unless the value implements Expirer.
+func WithTTLProvider[K comparable, V any](fn func(key K, value V) time.Time) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.ttlProvider = fn
+	}
+}
+
+// WithMaxEntries bounds the map to n entries, evicting according to the
+// eviction policy, LRU by default, once a write goes over.
+func WithMaxEntries[K comparable, V any](n int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxWeight bounds the total weight of the map's values, as measured by
+// weigher when they are stored, evicting like WithMaxEntries. It can be
+// combined with WithMaxEntries, ARC and SLRU size their lists from the
+// latter.
+func WithMaxWeight[K comparable, V any](max int64, weigher func(key K, value V) int64) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.maxWeight = max
+		c.weigher = weigher
+	}
+}
+
+func WithEvictionPolicy[K comparable, V any](policy EvictionPolicy) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.evictionPolicy = policy
+	}
+}
+
+// WithBufferedAccess records reads for the eviction policy through a lossy
+// buffer applied in batches on a background goroutine, stopped by Close.
+// Reads under load may go unrecorded, which only makes eviction less exact.
+func WithBufferedAccess[K comparable, V any]() Option[K, V] {
+	return func(c *config[K, V]) {
+		c.bufferedAccess = true
+	}
+}
+
+// WithClock makes the map read the time from now instead of time.Now, for
+// tests that need to control expiry.
+func WithClock[K comparable, V any](now func() time.Time) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.clock = now
+	}
+}
+
+// WithValueCopier makes reads return copy(value) rather than the stored value,
+// so callers mutating what they get back can't corrupt the map's copy. Values
+// handed back on removal, by LoadAndDelete or Swap, are not copied.
+func WithValueCopier[K comparable, V any](copy func(V) V) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.copier = copy
+	}
+}
+
+// WithReplayBuffer keeps the last n mutations for SubscribeWithReplay. With it
+// mutations are published one at a time rather than per shard.
+func WithReplayBuffer[K comparable, V any](n int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.replaySize = n
+	}
+}
@@ -0,0 +1,193 @@
+package expiringmap
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+)
+
+// RangeByExpiry calls f for each live entry, soonest expiring first, until f
+// returns false. It sorts a snapshot of the map so f may call back into it,
+// which takes O(n log n) for n entries however soon f stops, use NextExpiry
+// or PopNextExpiring when only the first few are needed.
+func (m *ExpiringMap[K, V]) RangeByExpiry(f func(key K, value V, expiresAt time.Time) bool) {
+	entries := m.snapshot()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].TTL.Before(entries[j].TTL) })
+	for _, e := range entries {
+		if !f(e.Key, e.Val, e.TTL) {
+			return
+		}
+	}
+}
+
+func (m *ExpiringMap[K, V]) snapshot() []Entry[K, V] {
+	var entries []Entry[K, V]
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				e := newEntry(key, item)
+				e.Val = m.read(key, item)
+				entries = append(entries, e)
+			}
+		}
+	}, nil)
+	return entries
+}
+
+// OldestEntry returns the live entry created first.
+func (m *ExpiringMap[K, V]) OldestEntry() (Entry[K, V], bool) {
+	return m.first(func(a, b expiringMapVal[V]) bool { return a.created < b.created })
+}
+
+// NewestEntry returns the live entry created last.
+func (m *ExpiringMap[K, V]) NewestEntry() (Entry[K, V], bool) {
+	return m.first(func(a, b expiringMapVal[V]) bool { return a.created > b.created })
+}
+
+// first returns the live entry ordered first by less.
+func (m *ExpiringMap[K, V]) first(less func(a, b expiringMapVal[V]) bool) (Entry[K, V], bool) {
+	var (
+		found bool
+		key   K
+		best  expiringMapVal[V]
+	)
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for k, item := range s.items {
+			if owns.skip(k) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, k, item)
+			} else if !found || less(item, best) {
+				found, key, best = true, k, item
+			}
+		}
+	}, nil)
+	if !found {
+		return Entry[K, V]{}, false
+	}
+	e := newEntry(key, best)
+	e.Val = m.read(key, best)
+	return e, true
+}
+
+// NextExpiry returns the soonest expiry among live entries. Each shard keeps
+// its soonest expiry, so this only scans the shards whose soonest entry left
+// or expired since they were last asked.
+func (m *ExpiringMap[K, V]) NextExpiry() (time.Time, bool) {
+	var (
+		found bool
+		next  time.Time
+	)
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], _ keyFilter[K]) {
+		ttl, ok := s.soonestExpiry()
+		if ok && ttl.Before(now) {
+			for key, item := range s.items {
+				if item.expired(now) {
+					m.expire(s, key, item)
+				}
+			}
+			ttl, ok = s.soonestExpiry()
+		}
+		if ok && (!found || ttl.Before(next)) {
+			found, next = true, ttl
+		}
+	}, nil)
+	return next, found
+}
+
+// PopNextExpiring removes and returns up to n live entries closest to expiry,
+// soonest first. Every shard is locked while they are chosen so no entry
+// can be popped twice or overtaken by a concurrent write, though only n
+// candidates are held at a time.
+func (m *ExpiringMap[K, V]) PopNextExpiring(n int) []Entry[K, V] {
+	return m.popNextExpiring(n, RemovalDeleted)
+}
+
+func (m *ExpiringMap[K, V]) popNextExpiring(n int, reason RemovalReason) []Entry[K, V] {
+	if n <= 0 {
+		return nil
+	}
+	shards := m.lockAll()
+	defer unlockAll(shards)
+	h := make(expiryHeap[K, V], 0, n)
+	now := m.now()
+	for _, s := range shards {
+		for key, item := range s.items {
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else if len(h) < n {
+				heap.Push(&h, expiryCandidate[K, V]{s, key, item})
+			} else if item.ttl.Before(h[0].item.ttl) {
+				h[0] = expiryCandidate[K, V]{s, key, item}
+				heap.Fix(&h, 0)
+			}
+		}
+	}
+	entries := make([]Entry[K, V], len(h))
+	for i := len(entries) - 1; i >= 0; i-- {
+		c := heap.Pop(&h).(expiryCandidate[K, V])
+		m.remove(c.s, c.key, c.item, reason, false)
+		entries[i] = newEntry(c.key, c.item)
+	}
+	return entries
+}
+
+type expiryCandidate[K comparable, V any] struct {
+	s    *shard[K, V]
+	key  K
+	item expiringMapVal[V]
+}
+
+// expiryHeap is a max heap on ttl, so the candidate expiring last is the one
+// to displace.
+type expiryHeap[K comparable, V any] []expiryCandidate[K, V]
+
+func (h expiryHeap[K, V]) Len() int           { return len(h) }
+func (h expiryHeap[K, V]) Less(i, j int) bool { return h[i].item.ttl.After(h[j].item.ttl) }
+func (h expiryHeap[K, V]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *expiryHeap[K, V]) Push(x any)        { *h = append(*h, x.(expiryCandidate[K, V])) }
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// Ordered is satisfied by the types < works on.
+type Ordered interface {
+	Number | ~string
+}
+
+// SortedKeys returns the live keys sorted by less.
+func (m *ExpiringMap[K, V]) SortedKeys(less func(a, b K) bool) []K {
+	var keys []K
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				keys = append(keys, key)
+			}
+		}
+	}, nil)
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+	return keys
+}
+
+// SortedKeys returns the live keys of m in ascending order.
+func SortedKeys[K Ordered, V any](m *ExpiringMap[K, V]) []K {
+	return m.SortedKeys(func(a, b K) bool { return a < b })
+}
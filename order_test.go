@@ -0,0 +1,203 @@
+package expiringmap
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRangeByExpiry(t *testing.T) {
+	m := New[string, Animal]()
+	now := time.Now()
+	m.Set("tiger", Animal{"tiger"}, now.Add(3*time.Minute))
+	m.Set("elephant", Animal{"elephant"}, now.Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, now.Add(2*time.Minute))
+	m.Set("expired", Animal{"expired"}, now.Add(-time.Minute))
+
+	var keys []string
+	m.RangeByExpiry(func(key string, value Animal, expiresAt time.Time) bool {
+		keys = append(keys, key)
+		return true
+	})
+	expected := []string{"elephant", "monkey", "tiger"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, keys)
+		}
+	}
+
+	count := 0
+	m.RangeByExpiry(func(key string, value Animal, expiresAt time.Time) bool {
+		m.Expire(key, expiresAt.Add(time.Hour))
+		count += 1
+		return false
+	})
+	if count != 1 {
+		t.Error("expected range to stop early.")
+	}
+}
+
+func TestOldestNewestEntry(t *testing.T) {
+	m := New[string, Animal]()
+	if _, ok := m.OldestEntry(); ok {
+		t.Error("empty map has no oldest entry.")
+	}
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+	time.Sleep(time.Millisecond)
+	m.Set("expired", Animal{"expired"}, time.Now().Add(-time.Minute))
+
+	if e, ok := m.OldestEntry(); !ok || e.Key != "elephant" {
+		t.Errorf("expected elephant to be oldest, got %v", e.Key)
+	}
+	if e, ok := m.NewestEntry(); !ok || e.Key != "tiger" {
+		t.Errorf("expected tiger to be newest, got %v", e.Key)
+	}
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	if e, _ := m.OldestEntry(); e.Key != "monkey" {
+		t.Errorf("replacing should renew creation, got %v", e.Key)
+	}
+}
+
+func TestNextExpiry(t *testing.T) {
+	m := New[string, Animal]()
+	if _, ok := m.NextExpiry(); ok {
+		t.Error("empty map has no next expiry.")
+	}
+	now := time.Now()
+	m.Set("elephant", Animal{"elephant"}, now.Add(2*time.Minute))
+	m.Set("monkey", Animal{"monkey"}, now.Add(time.Minute))
+	m.Set("expired", Animal{"expired"}, now.Add(-time.Minute))
+	if next, ok := m.NextExpiry(); !ok || !next.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected monkey's expiry, got %v", next)
+	}
+}
+
+func TestNextExpiryFollowsWrites(t *testing.T) {
+	m := New[int, int]()
+	now := time.Now()
+	r := rand.New(rand.NewSource(1))
+	ttls := make(map[int]time.Time)
+	check := func(step string) {
+		t.Helper()
+		var want time.Time
+		for _, ttl := range ttls {
+			if want.IsZero() || ttl.Before(want) {
+				want = ttl
+			}
+		}
+		next, ok := m.NextExpiry()
+		if ok != !want.IsZero() || !next.Equal(want) {
+			t.Fatalf("after %s expected %v, got %v %v", step, want, next, ok)
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		key := r.Intn(100)
+		ttl := now.Add(time.Duration(r.Intn(1000)+1) * time.Minute)
+		switch r.Intn(4) {
+		case 0:
+			m.Delete(key)
+			delete(ttls, key)
+		case 1:
+			if m.Expire(key, ttl) {
+				ttls[key] = ttl
+			}
+		default:
+			m.Set(key, key, ttl)
+			ttls[key] = ttl
+		}
+		if i%500 == 250 {
+			m.Resize(1 << (i % 7))
+		}
+		check(fmt.Sprint("step ", i))
+	}
+	m.Clear()
+	ttls = nil
+	check("clear")
+}
+
+func TestPopNextExpiring(t *testing.T) {
+	m := New[string, int]()
+	now := time.Now()
+	m.Set("c", 3, now.Add(3*time.Minute))
+	m.Set("a", 1, now.Add(time.Minute))
+	m.Set("stale", 0, now.Add(-time.Second))
+	m.Set("b", 2, now.Add(2*time.Minute))
+
+	entries := m.PopNextExpiring(2)
+	if len(entries) != 2 || entries[0].Key != "a" || entries[1].Key != "b" {
+		t.Fatalf("expected a and b, got %v", entries)
+	}
+	if m.Len() != 1 || !m.Has("c") {
+		t.Error("expected only c to remain.")
+	}
+	if entries := m.PopNextExpiring(5); len(entries) != 1 || entries[0].Val != 3 {
+		t.Errorf("expected c, got %v", entries)
+	}
+	if entries := m.PopNextExpiring(1); len(entries) != 0 {
+		t.Errorf("expected nothing left, got %v", entries)
+	}
+}
+
+func TestPopNextExpiringConcurrent(t *testing.T) {
+	m := New[int, int]()
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i, now.Add(time.Duration(i+1)*time.Minute))
+	}
+	var mu sync.Mutex
+	popped := make(map[int]int)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				entries := m.PopNextExpiring(7)
+				if len(entries) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, e := range entries {
+					popped[e.Key]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(popped) != 1000 {
+		t.Errorf("expected 1000 entries popped, got %d", len(popped))
+	}
+	for key, n := range popped {
+		if n != 1 {
+			t.Errorf("popped %d %d times", key, n)
+		}
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	m := New[string, int]()
+	for _, key := range []string{"monkey", "elephant", "tiger", "ant"} {
+		m.Set(key, 0, time.Now().Add(time.Minute))
+	}
+	m.Set("zebra", 0, time.Now().Add(-time.Second))
+
+	keys := SortedKeys(&m)
+	if strings.Join(keys, ",") != "ant,elephant,monkey,tiger" {
+		t.Errorf("expected ascending keys, got %v", keys)
+	}
+	keys = m.SortedKeys(func(a, b string) bool { return len(a) < len(b) || len(a) == len(b) && a < b })
+	if strings.Join(keys, ",") != "ant,tiger,monkey,elephant" {
+		t.Errorf("expected keys by length, got %v", keys)
+	}
+}
@@ -0,0 +1,59 @@
+package expiringmap
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered from a callback, Callback names the kind of
+// callback that panicked.
+type PanicError struct {
+	Callback string
+	Value    any
+	Stack    []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("expiringmap: %s panicked: %v", e.Callback, e.Value)
+}
+
+// WithErrorHook is called with a PanicError whenever a removal listener or
+// callback, near expiry callback or subscriber panics. Such panics are
+// recovered so they can't take down the goroutine that happened to trigger
+// the callback. It is also called with the errors of the invalidation bus.
+// Without a hook errors are logged.
+func WithErrorHook[K comparable, V any](fn func(err error)) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.errorHook = fn
+	}
+}
+
+// recoverCallback must be deferred directly around a user callback.
+func (m *ExpiringMap[K, V]) recoverCallback(callback string) {
+	if r := recover(); r != nil {
+		m.reportError(&PanicError{Callback: callback, Value: r, Stack: debug.Stack()})
+	}
+}
+
+func (m *ExpiringMap[K, V]) reportError(err error) {
+	if m.config.errorHook != nil {
+		m.config.errorHook(err)
+		return
+	}
+	if p, ok := err.(*PanicError); ok {
+		log.Printf("%v\n%s", p, p.Stack)
+	} else {
+		log.Print(err)
+	}
+}
+
+func (m *ExpiringMap[K, V]) callRemoval(callback string, fn func(K, V, RemovalReason), key K, value V, reason RemovalReason) {
+	defer m.recoverCallback(callback)
+	fn(key, value, reason)
+}
+
+func (m *ExpiringMap[K, V]) callRemovalCallback(fn func(V, RemovalReason), value V, reason RemovalReason) {
+	defer m.recoverCallback("removal callback")
+	fn(value, reason)
+}
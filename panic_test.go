@@ -0,0 +1,48 @@
+package expiringmap
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallbackPanicsAreRecovered(t *testing.T) {
+	var mutex sync.Mutex
+	var callbacks []string
+	m := New(
+		WithErrorHook[string, int](func(err error) {
+			var panicErr *PanicError
+			if !errors.As(err, &panicErr) || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+				t.Errorf("unexpected error %v", err)
+			}
+			mutex.Lock()
+			callbacks = append(callbacks, panicErr.Callback)
+			mutex.Unlock()
+		}),
+		WithRemovalListener(func(key string, value int, reason RemovalReason) { panic("boom") }),
+		WithNearExpiry(0.01, func(key string, value int, ttl time.Time) { panic("boom") }),
+	)
+	m.Subscribe(func(mutation Mutation[string, int]) { panic("boom") })
+
+	m.Set("a", 1, time.Now().Add(50*time.Millisecond))
+	m.SetWithCallback("b", 2, time.Now().Add(-time.Second), func(key string, value int, reason RemovalReason) { panic("boom") })
+	if _, ok := m.Get("b"); ok {
+		t.Error("expected b to have expired")
+	}
+	time.Sleep(20 * time.Millisecond)
+	m.Set("c", 3, time.Now().Add(time.Minute))
+	if v, ok := m.Get("c"); !ok || v != 3 {
+		t.Errorf("expected the map to keep working, got %d %v", v, ok)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	seen := make(map[string]int)
+	for _, callback := range callbacks {
+		seen[callback]++
+	}
+	if seen["removal listener"] != 1 || seen["removal callback"] != 1 || seen["near expiry callback"] != 1 || seen["subscriber"] != 4 {
+		t.Errorf("unexpected recovered panics %v", seen)
+	}
+}
@@ -0,0 +1,63 @@
+package expiringmap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aicacia/go-cmap"
+)
+
+// ForEachParallel calls fn for each live entry from workers goroutines. Like
+// an errgroup the first error stops entries from being handed out and is
+// returned once running calls finish, as is ctx's error if it ends first.
+func (m *ExpiringMap[K, V]) ForEachParallel(ctx context.Context, workers int, fn func(key K, value V) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	entries := make(chan cmap.Entry[K, V])
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entries {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(entry.Key, entry.Val); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+	err := m.RangeCtx(ctx, func(key K, value V) bool {
+		select {
+		case entries <- cmap.Entry[K, V]{Key: key, Val: value}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	close(entries)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,65 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachParallel(t *testing.T) {
+	m := New[int, int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i, time.Now().Add(time.Minute))
+	}
+	var sum, running, peak atomic.Int64
+	err := m.ForEachParallel(context.Background(), 4, func(_ int, value int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		sum.Add(int64(value))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Load() != 999*1000/2 {
+		t.Errorf("expected every entry to be visited, got %d", sum.Load())
+	}
+	if peak.Load() > 4 {
+		t.Errorf("expected at most 4 concurrent calls, got %d", peak.Load())
+	}
+}
+
+func TestForEachParallelError(t *testing.T) {
+	m := New[int, int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i, time.Now().Add(time.Minute))
+	}
+	failed := errors.New("failed")
+	var calls atomic.Int64
+	err := m.ForEachParallel(context.Background(), 2, func(int, int) error {
+		if calls.Add(1) == 10 {
+			return failed
+		}
+		return nil
+	})
+	if !errors.Is(err, failed) {
+		t.Errorf("expected the first error, got %v", err)
+	}
+	if calls.Load() > 20 {
+		t.Errorf("expected the error to stop the scan, got %d calls", calls.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := m.ForEachParallel(ctx, 2, func(int, int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+}
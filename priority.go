@@ -0,0 +1,119 @@
+package expiringmap
+
+import (
+	"sort"
+	"time"
+)
+
+// priorityEvictor keeps an evictor of the map's policy per priority so keys
+// are only offered for eviction once no key of a lower priority is left.
+// Shards switch to one the first time they hold a key with a priority, so
+// maps that never set one pay nothing for it.
+type priorityEvictor[K comparable] struct {
+	newEvictor func() evictor[K]
+	levels     map[int]evictor[K]
+	// order is the priorities in levels, lowest first.
+	order []int
+	// priorities holds the keys of a priority other than 0.
+	priorities map[K]int
+}
+
+func newPriorityEvictor[K comparable](base evictor[K], newEvictor func() evictor[K]) *priorityEvictor[K] {
+	return &priorityEvictor[K]{
+		newEvictor: newEvictor,
+		levels:     map[int]evictor[K]{0: base},
+		order:      []int{0},
+		priorities: make(map[K]int),
+	}
+}
+
+// prioritize moves key to priority, ahead of the add that follows.
+func (e *priorityEvictor[K]) prioritize(key K, priority int) {
+	if old := e.priorities[key]; old != priority {
+		e.levels[old].remove(key)
+	}
+	if priority == 0 {
+		delete(e.priorities, key)
+		return
+	}
+	e.priorities[key] = priority
+	if _, ok := e.levels[priority]; !ok {
+		e.levels[priority] = e.newEvictor()
+		i := sort.SearchInts(e.order, priority)
+		e.order = append(e.order, 0)
+		copy(e.order[i+1:], e.order[i:])
+		e.order[i] = priority
+	}
+}
+
+func (e *priorityEvictor[K]) add(key K, ttl time.Time, now int64) {
+	e.levels[e.priorities[key]].add(key, ttl, now)
+}
+
+func (e *priorityEvictor[K]) access(key K, now int64) {
+	e.levels[e.priorities[key]].access(key, now)
+}
+
+func (e *priorityEvictor[K]) remove(key K) {
+	e.levels[e.priorities[key]].remove(key)
+	delete(e.priorities, key)
+}
+
+func (e *priorityEvictor[K]) victim() (K, int64, bool) {
+	key, _, rank, ok := e.lowest(func(level evictor[K]) (K, int64, bool) { return level.victim() })
+	return key, rank, ok
+}
+
+func (e *priorityEvictor[K]) peek() (K, int64, bool) {
+	key, _, rank, ok := e.lowest(peek[K])
+	return key, rank, ok
+}
+
+// lowest is the victim chosen by pick of the lowest priority with one.
+func (e *priorityEvictor[K]) lowest(pick func(evictor[K]) (K, int64, bool)) (K, int, int64, bool) {
+	for _, priority := range e.order {
+		if key, rank, ok := pick(e.levels[priority]); ok {
+			return key, priority, rank, true
+		}
+	}
+	return *new(K), 0, 0, false
+}
+
+func (e *priorityEvictor[K]) evicted(key K) {
+	if g, ok := e.levels[e.priorities[key]].(ghostEvictor[K]); ok {
+		g.evicted(key)
+	}
+}
+
+// peekVictim peeks at the victim of e with its priority, 0 unless e is a
+// priorityEvictor.
+func peekVictim[K comparable](e evictor[K]) (K, int, int64, bool) {
+	if p, ok := e.(*priorityEvictor[K]); ok {
+		return p.lowest(peek[K])
+	}
+	key, rank, ok := peek(e)
+	return key, 0, rank, ok
+}
+
+// track offers key to the evictor of s at the priority of item, the first key
+// with a priority switching s to a priorityEvictor.
+func (m *ExpiringMap[K, V]) track(s *shard[K, V], key K, item expiringMapVal[V], now int64) {
+	priority := item.priority()
+	p, ok := s.evictor.(*priorityEvictor[K])
+	if !ok && priority != 0 && m.config.evictionPolicy != EvictNone {
+		shards := len(m.roots())
+		p = newPriorityEvictor(s.evictor, func() evictor[K] { return m.config.newEvictor(shards) })
+		s.evictor, ok = p, true
+	}
+	if ok {
+		p.prioritize(key, priority)
+	}
+	s.evictor.add(key, item.ttl, now)
+}
+
+func (item expiringMapVal[V]) priority() int {
+	if item.meta == nil {
+		return 0
+	}
+	return item.meta.priority
+}
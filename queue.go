@@ -0,0 +1,100 @@
+package expiringmap
+
+import "time"
+
+type queued[V any] struct {
+	val V
+	ttl time.Time
+}
+
+// ExpiringQueue keeps a FIFO queue per key whose items expire individually.
+type ExpiringQueue[K comparable, V any] struct {
+	m ExpiringMap[K, []queued[V]]
+}
+
+func NewQueue[K comparable, V any]() *ExpiringQueue[K, V] {
+	return &ExpiringQueue[K, V]{
+		m: New[K, []queued[V]](),
+	}
+}
+
+func (q *ExpiringQueue[K, V]) Push(key K, value V, ttl time.Time) {
+	q.m.compute(key, func(items []queued[V], ok bool) ([]queued[V], time.Time, bool) {
+		next := fresh(items, time.Now(), 1)
+		next = append(next, queued[V]{value, ttl})
+		return next, latestQueued(next), true
+	})
+}
+
+// PopFresh removes and returns the oldest unexpired item for key, dropping
+// any expired items ahead of it.
+func (q *ExpiringQueue[K, V]) PopFresh(key K) (V, bool) {
+	var (
+		value  V
+		popped bool
+	)
+	q.m.compute(key, func(items []queued[V], ok bool) ([]queued[V], time.Time, bool) {
+		next := fresh(items, time.Now(), 0)
+		if len(next) == 0 {
+			return nil, time.Time{}, false
+		}
+		value, popped = next[0].val, true
+		next = next[1:]
+		return next, latestQueued(next), len(next) > 0
+	})
+	return value, popped
+}
+
+func (q *ExpiringQueue[K, V]) PeekFresh(key K) (V, bool) {
+	items, _ := q.m.Get(key)
+	now := time.Now()
+	for _, item := range items {
+		if !item.ttl.Before(now) {
+			return item.val, true
+		}
+	}
+	return *new(V), false
+}
+
+// QueueLen returns the number of unexpired items queued for key.
+func (q *ExpiringQueue[K, V]) QueueLen(key K) int {
+	items, _ := q.m.Get(key)
+	now := time.Now()
+	count := 0
+	for _, item := range items {
+		if !item.ttl.Before(now) {
+			count += 1
+		}
+	}
+	return count
+}
+
+func (q *ExpiringQueue[K, V]) Delete(key K) bool {
+	return q.m.Delete(key)
+}
+
+// Len returns the number of keys with at least one live item.
+func (q *ExpiringQueue[K, V]) Len() int {
+	return q.m.Len()
+}
+
+// fresh copies the unexpired items so stored slices are never modified.
+func fresh[V any](items []queued[V], now time.Time, extra int) []queued[V] {
+	next := make([]queued[V], 0, len(items)+extra)
+	for _, item := range items {
+		if !item.ttl.Before(now) {
+			next = append(next, item)
+		}
+	}
+	return next
+}
+
+func latestQueued[V any](items []queued[V]) time.Time {
+	var max time.Time
+	for _, item := range items {
+		if item.ttl.After(max) {
+			max = item.ttl
+		}
+	}
+	return max
+}
@@ -0,0 +1,48 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := NewQueue[string, Animal]()
+	q.Push("zoo", Animal{"elephant"}, time.Now().Add(10*time.Millisecond))
+	q.Push("zoo", Animal{"monkey"}, time.Now().Add(time.Minute))
+	q.Push("zoo", Animal{"tiger"}, time.Now().Add(time.Minute))
+
+	if q.QueueLen("zoo") != 3 {
+		t.Errorf("expected 3 items, got %d", q.QueueLen("zoo"))
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if value, ok := q.PeekFresh("zoo"); !ok || value.name != "monkey" {
+		t.Error("expected expired head to be skipped.")
+	}
+	for _, name := range []string{"monkey", "tiger"} {
+		value, ok := q.PopFresh("zoo")
+		if !ok || value.name != name {
+			t.Errorf("expected %s, got %v", name, value)
+		}
+	}
+	if _, ok := q.PopFresh("zoo"); ok {
+		t.Error("queue should be empty.")
+	}
+	if q.Len() != 0 {
+		t.Error("empty queues should be removed.")
+	}
+}
+
+func TestQueueExpiry(t *testing.T) {
+	q := NewQueue[string, Animal]()
+	q.Push("zoo", Animal{"elephant"}, time.Now().Add(10*time.Millisecond))
+	q.Push("farm", Animal{"cow"}, time.Now().Add(time.Minute))
+
+	time.Sleep(20 * time.Millisecond)
+	if q.Len() != 1 {
+		t.Error("queue should expire with its last item.")
+	}
+	if _, ok := q.PopFresh("zoo"); ok {
+		t.Error("expired items shouldn't be popped.")
+	}
+}
@@ -0,0 +1,73 @@
+package expiringmap
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RandomKeys returns up to n distinct live keys chosen at random. Like the
+// random evictor each key is picked from a random position in a random
+// shard, moving on to the next shard when that one has none left, so only
+// one shard is locked and scanned at a time. Keys are close to uniformly
+// chosen as keys spread evenly over the shards. The result is not a
+// consistent snapshot when the map is being modified.
+func (m *ExpiringMap[K, V]) RandomKeys(n int) []K {
+	if n <= 0 {
+		return nil
+	}
+	keys := make([]K, 0, n)
+	chosen := make(map[K]struct{}, n)
+	now := m.now()
+	for len(keys) < n {
+		shards := m.roots()
+		start := rand.Intn(len(shards))
+		found, moved := false, false
+		for i := 0; i < len(shards) && !found; i++ {
+			s := shards[(start+i)%len(shards)]
+			s.lock()
+			if s.moved {
+				moved = true
+			} else if key, ok := m.randomKey(s, chosen, now); ok {
+				keys = append(keys, key)
+				chosen[key] = struct{}{}
+				found = true
+			}
+			s.unlock()
+		}
+		// a resize moving the shards sends us round again
+		if !found && !moved {
+			break
+		}
+	}
+	return keys
+}
+
+// randomKey returns a live key not chosen yet from a random position in s,
+// which must be locked, wrapping round to the first such key.
+func (m *ExpiringMap[K, V]) randomKey(s *shard[K, V], chosen map[K]struct{}, now time.Time) (K, bool) {
+	var (
+		first K
+		found bool
+	)
+	if len(s.items) == 0 {
+		return first, false
+	}
+	skip := rand.Intn(len(s.items))
+	i := 0
+	for key, item := range s.items {
+		if item.expired(now) {
+			m.expire(s, key, item)
+			continue
+		}
+		if _, ok := chosen[key]; !ok {
+			if i >= skip {
+				return key, true
+			}
+			if !found {
+				first, found = key, true
+			}
+		}
+		i++
+	}
+	return first, found
+}
@@ -0,0 +1,67 @@
+package expiringmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRandomKeys(t *testing.T) {
+	m := New[string, int]()
+	if len(m.RandomKeys(5)) != 0 {
+		t.Error("empty map has no keys.")
+	}
+	for i := 0; i < 100; i++ {
+		m.Set(strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	m.Set("expired", 0, time.Now().Add(-time.Minute))
+
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		keys := m.RandomKeys(10)
+		if len(keys) != 10 {
+			t.Fatalf("expected 10 keys, got %d", len(keys))
+		}
+		unique := make(map[string]bool)
+		for _, key := range keys {
+			if key == "expired" {
+				t.Error("expired keys shouldn't be sampled.")
+			}
+			if unique[key] {
+				t.Errorf("duplicate key %s", key)
+			}
+			unique[key] = true
+			counts[key] += 1
+		}
+	}
+	if len(counts) < 90 {
+		t.Errorf("expected samples to cover most keys, got %d", len(counts))
+	}
+	if len(m.RandomKeys(1000)) != 100 {
+		t.Error("expected every key when asking for more than the map holds.")
+	}
+}
+
+func TestRandomKeysResize(t *testing.T) {
+	m := New[int, int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i, time.Now().Add(time.Minute))
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, n := range []int{64, 256, 8, 128, 2, 32} {
+			m.Resize(n)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if keys := m.RandomKeys(10); len(keys) != 10 {
+			t.Fatalf("expected 10 keys while resizing, got %d", len(keys))
+		}
+	}
+}
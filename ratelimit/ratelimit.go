@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter is a token bucket per key. A bucket left alone long enough to
+// refill completely is indistinguishable from a new one, so idle buckets
+// expire from the map after that long.
+type Limiter[K comparable] struct {
+	rate    float64
+	burst   float64
+	idle    time.Duration
+	buckets expiringmap.ExpiringMap[K, bucket]
+}
+
+// New returns a Limiter allowing rate events per second per key with bursts
+// of up to burst events. It panics unless both are positive.
+func New[K comparable](rate float64, burst int) *Limiter[K] {
+	if !(rate > 0) || burst <= 0 {
+		panic("ratelimit: rate and burst must be positive")
+	}
+	idle := time.Duration(float64(burst) / rate * float64(time.Second))
+	if idle < time.Second {
+		idle = time.Second
+	}
+	return &Limiter[K]{
+		rate:    rate,
+		burst:   float64(burst),
+		idle:    idle,
+		buckets: expiringmap.New[K, bucket](),
+	}
+}
+
+func (l *Limiter[K]) Allow(key K) bool {
+	return l.AllowN(key, 1)
+}
+
+// AllowN takes n tokens from key's bucket and pushes back its expiry in one
+// update under the key's shard lock.
+func (l *Limiter[K]) AllowN(key K, n int) bool {
+	now := time.Now()
+	allowed := false
+	l.buckets.Compute(key, func(b bucket, ok bool) (bucket, time.Time, bool) {
+		if !ok {
+			b = bucket{tokens: l.burst, last: now}
+		}
+		if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+			b.tokens += elapsed * l.rate
+			if b.tokens > l.burst {
+				b.tokens = l.burst
+			}
+			b.last = now
+		}
+		if allowed = b.tokens >= float64(n); allowed {
+			b.tokens -= float64(n)
+		}
+		return b, now.Add(l.idle), true
+	})
+	return allowed
+}
+
+func (l *Limiter[K]) Reset(key K) {
+	l.buckets.Delete(key)
+}
+
+// Len returns the number of keys with a bucket that has not yet refilled.
+func (l *Limiter[K]) Len() int {
+	return l.buckets.Len()
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAllow(t *testing.T) {
+	l := New[string](1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow("elephant") {
+			t.Errorf("expected burst event %d to be allowed.", i)
+		}
+	}
+	if l.Allow("elephant") {
+		t.Error("expected event beyond burst to be limited.")
+	}
+	if !l.Allow("monkey") {
+		t.Error("keys should be limited independently.")
+	}
+
+	l.Reset("elephant")
+	if !l.Allow("elephant") {
+		t.Error("expected reset key to be allowed.")
+	}
+}
+
+func TestAllowRefill(t *testing.T) {
+	l := New[string](100, 1)
+
+	if !l.Allow("elephant") || l.Allow("elephant") {
+		t.Fatal("expected a single event to be allowed.")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !l.Allow("elephant") {
+		t.Error("expected bucket to refill.")
+	}
+}
+
+func TestIdleBucketsExpire(t *testing.T) {
+	l := New[string](1, 1)
+	l.idle = 10 * time.Millisecond
+
+	l.Allow("elephant")
+	if l.Len() != 1 {
+		t.Fatal("expected a bucket for the key.")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if l.Len() != 0 {
+		t.Error("expected idle bucket to expire.")
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	for _, c := range []struct {
+		rate  float64
+		burst int
+	}{{0, 1}, {-1, 1}, {1, 0}, {1, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected New(%v, %d) to panic", c.rate, c.burst)
+				}
+			}()
+			New[string](c.rate, c.burst)
+		}()
+	}
+}
+
+func TestAllowConcurrent(t *testing.T) {
+	l := New[string](0.001, 100)
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if l.Allow("elephant") {
+					allowed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed.Load() != 100 {
+		t.Errorf("expected exactly the burst to be allowed, got %d", allowed.Load())
+	}
+}
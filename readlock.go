@@ -0,0 +1,32 @@
+package expiringmap
+
+// WithReadOptimizedLocking makes Get share its shard's lock with other Gets,
+// which pays off for read heavy workloads with many goroutines reading the
+// same shards and costs a little otherwise, see the benchmarks module. A Get
+// finding an expired entry retakes the lock exclusively to remove it. Bounded
+// maps only share the lock with WithBufferedAccess, as recording a read in
+// the eviction policy needs it exclusively.
+func WithReadOptimizedLocking[K comparable, V any]() Option[K, V] {
+	return func(c *config[K, V]) {
+		c.readLocking = true
+	}
+}
+
+// getShared is Get under a read lock, done is false when the entry expired
+// and Get must remove it.
+func (m *ExpiringMap[K, V]) getShared(key K) (value V, ok, done bool) {
+	s := m.rlockShard(key)
+	defer s.mutex.RUnlock()
+	item, ok := s.items[key]
+	if !ok {
+		m.miss(s)
+		return value, false, true
+	}
+	now := m.now()
+	if item.expired(now) {
+		return value, false, false
+	}
+	m.accessed(s, key, now)
+	m.hit(s)
+	return m.read(key, item), true, true
+}
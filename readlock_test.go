@@ -0,0 +1,68 @@
+package expiringmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadOptimizedLocking(t *testing.T) {
+	m := New(WithReadOptimizedLocking[string, int]())
+	m.Set("elephant", 1, time.Now().Add(time.Minute))
+	m.Set("monkey", 2, time.Now().Add(-time.Minute))
+
+	// a Get goes ahead while another reader holds the shard
+	s := m.shard("elephant")
+	s.mutex.RLock()
+	done := make(chan struct{})
+	go func() {
+		if v, ok := m.Get("elephant"); !ok || v != 1 {
+			t.Errorf("expected 1, got %d %v", v, ok)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Get to share the lock")
+	}
+	s.mutex.RUnlock()
+
+	if _, ok := m.Get("monkey"); ok {
+		t.Error("expected monkey to have expired")
+	}
+	expected := Stats{Hits: 1, Misses: 1, Sets: 2, Expired: 1}
+	if stats := m.Stats(); stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestReadOptimizedLockingBounded(t *testing.T) {
+	unbuffered := New(WithReadOptimizedLocking[string, int](), WithMaxEntries[string, int](10))
+	if unbuffered.config.readLocking {
+		t.Error("expected Gets to lock exclusively without buffered access")
+	}
+	m := New(WithReadOptimizedLocking[int, int](), WithMaxEntries[int, int](100), WithBufferedAccess[int, int]())
+	defer m.Close()
+	if !m.config.readLocking {
+		t.Fatal("expected Gets to share the lock with buffered access")
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if g%4 == 0 {
+					m.Set(i%200, i, time.Now().Add(time.Minute))
+				} else {
+					m.Get(i % 200)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if m.Len() > 100 {
+		t.Errorf("expected at most 100 keys, got %d", m.Len())
+	}
+}
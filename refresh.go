@@ -0,0 +1,149 @@
+package expiringmap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Refresher keeps the most read keys of a map fresh by reloading them shortly
+// before they expire. Every read of the map finding a key counts, and counts
+// halve every interval so keys that cool down make room for others. Reloads
+// are traced and counted in the map's Stats like other loads, their errors
+// go to its error hook when run by Start.
+type Refresher[K comparable, V any] struct {
+	// TopN is the number of most read keys kept fresh.
+	TopN int
+	// Budget caps the keys reloaded per interval, zero means TopN.
+	Budget int
+	// Ahead is how long before expiry a key is reloaded.
+	Ahead    time.Duration
+	Interval time.Duration
+
+	m      *ExpiringMap[K, V]
+	loader BatchLoader[K, V]
+
+	mutex  sync.Mutex
+	counts map[K]uint64
+	unhook func()
+
+	start, once sync.Once
+	started     bool
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewRefresher starts counting the map's reads, Close stops it.
+func NewRefresher[K comparable, V any](m *ExpiringMap[K, V], loader BatchLoader[K, V]) *Refresher[K, V] {
+	r := &Refresher[K, V]{
+		TopN:     100,
+		Ahead:    10 * time.Second,
+		Interval: time.Second,
+		m:        m,
+		loader:   loader,
+		counts:   make(map[K]uint64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	r.unhook = m.onRead(r.count)
+	return r
+}
+
+func (r *Refresher[K, V]) count(key K) {
+	r.mutex.Lock()
+	r.counts[key] += 1
+	r.mutex.Unlock()
+}
+
+// Start refreshes every Interval until Close is called.
+func (r *Refresher[K, V]) Start() {
+	r.start.Do(func() {
+		r.started = true
+		go func() {
+			defer close(r.done)
+			ticker := time.NewTicker(r.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-r.stop:
+					return
+				case <-ticker.C:
+					if err := r.Refresh(context.Background()); err != nil {
+						r.m.reportError(fmt.Errorf("expiringmap: refreshing: %w", err))
+					}
+				}
+			}
+		}()
+	})
+}
+
+// Close stops counting reads and waits for a started refresh loop to return.
+func (r *Refresher[K, V]) Close() error {
+	r.once.Do(func() {
+		r.unhook()
+		close(r.stop)
+	})
+	// a Start racing Close either started first or never will
+	r.start.Do(func() {})
+	if r.started {
+		<-r.done
+	}
+	return nil
+}
+
+// Refresh reloads the hot keys that are due and returns the loader error.
+func (r *Refresher[K, V]) Refresh(ctx context.Context) error {
+	keys := r.due(r.m.now())
+	if len(keys) == 0 {
+		return nil
+	}
+	values, ttls, err := r.m.loadMany(ctx, "expiringmap.refresh", keys, r.loader)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			r.m.setLoaded(key, value, ttls[key])
+		}
+	}
+	return nil
+}
+
+func (r *Refresher[K, V]) due(now time.Time) []K {
+	type hot struct {
+		key   K
+		count uint64
+	}
+	r.mutex.Lock()
+	hots := make([]hot, 0, len(r.counts))
+	for key, count := range r.counts {
+		hots = append(hots, hot{key, count})
+		if count /= 2; count == 0 {
+			delete(r.counts, key)
+		} else {
+			r.counts[key] = count
+		}
+	}
+	r.mutex.Unlock()
+
+	sort.Slice(hots, func(i, j int) bool { return hots[i].count > hots[j].count })
+	if len(hots) > r.TopN {
+		hots = hots[:r.TopN]
+	}
+	budget := r.Budget
+	if budget <= 0 {
+		budget = r.TopN
+	}
+	keys := make([]K, 0, budget)
+	for _, h := range hots {
+		if len(keys) == budget {
+			break
+		}
+		if ttl, ok := r.m.TTL(h.key); ok && ttl.Before(now.Add(r.Ahead)) {
+			keys = append(keys, h.key)
+		}
+	}
+	return keys
+}
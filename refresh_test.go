@@ -0,0 +1,127 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefresher(t *testing.T) {
+	m := New[string, int]()
+	for _, key := range []string{"elephant", "monkey", "tiger"} {
+		m.Set(key, 0, time.Now().Add(50*time.Millisecond))
+	}
+
+	var mutex sync.Mutex
+	var loaded []string
+	r := NewRefresher[string, int](&m, BatchLoaderFunc[string, int](func(ctx context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		mutex.Lock()
+		loaded = append(loaded, keys...)
+		mutex.Unlock()
+		values := make(map[string]int)
+		ttls := make(map[string]time.Time)
+		for _, key := range keys {
+			values[key] = 1
+			ttls[key] = time.Now().Add(time.Minute)
+		}
+		return values, ttls, nil
+	}))
+	r.TopN = 2
+	r.Budget = 1
+	r.Ahead = time.Second
+
+	for i := 0; i < 3; i++ {
+		m.Get("elephant")
+	}
+	m.Get("monkey")
+	m.Get("monkey")
+	m.Get("tiger")
+
+	if err := r.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0] != "elephant" {
+		t.Errorf("expected the hottest key within budget, got %v", loaded)
+	}
+	if value, _ := m.Get("elephant"); value != 1 {
+		t.Error("expected refreshed value.")
+	}
+
+	loaded = nil
+	r.Refresh(context.Background())
+	if len(loaded) != 1 || loaded[0] != "monkey" {
+		t.Errorf("expected fresh keys to be skipped, got %v", loaded)
+	}
+	loaded = nil
+	r.Refresh(context.Background())
+	if len(loaded) != 0 {
+		t.Errorf("tiger isn't in the top keys, got %v", loaded)
+	}
+}
+
+func TestRefresherStart(t *testing.T) {
+	m := New[string, int]()
+	m.Set("elephant", 0, time.Now().Add(20*time.Millisecond))
+	r := NewRefresher[string, int](&m, BatchLoaderFunc[string, int](func(ctx context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		return map[string]int{"elephant": 1}, map[string]time.Time{"elephant": time.Now().Add(time.Minute)}, nil
+	}))
+	r.Interval = 5 * time.Millisecond
+	r.Ahead = time.Second
+	for i := 0; i < 100; i++ {
+		m.Get("elephant")
+	}
+	r.Start()
+	defer r.Close()
+
+	time.Sleep(40 * time.Millisecond)
+	if value, ok := m.Get("elephant"); !ok || value != 1 {
+		t.Error("expected hot key to be kept fresh.")
+	}
+}
+
+func TestRefresherUsesMap(t *testing.T) {
+	errs := make(chan error, 100)
+	m := New(WithErrorHook[string, int](func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+	m.Set("elephant", 0, time.Now().Add(time.Millisecond*20))
+	var calls atomic.Int64
+	r := NewRefresher[string, int](&m, BatchLoaderFunc[string, int](func(ctx context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		calls.Add(1)
+		return nil, nil, errors.New("boom")
+	}))
+	r.Interval = 5 * time.Millisecond
+	r.Ahead = time.Second
+	m.Get("elephant")
+	r.Start()
+
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "boom") {
+			t.Errorf("expected the loader error, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the refresh error to reach the error hook")
+	}
+	r.Close()
+	if m.Stats().Loads == 0 {
+		t.Error("expected refreshes to be counted as loads")
+	}
+	n := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if calls.Load() != n {
+		t.Error("expected no refresh once Close returned")
+	}
+	m.Get("elephant")
+	if len(r.due(time.Now())) != 0 {
+		t.Error("expected reads after Close to not be counted")
+	}
+	r.Close()
+}
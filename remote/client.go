@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"context"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/remote/expiringmappb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Client struct {
+	client expiringmappb.ExpiringMapClient
+}
+
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{
+		client: expiringmappb.NewExpiringMapClient(cc),
+	}
+}
+
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Time) (bool, error) {
+	res, err := c.client.Set(ctx, &expiringmappb.SetRequest{
+		Key:       key,
+		Value:     value,
+		ExpiresAt: timestamppb.New(ttl),
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.Created, nil
+}
+
+func (c *Client) Get(ctx context.Context, key string) ([]byte, time.Time, bool, error) {
+	res, err := c.client.Get(ctx, &expiringmappb.GetRequest{Key: key})
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	if !res.Found {
+		return nil, time.Time{}, false, nil
+	}
+	if res.ExpiresAt == nil {
+		return res.Value, expiringmap.NoExpiry, true, nil
+	}
+	return res.Value, res.ExpiresAt.AsTime(), true, nil
+}
+
+func (c *Client) Delete(ctx context.Context, key string) (bool, error) {
+	res, err := c.client.Delete(ctx, &expiringmappb.DeleteRequest{Key: key})
+	if err != nil {
+		return false, err
+	}
+	return res.Deleted, nil
+}
+
+// Watch streams mutations of keys starting with prefix to fn until ctx is done
+// or the stream fails.
+func (c *Client) Watch(ctx context.Context, prefix string, fn func(expiringmap.Mutation[string, []byte])) error {
+	stream, err := c.client.Watch(ctx, &expiringmappb.WatchRequest{Prefix: prefix})
+	if err != nil {
+		return err
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		fn(fromWatchEvent(event))
+	}
+}
@@ -0,0 +1,248 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v25.3.0
+// source: expiringmap.proto
+
+package expiringmappb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	ExpiringMap_Set_FullMethodName    = "/expiringmap.v1.ExpiringMap/Set"
+	ExpiringMap_Get_FullMethodName    = "/expiringmap.v1.ExpiringMap/Get"
+	ExpiringMap_Delete_FullMethodName = "/expiringmap.v1.ExpiringMap/Delete"
+	ExpiringMap_Watch_FullMethodName  = "/expiringmap.v1.ExpiringMap/Watch"
+)
+
+// ExpiringMapClient is the client API for ExpiringMap service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ExpiringMapClient interface {
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ExpiringMap_WatchClient, error)
+}
+
+type expiringMapClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExpiringMapClient(cc grpc.ClientConnInterface) ExpiringMapClient {
+	return &expiringMapClient{cc}
+}
+
+func (c *expiringMapClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
+	out := new(SetResponse)
+	err := c.cc.Invoke(ctx, ExpiringMap_Set_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expiringMapClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, ExpiringMap_Get_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expiringMapClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, ExpiringMap_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *expiringMapClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (ExpiringMap_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ExpiringMap_ServiceDesc.Streams[0], ExpiringMap_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &expiringMapWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ExpiringMap_WatchClient interface {
+	Recv() (*WatchEvent, error)
+	grpc.ClientStream
+}
+
+type expiringMapWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *expiringMapWatchClient) Recv() (*WatchEvent, error) {
+	m := new(WatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ExpiringMapServer is the server API for ExpiringMap service.
+// All implementations must embed UnimplementedExpiringMapServer
+// for forward compatibility
+type ExpiringMapServer interface {
+	Set(context.Context, *SetRequest) (*SetResponse, error)
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	Watch(*WatchRequest, ExpiringMap_WatchServer) error
+	mustEmbedUnimplementedExpiringMapServer()
+}
+
+// UnimplementedExpiringMapServer must be embedded to have forward compatible implementations.
+type UnimplementedExpiringMapServer struct {
+}
+
+func (UnimplementedExpiringMapServer) Set(context.Context, *SetRequest) (*SetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (UnimplementedExpiringMapServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedExpiringMapServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedExpiringMapServer) Watch(*WatchRequest, ExpiringMap_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedExpiringMapServer) mustEmbedUnimplementedExpiringMapServer() {}
+
+// UnsafeExpiringMapServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExpiringMapServer will
+// result in compilation errors.
+type UnsafeExpiringMapServer interface {
+	mustEmbedUnimplementedExpiringMapServer()
+}
+
+func RegisterExpiringMapServer(s grpc.ServiceRegistrar, srv ExpiringMapServer) {
+	s.RegisterService(&ExpiringMap_ServiceDesc, srv)
+}
+
+func _ExpiringMap_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpiringMapServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpiringMap_Set_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpiringMapServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpiringMap_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpiringMapServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpiringMap_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpiringMapServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpiringMap_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExpiringMapServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExpiringMap_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExpiringMapServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExpiringMap_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ExpiringMapServer).Watch(m, &expiringMapWatchServer{stream})
+}
+
+type ExpiringMap_WatchServer interface {
+	Send(*WatchEvent) error
+	grpc.ServerStream
+}
+
+type expiringMapWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *expiringMapWatchServer) Send(m *WatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ExpiringMap_ServiceDesc is the grpc.ServiceDesc for ExpiringMap service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExpiringMap_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "expiringmap.v1.ExpiringMap",
+	HandlerType: (*ExpiringMapServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Set",
+			Handler:    _ExpiringMap_Set_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _ExpiringMap_Get_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ExpiringMap_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _ExpiringMap_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "expiringmap.proto",
+}
@@ -0,0 +1,19 @@
+module github.com/aicacia/go-expiringmap/remote
+
+go 1.20
+
+require (
+	github.com/aicacia/go-expiringmap v0.0.0-00010101000000-000000000000
+	google.golang.org/grpc v1.63.2
+	google.golang.org/protobuf v1.33.0
+)
+
+require (
+	github.com/aicacia/go-cmap v0.0.0-20240724224630-f18e88ea2705 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240227224415-6ceb2ff114de // indirect
+)
+
+replace github.com/aicacia/go-expiringmap => ../
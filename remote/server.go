@@ -0,0 +1,151 @@
+package remote
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/remote/expiringmappb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const DefaultWatchBufferSize = 256
+
+type Server struct {
+	expiringmappb.UnimplementedExpiringMapServer
+
+	m               *expiringmap.ExpiringMap[string, []byte]
+	watchBufferSize int
+}
+
+type ServerOption func(*Server)
+
+// WithWatchBufferSize is how many events a watcher may fall behind by before
+// its stream is ended, DefaultWatchBufferSize by default.
+func WithWatchBufferSize(size int) ServerOption {
+	return func(s *Server) {
+		s.watchBufferSize = size
+	}
+}
+
+func NewServer(m *expiringmap.ExpiringMap[string, []byte], options ...ServerOption) *Server {
+	s := &Server{
+		m:               m,
+		watchBufferSize: DefaultWatchBufferSize,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func (s *Server) Set(_ context.Context, req *expiringmappb.SetRequest) (*expiringmappb.SetResponse, error) {
+	if req.ExpiresAt == nil {
+		return nil, status.Error(codes.InvalidArgument, "expires_at is required")
+	}
+	created := s.m.Set(req.Key, req.Value, req.ExpiresAt.AsTime())
+	return &expiringmappb.SetResponse{Created: created}, nil
+}
+
+func (s *Server) Get(_ context.Context, req *expiringmappb.GetRequest) (*expiringmappb.GetResponse, error) {
+	entry, ok := s.m.GetEntry(req.Key)
+	if !ok {
+		return &expiringmappb.GetResponse{}, nil
+	}
+	return &expiringmappb.GetResponse{
+		Found:     true,
+		Value:     entry.Val,
+		ExpiresAt: expiresAt(entry.TTL),
+	}, nil
+}
+
+func (s *Server) Delete(_ context.Context, req *expiringmappb.DeleteRequest) (*expiringmappb.DeleteResponse, error) {
+	return &expiringmappb.DeleteResponse{Deleted: s.m.Delete(req.Key)}, nil
+}
+
+func (s *Server) Watch(req *expiringmappb.WatchRequest, stream expiringmappb.ExpiringMap_WatchServer) error {
+	events := make(chan *expiringmappb.WatchEvent, s.watchBufferSize)
+	overflow := make(chan struct{})
+	var once sync.Once
+	unsubscribe := s.m.Subscribe(func(mutation expiringmap.Mutation[string, []byte]) {
+		if mutation.Op != expiringmap.MutationClear && !strings.HasPrefix(mutation.Key, req.Prefix) {
+			return
+		}
+		select {
+		case events <- toWatchEvent(mutation):
+		default:
+			once.Do(func() { close(overflow) })
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-overflow:
+			return status.Error(codes.ResourceExhausted, "watcher fell behind")
+		case event := <-events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toWatchEvent(mutation expiringmap.Mutation[string, []byte]) *expiringmappb.WatchEvent {
+	event := &expiringmappb.WatchEvent{
+		Key:   mutation.Key,
+		Value: mutation.Val,
+	}
+	switch mutation.Op {
+	case expiringmap.MutationSet:
+		event.Op = expiringmappb.Op_OP_SET
+	case expiringmap.MutationDelete:
+		event.Op = expiringmappb.Op_OP_DELETE
+	case expiringmap.MutationExpired:
+		event.Op = expiringmappb.Op_OP_EXPIRED
+	case expiringmap.MutationClear:
+		event.Op = expiringmappb.Op_OP_CLEAR
+	}
+	if !mutation.TTL.IsZero() {
+		event.ExpiresAt = expiresAt(mutation.TTL)
+	}
+	return event
+}
+
+// expiresAt converts ttl for the wire, where a key that never expires has no
+// expires_at rather than one in 9999.
+func expiresAt(ttl time.Time) *timestamppb.Timestamp {
+	if !ttl.Before(expiringmap.NoExpiry) {
+		return nil
+	}
+	return timestamppb.New(ttl)
+}
+
+func fromWatchEvent(event *expiringmappb.WatchEvent) expiringmap.Mutation[string, []byte] {
+	mutation := expiringmap.Mutation[string, []byte]{
+		Key: event.Key,
+		Val: event.Value,
+	}
+	switch event.Op {
+	case expiringmappb.Op_OP_SET:
+		mutation.Op = expiringmap.MutationSet
+	case expiringmappb.Op_OP_DELETE:
+		mutation.Op = expiringmap.MutationDelete
+	case expiringmappb.Op_OP_EXPIRED:
+		mutation.Op = expiringmap.MutationExpired
+	case expiringmappb.Op_OP_CLEAR:
+		mutation.Op = expiringmap.MutationClear
+	}
+	if event.ExpiresAt != nil {
+		mutation.TTL = event.ExpiresAt.AsTime()
+	} else if mutation.Op == expiringmap.MutationSet {
+		mutation.TTL = expiringmap.NoExpiry
+	}
+	return mutation
+}
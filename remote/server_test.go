@@ -0,0 +1,120 @@
+package remote
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/remote/expiringmappb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T) (*Client, *expiringmap.ExpiringMap[string, []byte]) {
+	m := expiringmap.New[string, []byte]()
+	l := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer()
+	expiringmappb.RegisterExpiringMapServer(s, NewServer(&m))
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return NewClient(cc), &m
+}
+
+func TestSetGetDelete(t *testing.T) {
+	c, m := newTestClient(t)
+	ctx := context.Background()
+
+	ttl := time.Now().Add(time.Minute)
+	if created, err := c.Set(ctx, "elephant", []byte("big"), ttl); err != nil || !created {
+		t.Fatalf("expected set to create entry, got %v %v", created, err)
+	}
+	if value, ok := m.Get("elephant"); !ok || string(value) != "big" {
+		t.Error("set should be visible in the served map.")
+	}
+
+	value, expiresAt, ok, err := c.Get(ctx, "elephant")
+	if err != nil || !ok {
+		t.Fatalf("expected entry, got %v %v", ok, err)
+	}
+	if string(value) != "big" || !expiresAt.Equal(ttl) {
+		t.Error("entry was modified.")
+	}
+
+	c.Set(ctx, "tortoise", []byte("old"), expiringmap.NoExpiry)
+	if _, expiresAt, ok, _ := c.Get(ctx, "tortoise"); !ok || !expiresAt.Equal(expiringmap.NoExpiry) {
+		t.Errorf("expected a key without an expiry, got %v %v", expiresAt, ok)
+	}
+	if event := toWatchEvent(expiringmap.Mutation[string, []byte]{Op: expiringmap.MutationSet, TTL: expiringmap.NoExpiry}); event.ExpiresAt != nil {
+		t.Errorf("expected no expires_at for a key without an expiry, got %v", event.ExpiresAt)
+	}
+
+	if deleted, err := c.Delete(ctx, "elephant"); err != nil || !deleted {
+		t.Fatalf("expected delete, got %v %v", deleted, err)
+	}
+	if _, _, ok, _ := c.Get(ctx, "elephant"); ok {
+		t.Error("entry should have been deleted.")
+	}
+	if deleted, err := c.Delete(ctx, "elephant"); err != nil || deleted {
+		t.Errorf("expected deleting a missing key to report false, got %v %v", deleted, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	c, m := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mutations := make(chan expiringmap.Mutation[string, []byte], 10)
+	go c.Watch(ctx, "animal:", func(mutation expiringmap.Mutation[string, []byte]) {
+		mutations <- mutation
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		m.Set("animal:ready", nil, time.Now().Add(time.Minute))
+		select {
+		case <-mutations:
+		case <-time.After(10 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("watch never started.")
+			}
+			continue
+		}
+		break
+	}
+	for len(mutations) > 0 {
+		<-mutations
+	}
+
+	m.Set("plant:fern", []byte("green"), time.Now().Add(time.Minute))
+	m.Set("animal:tortoise", []byte("old"), expiringmap.NoExpiry)
+	m.Set("animal:elephant", []byte("big"), time.Now().Add(time.Minute))
+	m.Delete("animal:elephant")
+
+	if never := <-mutations; never.Key != "animal:tortoise" || !never.TTL.Equal(expiringmap.NoExpiry) {
+		t.Errorf("expected a key without an expiry to stay so, got %+v", never)
+	}
+
+	set := <-mutations
+	if set.Op != expiringmap.MutationSet || set.Key != "animal:elephant" || string(set.Val) != "big" {
+		t.Errorf("unexpected mutation %+v", set)
+	}
+	del := <-mutations
+	if del.Op != expiringmap.MutationDelete || del.Key != "animal:elephant" {
+		t.Errorf("unexpected mutation %+v", del)
+	}
+}
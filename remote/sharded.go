@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const DefaultReplicas = 128
+
+var ErrNoNodes = errors.New("remote: no nodes available")
+
+type Node interface {
+	Set(ctx context.Context, key string, value []byte, ttl time.Time) (bool, error)
+	Get(ctx context.Context, key string) ([]byte, time.Time, bool, error)
+	Delete(ctx context.Context, key string) (bool, error)
+}
+
+var _ Node = (*Client)(nil)
+
+type ringPoint struct {
+	hash uint32
+	name string
+}
+
+// ShardedClient spreads keys across several remote maps using a consistent
+// hash ring, so adding or removing a node only remaps a fraction of keys.
+type ShardedClient struct {
+	mutex    sync.RWMutex
+	replicas int
+	nodes    map[string]Node
+	ring     []ringPoint
+}
+
+func NewShardedClient(replicas int) *ShardedClient {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	return &ShardedClient{
+		replicas: replicas,
+		nodes:    make(map[string]Node),
+	}
+}
+
+func (c *ShardedClient) AddNode(name string, node Node) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.nodes[name] = node
+	c.rebuild()
+}
+
+func (c *ShardedClient) RemoveNode(name string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.nodes, name)
+	c.rebuild()
+}
+
+func (c *ShardedClient) NodeFor(key string) (string, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.nodeFor(key)
+}
+
+// nodeFor must be called with the mutex held.
+func (c *ShardedClient) nodeFor(key string) (string, bool) {
+	if len(c.ring) == 0 {
+		return "", false
+	}
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(c.ring), func(i int) bool { return c.ring[i].hash >= hash })
+	if i == len(c.ring) {
+		i = 0
+	}
+	return c.ring[i].name, true
+}
+
+func (c *ShardedClient) Set(ctx context.Context, key string, value []byte, ttl time.Time) (bool, error) {
+	node, err := c.node(key)
+	if err != nil {
+		return false, err
+	}
+	return node.Set(ctx, key, value, ttl)
+}
+
+func (c *ShardedClient) Get(ctx context.Context, key string) ([]byte, time.Time, bool, error) {
+	node, err := c.node(key)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+	return node.Get(ctx, key)
+}
+
+func (c *ShardedClient) Delete(ctx context.Context, key string) (bool, error) {
+	node, err := c.node(key)
+	if err != nil {
+		return false, err
+	}
+	return node.Delete(ctx, key)
+}
+
+// node looks up the ring and the node under one lock, so a node removed in
+// between can't turn a key the ring still has nodes for into ErrNoNodes.
+func (c *ShardedClient) node(key string) (Node, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if name, ok := c.nodeFor(key); ok {
+		return c.nodes[name], nil
+	}
+	return nil, ErrNoNodes
+}
+
+func (c *ShardedClient) rebuild() {
+	ring := make([]ringPoint, 0, len(c.nodes)*c.replicas)
+	for name := range c.nodes {
+		for i := 0; i < c.replicas; i++ {
+			hash := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + "-" + name))
+			ring = append(ring, ringPoint{hash: hash, name: name})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		if ring[i].hash == ring[j].hash {
+			return ring[i].name < ring[j].name
+		}
+		return ring[i].hash < ring[j].hash
+	})
+	c.ring = ring
+}
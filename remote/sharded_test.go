@@ -0,0 +1,97 @@
+package remote
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedClient(t *testing.T) {
+	a, ma := newTestClient(t)
+	b, mb := newTestClient(t)
+
+	c := NewShardedClient(0)
+	c.AddNode("a", a)
+	c.AddNode("b", b)
+
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		if _, err := c.Set(ctx, strconv.Itoa(i), []byte("value"), time.Now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ma.Len() == 0 || mb.Len() == 0 || ma.Len()+mb.Len() != 100 {
+		t.Errorf("expected keys to be spread across nodes, got %d and %d.", ma.Len(), mb.Len())
+	}
+	for i := 0; i < 100; i++ {
+		if _, _, ok, err := c.Get(ctx, strconv.Itoa(i)); err != nil || !ok {
+			t.Fatalf("expected key %d to be found, got %v %v", i, ok, err)
+		}
+	}
+}
+
+func TestShardedClientRemap(t *testing.T) {
+	c := NewShardedClient(0)
+	c.AddNode("a", nil)
+	c.AddNode("b", nil)
+	c.AddNode("c", nil)
+
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before[key], _ = c.NodeFor(key)
+	}
+	c.RemoveNode("c")
+	for key, name := range before {
+		after, _ := c.NodeFor(key)
+		if name != "c" && after != name {
+			t.Fatalf("key %s moved from %s to %s although its node remained.", key, name, after)
+		}
+	}
+}
+
+func TestShardedClientNoNodes(t *testing.T) {
+	c := NewShardedClient(0)
+	if _, _, _, err := c.Get(context.Background(), "elephant"); err != ErrNoNodes {
+		t.Errorf("expected ErrNoNodes, got %v", err)
+	}
+}
+
+type nopNode struct{}
+
+func (nopNode) Set(context.Context, string, []byte, time.Time) (bool, error) { return true, nil }
+func (nopNode) Get(context.Context, string) ([]byte, time.Time, bool, error) {
+	return nil, time.Time{}, false, nil
+}
+func (nopNode) Delete(context.Context, string) (bool, error) { return false, nil }
+
+func TestShardedClientConcurrentRemoval(t *testing.T) {
+	c := NewShardedClient(0)
+	c.AddNode("a", nopNode{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				c.AddNode("b", nopNode{})
+				c.RemoveNode("b")
+			}
+		}
+	}()
+	ctx := context.Background()
+	for i := 0; i < 10000; i++ {
+		if _, err := c.Set(ctx, strconv.Itoa(i), nil, time.Now()); err != nil {
+			t.Errorf("expected a node for key %d, got %v", i, err)
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,84 @@
+package expiringmap
+
+import (
+	"fmt"
+	"time"
+)
+
+type RemovalReason int
+
+const (
+	RemovalNone RemovalReason = iota
+	RemovalExpired
+	RemovalEvicted
+	RemovalDeleted
+	RemovalReplaced
+	RemovalCleared
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovalNone:
+		return "none"
+	case RemovalExpired:
+		return "expired"
+	case RemovalEvicted:
+		return "evicted"
+	case RemovalDeleted:
+		return "deleted"
+	case RemovalReplaced:
+		return "replaced"
+	case RemovalCleared:
+		return "cleared"
+	default:
+		return "unknown"
+	}
+}
+
+func (r RemovalReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *RemovalReason) UnmarshalText(text []byte) error {
+	for reason := RemovalNone; reason <= RemovalCleared; reason++ {
+		if reason.String() == string(text) {
+			*r = reason
+			return nil
+		}
+	}
+	return fmt.Errorf("expiringmap: unknown removal reason %q", text)
+}
+
+func (m *ExpiringMap[K, V]) removed(key K, item expiringMapVal[V], reason RemovalReason) {
+	m.stats.removed(reason)
+	for _, fn := range m.config.removalListeners {
+		m.callRemoval("removal listener", fn, key, item.val, reason)
+	}
+	if item.meta != nil && item.meta.onRemoved != nil {
+		m.callRemovalCallback(item.meta.onRemoved, item.val, reason)
+	}
+}
+
+// SetWithCallback is Set calling onRemoved, in addition to any removal
+// listeners, when this value leaves the map, including by being replaced.
+// Like a removal listener it runs while the key's shard is locked.
+func (m *ExpiringMap[K, V]) SetWithCallback(key K, value V, ttl time.Time, onRemoved func(key K, value V, reason RemovalReason)) bool {
+	item := expiringMapVal[V]{val: value, ttl: ttl, created: m.now().UnixNano()}
+	if onRemoved != nil {
+		m.callbacks.Store(true)
+		item.meta = &entryMeta[V]{onRemoved: func(value V, reason RemovalReason) {
+			onRemoved(key, value, reason)
+		}}
+	}
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	isNew := true
+	if old, ok := s.items[key]; ok {
+		if old.expired(m.now()) {
+			m.expire(s, key, old)
+		} else {
+			isNew = false
+		}
+	}
+	return m.store(s, key, item) && isNew
+}
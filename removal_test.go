@@ -0,0 +1,95 @@
+package expiringmap
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type removal struct {
+	key    string
+	value  string
+	reason RemovalReason
+}
+
+func TestRemovalListener(t *testing.T) {
+	var mutex sync.Mutex
+	var removals []removal
+	m := New(WithRemovalListener(func(key string, value string, reason RemovalReason) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		removals = append(removals, removal{key, value, reason})
+	}))
+
+	m.Set("elephant", "grey", time.Now().Add(time.Minute))
+	m.Set("elephant", "pink", time.Now().Add(time.Minute))
+	m.Expire("elephant", time.Now().Add(time.Hour))
+	m.Delete("elephant")
+	m.Set("monkey", "brown", time.Now().Add(10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	m.Get("monkey")
+
+	expected := []removal{
+		{"elephant", "grey", RemovalReplaced},
+		{"elephant", "pink", RemovalDeleted},
+		{"monkey", "brown", RemovalExpired},
+	}
+	if len(removals) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, removals)
+	}
+	for i := range expected {
+		if removals[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], removals[i])
+		}
+	}
+
+	removals = nil
+	m.Set("tiger", "orange", time.Now().Add(time.Minute))
+	m.Set("zebra", "striped", time.Now().Add(time.Minute))
+	m.Clear()
+	sort.Slice(removals, func(i, j int) bool { return removals[i].key < removals[j].key })
+	if len(removals) != 2 || removals[0] != (removal{"tiger", "orange", RemovalCleared}) || removals[1] != (removal{"zebra", "striped", RemovalCleared}) {
+		t.Errorf("expected cleared removals, got %v", removals)
+	}
+}
+
+func TestRemovalReasonString(t *testing.T) {
+	for reason, name := range map[RemovalReason]string{
+		RemovalNone:     "none",
+		RemovalExpired:  "expired",
+		RemovalEvicted:  "evicted",
+		RemovalDeleted:  "deleted",
+		RemovalReplaced: "replaced",
+		RemovalCleared:  "cleared",
+	} {
+		if reason.String() != name {
+			t.Errorf("expected %s, got %s", name, reason)
+		}
+		var parsed RemovalReason
+		if err := parsed.UnmarshalText([]byte(name)); err != nil || parsed != reason {
+			t.Errorf("expected %s to parse, got %s %v", name, parsed, err)
+		}
+	}
+}
+
+func TestSetWithCallback(t *testing.T) {
+	var removed []string
+	m := New[string, int]()
+	onRemoved := func(key string, value int, reason RemovalReason) {
+		removed = append(removed, key+":"+reason.String())
+	}
+	m.SetWithCallback("conn1", 1, time.Now().Add(time.Minute), onRemoved)
+	m.SetWithCallback("conn2", 2, time.Now().Add(time.Minute), onRemoved)
+	m.SetWithCallback("conn3", 3, time.Now().Add(time.Minute), onRemoved)
+	m.Set("plain", 4, time.Now().Add(time.Minute))
+
+	m.Delete("conn1")
+	m.Set("conn2", 5, time.Now().Add(time.Minute))
+	m.Delete("conn2")
+	m.Clear()
+	if strings.Join(removed, ",") != "conn1:deleted,conn2:replaced,conn3:cleared" {
+		t.Errorf("expected each callback once, got %v", removed)
+	}
+}
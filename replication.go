@@ -0,0 +1,93 @@
+package expiringmap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+type MutationSink[K comparable, V any] interface {
+	WriteMutation(mutation Mutation[K, V]) error
+}
+
+type MutationSource[K comparable, V any] interface {
+	ReadMutation() (Mutation[K, V], error)
+}
+
+// ReplicateTo writes the current contents of the map followed by every
+// subsequent mutation to sink. The returned stop function ends replication,
+// waits for pending mutations to be written and returns the first sink error.
+func (m *ExpiringMap[K, V]) ReplicateTo(sink MutationSink[K, V]) func() error {
+	return m.stream(true, sink.WriteMutation)
+}
+
+// ApplyMutations reads mutations from source and applies them to the map
+// until source returns io.EOF.
+func (m *ExpiringMap[K, V]) ApplyMutations(source MutationSource[K, V]) error {
+	return m.applyMutations(context.Background(), source)
+}
+
+func (m *ExpiringMap[K, V]) applyMutations(ctx context.Context, source MutationSource[K, V]) (err error) {
+	_, span := m.startSpan(ctx, "expiringmap.replay")
+	applied := 0
+	defer func() {
+		span.SetAttribute("keys", applied)
+		endSpan(span, err)
+	}()
+	for ; ; applied++ {
+		mutation, err := source.ReadMutation()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		m.ApplyMutation(mutation)
+	}
+}
+
+func (m *ExpiringMap[K, V]) ApplyMutation(mutation Mutation[K, V]) {
+	if m.config.lww && mutation.Stamp != nil && mutation.Op != MutationClear {
+		m.applyStamped(mutation)
+		return
+	}
+	switch mutation.Op {
+	case MutationSet:
+		m.Set(mutation.Key, mutation.Val, mutation.TTL)
+	case MutationDelete, MutationExpired:
+		m.Delete(mutation.Key)
+	case MutationClear:
+		m.Clear()
+	}
+}
+
+type MutationEncoder[K comparable, V any] struct {
+	mutex sync.Mutex
+	enc   *json.Encoder
+}
+
+func NewMutationEncoder[K comparable, V any](w io.Writer) *MutationEncoder[K, V] {
+	return &MutationEncoder[K, V]{enc: json.NewEncoder(w)}
+}
+
+func (e *MutationEncoder[K, V]) WriteMutation(mutation Mutation[K, V]) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.enc.Encode(&mutation)
+}
+
+type MutationDecoder[K comparable, V any] struct {
+	dec *json.Decoder
+}
+
+func NewMutationDecoder[K comparable, V any](r io.Reader) *MutationDecoder[K, V] {
+	return &MutationDecoder[K, V]{dec: json.NewDecoder(r)}
+}
+
+func (d *MutationDecoder[K, V]) ReadMutation() (Mutation[K, V], error) {
+	var mutation Mutation[K, V]
+	err := d.dec.Decode(&mutation)
+	return mutation, err
+}
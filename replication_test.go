@@ -0,0 +1,59 @@
+package expiringmap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type Plant struct {
+	Name string `json:"name"`
+}
+
+func TestReplicateTo(t *testing.T) {
+	primary := New[string, Plant]()
+	primary.Set("fern", Plant{"fern"}, time.Now().Add(time.Minute))
+
+	var buf bytes.Buffer
+	stop := primary.ReplicateTo(NewMutationEncoder[string, Plant](&buf))
+
+	primary.Set("cactus", Plant{"cactus"}, time.Now().Add(time.Minute))
+	primary.Set("moss", Plant{"moss"}, time.Now().Add(time.Minute))
+	primary.Delete("moss")
+	ttl := time.Now().Add(time.Hour)
+	primary.Expire("fern", ttl)
+
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	primary.Set("rose", Plant{"rose"}, time.Now().Add(time.Minute))
+
+	standby := New[string, Plant]()
+	if err := standby.ApplyMutations(NewMutationDecoder[string, Plant](&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	if standby.Len() != 2 {
+		t.Errorf("expected 2 replicated elements, got %d.", standby.Len())
+	}
+	if plant, ok := standby.Get("cactus"); !ok || plant.Name != "cactus" {
+		t.Error("expected cactus to be replicated.")
+	}
+	if got, ok := standby.TTL("fern"); !ok || !got.Equal(ttl) {
+		t.Error("expected fern ttl to be replicated.")
+	}
+	if standby.Has("rose") {
+		t.Error("mutations after stop shouldn't be replicated.")
+	}
+}
+
+func TestApplyMutationClear(t *testing.T) {
+	m := New[string, Plant]()
+	m.Set("fern", Plant{"fern"}, time.Now().Add(time.Minute))
+
+	m.ApplyMutation(Mutation[string, Plant]{Op: MutationClear})
+
+	if !m.IsEmpty() {
+		t.Error("map should be empty.")
+	}
+}
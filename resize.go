@@ -0,0 +1,134 @@
+package expiringmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// resizeInterval is how often at most maps with WithShardResizing check
+// whether to resize.
+const resizeInterval = 100 * time.Millisecond
+
+type resizeState struct {
+	running atomic.Bool
+	checked atomic.Int64
+}
+
+// WithShardResizing doubles the number of shards once they hold more than
+// grow entries on average, and when shrink is positive halves it, though
+// never below the initial count, once they hold fewer than shrink. Resizes
+// run in the background, see Resize. shrink should be well under half of
+// grow so a resize doesn't undo the last.
+func WithShardResizing[K comparable, V any](grow, shrink int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.growAt = grow
+		c.shrinkAt = shrink
+	}
+}
+
+func (c *config[K, V]) newShards(n int) []*shard[K, V] {
+	shards := make([]*shard[K, V], n)
+	for i := range shards {
+		shards[i] = newShard[K, V](uint64(n), uint64(i), c.lockSampling)
+		if c.bounded() {
+			shards[i].evictor = c.newEvictor(n)
+		}
+	}
+	return shards
+}
+
+// Shards returns the number of shards.
+func (m *ExpiringMap[K, V]) Shards() int {
+	return len(m.roots())
+}
+
+// Resize changes the number of shards to n rounded up to a power of two. Keys
+// are moved a shard at a time, only operations on the shard being moved wait
+// meanwhile. Moved keys lose the access history eviction policies keep, and
+// the shards' stats start over, though Stats keeps their lock totals.
+func (m *ExpiringMap[K, V]) Resize(n int) {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	m.resizing.Lock()
+	defer m.resizing.Unlock()
+	old := m.roots()
+	if size == len(old) {
+		return
+	}
+	shards := m.config.newShards(size)
+	// both counts are powers of two, so the keys of an old shard go to the
+	// new shards congruent to it modulo the smaller count
+	span := uint64(len(old))
+	if uint64(size) < span {
+		span = uint64(size)
+	}
+	for _, o := range old {
+		var children []*shard[K, V]
+		for _, c := range shards {
+			if c.index%span == o.index%span {
+				children = append(children, c)
+			}
+		}
+		o.lock()
+		for _, c := range children {
+			c.lock()
+		}
+		for key, item := range o.items {
+			c := o.childOf(children, m.hash(key))
+			c.items[key] = item
+			c.expiryAdded(item.ttl)
+			if c.evictor != nil {
+				m.track(c, key, item, item.created)
+			}
+		}
+		o.items, o.evictor = make(map[K]expiringMapVal[V]), nil
+		o.moved, o.children = true, children
+		for _, c := range children {
+			c.unlock()
+		}
+		o.unlock()
+	}
+	m.table.Store(&shards)
+	m.retire(old)
+}
+
+// checkResize runs after a write left a shard holding entries, starting a
+// background resize when the shards have outgrown, or shrunk below, their
+// thresholds.
+func (m *ExpiringMap[K, V]) checkResize(entries int) {
+	c := &m.config
+	if entries <= c.growAt && (c.shrinkAt <= 0 || entries >= c.shrinkAt || m.Shards() <= shardCount) {
+		return
+	}
+	now := time.Now().UnixNano()
+	if checked := m.resize.checked.Load(); now-checked < int64(resizeInterval) || !m.resize.checked.CompareAndSwap(checked, now) {
+		return
+	}
+	if !m.resize.running.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer m.resize.running.Store(false)
+		if n := m.resizeTarget(); n != m.Shards() {
+			m.Resize(n)
+		}
+	}()
+}
+
+func (m *ExpiringMap[K, V]) resizeTarget() int {
+	entries := 0
+	m.eachShard(func(s *shard[K, V], _ keyFilter[K]) {
+		entries += len(s.items)
+	}, nil)
+	c := &m.config
+	n := m.Shards()
+	for entries/n > c.growAt {
+		n *= 2
+	}
+	for c.shrinkAt > 0 && n > shardCount && entries/n < c.shrinkAt && entries/(n/2) <= c.growAt {
+		n /= 2
+	}
+	return n
+}
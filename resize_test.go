@@ -0,0 +1,151 @@
+package expiringmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResize(t *testing.T) {
+	m := New[int, int]()
+	ttl := time.Now().Add(time.Minute)
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i, ttl)
+	}
+	for _, n := range []int{100, 4, 1, 32} {
+		m.Resize(n)
+		if m.Len() != 1000 {
+			t.Fatalf("expected 1000 keys after resizing to %d, got %d", n, m.Len())
+		}
+		for i := 0; i < 1000; i++ {
+			if v, ok := m.Get(i); !ok || v != i {
+				t.Fatalf("expected %d after resizing to %d, got %d %v", i, n, v, ok)
+			}
+		}
+	}
+	if m.Shards() != 32 {
+		t.Errorf("expected 32 shards, got %d", m.Shards())
+	}
+}
+
+func TestResizeConcurrent(t *testing.T) {
+	m := New[int, int]()
+	ttl := time.Now().Add(time.Minute)
+	for i := 0; i < 1000; i++ {
+		m.Set(i, i, ttl)
+	}
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 1000; !stop.Load(); i++ {
+			m.Set(i, i, ttl)
+			m.Delete(i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; !stop.Load(); i = (i + 1) % 1000 {
+			if _, ok := m.Get(i); !ok {
+				t.Errorf("lost %d", i)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for !stop.Load() {
+			seen := make(map[int]bool)
+			m.Range(func(key, value int) bool {
+				if seen[key] {
+					t.Errorf("saw %d twice", key)
+				}
+				seen[key] = true
+				return true
+			})
+			for i := 0; i < 1000; i++ {
+				if !seen[i] {
+					t.Errorf("range missed %d", i)
+					return
+				}
+			}
+		}
+	}()
+	for _, n := range []int{64, 256, 8, 128, 2, 32} {
+		m.Resize(n)
+	}
+	stop.Store(true)
+	wg.Wait()
+	if m.Len() != 1000 {
+		t.Errorf("expected 1000 keys, got %d", m.Len())
+	}
+}
+
+func TestResizeIterator(t *testing.T) {
+	m := New[int, int]()
+	ttl := time.Now().Add(time.Minute)
+	for i := 0; i < 100; i++ {
+		m.Set(i, i, ttl)
+	}
+	it := m.Iterator()
+	seen := make(map[int]bool)
+	for i := 0; i < 10; i++ {
+		key, _, _ := it.Next()
+		seen[key] = true
+	}
+	m.Resize(128)
+	for key, _, ok := it.Next(); ok; key, _, ok = it.Next() {
+		if seen[key] {
+			t.Errorf("saw %d twice", key)
+		}
+		seen[key] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("expected 100 keys, got %d", len(seen))
+	}
+}
+
+func TestResizeEviction(t *testing.T) {
+	m := New(WithMaxEntries[int, int](100))
+	ttl := time.Now().Add(time.Minute)
+	for i := 0; i < 100; i++ {
+		m.Set(i, i, ttl)
+	}
+	m.Resize(4)
+	m.Get(0)
+	for i := 100; i < 150; i++ {
+		m.Set(i, i, ttl)
+	}
+	if m.Len() != 100 {
+		t.Errorf("expected 100 keys, got %d", m.Len())
+	}
+	if _, ok := m.Get(0); !ok {
+		t.Error("expected recently read key to survive eviction")
+	}
+}
+
+func TestShardResizing(t *testing.T) {
+	m := New(WithShardResizing[int, int](8, 2))
+	ttl := time.Now().Add(time.Minute)
+	for i := 0; i < 10000; i++ {
+		m.Set(i, i, ttl)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Shards() < 1024 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		m.Set(0, 0, ttl)
+	}
+	if m.Shards() < 1024 {
+		t.Fatalf("expected at least 1024 shards, got %d", m.Shards())
+	}
+	m.deleteWhere(func(key int) bool { return key > 0 })
+	for m.Shards() > 32 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		m.Set(0, 0, ttl)
+	}
+	if m.Shards() != 32 {
+		t.Errorf("expected to shrink back to 32 shards, got %d", m.Shards())
+	}
+}
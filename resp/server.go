@@ -0,0 +1,378 @@
+package resp
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/internal/glob"
+)
+
+type Server struct {
+	m         *expiringmap.ExpiringMap[string, string]
+	mutex     sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// maxCursors bounds the SCAN cursors a connection can leave open, the oldest
+// is dropped to make room.
+const maxCursors = 16
+
+// session is the state of one connection, the SCAN cursors it has open.
+type session struct {
+	cursors map[uint64]*expiringmap.Iterator[string, string]
+	order   []uint64
+	next    uint64
+}
+
+func (sess *session) open(it *expiringmap.Iterator[string, string]) uint64 {
+	if sess.cursors == nil {
+		sess.cursors = make(map[uint64]*expiringmap.Iterator[string, string])
+	}
+	if len(sess.order) == maxCursors {
+		sess.take(sess.order[0]).Close()
+	}
+	sess.next += 1
+	sess.cursors[sess.next] = it
+	sess.order = append(sess.order, sess.next)
+	return sess.next
+}
+
+func (sess *session) take(cursor uint64) *expiringmap.Iterator[string, string] {
+	it, ok := sess.cursors[cursor]
+	if !ok {
+		return nil
+	}
+	delete(sess.cursors, cursor)
+	for i, c := range sess.order {
+		if c == cursor {
+			sess.order = append(sess.order[:i], sess.order[i+1:]...)
+			break
+		}
+	}
+	return it
+}
+
+func (sess *session) close() {
+	for _, it := range sess.cursors {
+		it.Close()
+	}
+}
+
+func NewServer(m *expiringmap.ExpiringMap[string, string]) *Server {
+	return &Server{
+		m:         m,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		l.Close()
+		return net.ErrClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.listeners, l)
+		s.mutex.Unlock()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mutex.Lock()
+			closed := s.closed
+			s.mutex.Unlock()
+			if closed {
+				return net.ErrClosed
+			}
+			return err
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+func (s *Server) ServeConn(conn net.Conn) {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		conn.Close()
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+	}()
+
+	r := newReader(conn)
+	w := newWriter(conn)
+	sess := &session{}
+	defer sess.close()
+	for {
+		args, err := r.readCommand()
+		if err != nil {
+			if errors.Is(err, ErrProtocol) {
+				w.error("ERR Protocol error")
+				w.flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := s.handle(w, sess, args)
+		if err := w.flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (s *Server) handle(w *writer, sess *session, args []string) bool {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		if len(args) > 1 {
+			w.bulk(args[1])
+		} else {
+			w.simple("PONG")
+		}
+	case "QUIT":
+		w.simple("OK")
+		return true
+	case "GET":
+		if len(args) != 2 {
+			wrongArgs(w, args[0])
+			break
+		}
+		if value, ok := s.m.Get(args[1]); ok {
+			w.bulk(value)
+		} else {
+			w.null()
+		}
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		if len(args) < 2 {
+			wrongArgs(w, args[0])
+			break
+		}
+		count := int64(0)
+		for _, key := range args[1:] {
+			if s.m.Delete(key) {
+				count += 1
+			}
+		}
+		w.integer(count)
+	case "TTL", "PTTL":
+		if len(args) != 2 {
+			wrongArgs(w, args[0])
+			break
+		}
+		ttl, ok := s.m.TTL(args[1])
+		if !ok {
+			w.integer(-2)
+		} else if !ttl.Before(expiringmap.NoExpiry) {
+			w.integer(-1)
+		} else if strings.EqualFold(args[0], "PTTL") {
+			w.integer(time.Until(ttl).Milliseconds())
+		} else {
+			w.integer(int64((time.Until(ttl) + time.Second/2) / time.Second))
+		}
+	case "EXPIRE", "PEXPIRE":
+		if len(args) != 3 {
+			wrongArgs(w, args[0])
+			break
+		}
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			w.error("ERR value is not an integer or out of range")
+			break
+		}
+		unit := time.Second
+		if strings.EqualFold(args[0], "PEXPIRE") {
+			unit = time.Millisecond
+		}
+		if s.m.Expire(args[1], time.Now().Add(time.Duration(n)*unit)) {
+			w.integer(1)
+		} else {
+			w.integer(0)
+		}
+	case "SCAN":
+		s.scan(w, sess, args)
+	default:
+		w.error("ERR unknown command " + strconv.Quote(args[0]))
+	}
+	return false
+}
+
+func (s *Server) set(w *writer, args []string) {
+	if len(args) < 3 {
+		wrongArgs(w, args[0])
+		return
+	}
+	key, value := args[1], args[2]
+	ttl := expiringmap.NoExpiry
+	nx, xx := false, false
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				w.error("ERR syntax error")
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			unit := time.Second
+			if strings.EqualFold(args[i], "PX") {
+				unit = time.Millisecond
+			}
+			ttl = time.Now().Add(time.Duration(n) * unit)
+			i += 1
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+	if nx && xx {
+		w.error("ERR syntax error")
+		return
+	}
+	if nx {
+		if s.m.Has(key) || !s.m.SetIfAbsent(key, value, ttl) {
+			w.null()
+			return
+		}
+	} else if xx {
+		found := false
+		s.m.Compute(key, func(_ string, ok bool) (string, time.Time, bool) {
+			found = ok
+			return value, ttl, ok
+		})
+		if !found {
+			w.null()
+			return
+		}
+	} else {
+		s.m.Set(key, value, ttl)
+	}
+	w.simple("OK")
+}
+
+// scan walks the map with an Iterator kept open on the connection between
+// calls, the cursor handed back names it. As with Redis, keys written during
+// a scan may or may not be returned, and a cursor the connection does not
+// hold, dropped or from another connection, ends the scan.
+func (s *Server) scan(w *writer, sess *session, args []string) {
+	if len(args) < 2 {
+		wrongArgs(w, args[0])
+		return
+	}
+	cursor, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		w.error("ERR invalid cursor")
+		return
+	}
+	pattern := ""
+	count := 10
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			w.error("ERR syntax error")
+			return
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+			if err := glob.Validate(pattern); err != nil {
+				w.error("ERR invalid pattern")
+				return
+			}
+		case "COUNT":
+			count, err = strconv.Atoi(args[i+1])
+			if err != nil || count < 1 {
+				w.error("ERR syntax error")
+				return
+			}
+		default:
+			w.error("ERR syntax error")
+			return
+		}
+	}
+
+	var it *expiringmap.Iterator[string, string]
+	if cursor == 0 {
+		it = s.m.Iterator()
+	} else if it = sess.take(cursor); it == nil {
+		w.array(2)
+		w.bulk("0")
+		w.array(0)
+		return
+	}
+	matched := make([]string, 0)
+	next := uint64(0)
+	for visited := 0; ; visited++ {
+		if visited == count {
+			next = sess.open(it)
+			break
+		}
+		key, _, ok := it.Next()
+		if !ok {
+			it.Close()
+			break
+		}
+		if pattern == "" || glob.Match(pattern, key) {
+			matched = append(matched, key)
+		}
+	}
+
+	w.array(2)
+	w.bulk(strconv.FormatUint(next, 10))
+	w.array(len(matched))
+	for _, key := range matched {
+		w.bulk(key)
+	}
+}
+
+func wrongArgs(w *writer, cmd string) {
+	w.error("ERR wrong number of arguments for '" + strings.ToLower(cmd) + "' command")
+}
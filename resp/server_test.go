@@ -0,0 +1,236 @@
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+type client struct {
+	conn net.Conn
+	r    *reader
+}
+
+func newTestClient(t *testing.T) (*client, *expiringmap.ExpiringMap[string, string]) {
+	m := expiringmap.New[string, string]()
+	s := NewServer(&m)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &client{conn: conn, r: newReader(conn)}, &m
+}
+
+func (c *client) do(t *testing.T, args ...string) string {
+	w := bufio.NewWriter(c.conn)
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return c.reply(t)
+}
+
+func (c *client) reply(t *testing.T) string {
+	line, err := c.r.readLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch line[0] {
+	case '$':
+		if line == "$-1" {
+			return "(nil)"
+		}
+		var size int
+		fmt.Sscanf(line, "$%d", &size)
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r.r, buf); err != nil {
+			t.Fatal(err)
+		}
+		return string(buf[:size])
+	case '*':
+		var n int
+		fmt.Sscanf(line, "*%d", &n)
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = c.reply(t)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	default:
+		return line[1:]
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	if got := c.do(t, "GET", "elephant"); got != "(nil)" {
+		t.Errorf("expected nil, got %q", got)
+	}
+	if got := c.do(t, "SET", "elephant", "big"); got != "OK" {
+		t.Errorf("expected OK, got %q", got)
+	}
+	if got := c.do(t, "GET", "elephant"); got != "big" {
+		t.Errorf("expected big, got %q", got)
+	}
+	if got := c.do(t, "SET", "elephant", "small", "NX"); got != "(nil)" {
+		t.Errorf("expected nil, got %q", got)
+	}
+	if got := c.do(t, "SET", "monkey", "small", "XX"); got != "(nil)" {
+		t.Errorf("expected nil, got %q", got)
+	}
+	if got := c.do(t, "GET", "monkey"); got != "(nil)" {
+		t.Errorf("expected XX not to create monkey, got %q", got)
+	}
+	if got := c.do(t, "SET", "elephant", "small", "XX"); got != "OK" {
+		t.Errorf("expected OK, got %q", got)
+	}
+	if got := c.do(t, "GET", "elephant"); got != "small" {
+		t.Errorf("expected small, got %q", got)
+	}
+	if got := c.do(t, "DEL", "elephant", "monkey"); got != "1" {
+		t.Errorf("expected 1, got %q", got)
+	}
+}
+
+func TestTTLExpire(t *testing.T) {
+	c, m := newTestClient(t)
+
+	if got := c.do(t, "TTL", "elephant"); got != "-2" {
+		t.Errorf("expected -2, got %q", got)
+	}
+	c.do(t, "SET", "elephant", "big")
+	if got := c.do(t, "TTL", "elephant"); got != "-1" {
+		t.Errorf("expected -1, got %q", got)
+	}
+	c.do(t, "SET", "elephant", "big", "EX", "100")
+	if got := c.do(t, "TTL", "elephant"); got != "100" {
+		t.Errorf("expected 100, got %q", got)
+	}
+	if got := c.do(t, "EXPIRE", "elephant", "10"); got != "1" {
+		t.Errorf("expected 1, got %q", got)
+	}
+	if got := c.do(t, "TTL", "elephant"); got != "10" {
+		t.Errorf("expected 10, got %q", got)
+	}
+	if got := c.do(t, "EXPIRE", "elephant", "-1"); got != "1" {
+		t.Errorf("expected 1, got %q", got)
+	}
+	if m.Has("elephant") {
+		t.Error("element should have expired.")
+	}
+}
+
+// scanPage splits a SCAN reply into its cursor and keys.
+func scanPage(t *testing.T, got string) (string, []string) {
+	cursor, keys, ok := strings.Cut(strings.TrimPrefix(got, "["), " ")
+	if !ok {
+		t.Fatalf("unexpected scan reply %q", got)
+	}
+	return cursor, strings.Fields(strings.TrimSuffix(strings.TrimPrefix(keys, "["), "]]"))
+}
+
+func TestScan(t *testing.T) {
+	c, m := newTestClient(t)
+
+	for i := 0; i < 25; i++ {
+		m.Set(fmt.Sprintf("key:%02d", i), "value", time.Now().Add(time.Minute))
+	}
+
+	seen := make(map[string]bool)
+	pages := 0
+	for cursor := "0"; ; {
+		next, keys := scanPage(t, c.do(t, "SCAN", cursor, "COUNT", "10"))
+		pages += 1
+		if next != "0" && len(keys) != 10 {
+			t.Errorf("expected a full page, got %v", keys)
+		}
+		for _, key := range keys {
+			if seen[key] {
+				t.Errorf("expected %q once", key)
+			}
+			seen[key] = true
+		}
+		if cursor = next; cursor == "0" {
+			break
+		}
+	}
+	if len(seen) != 25 || pages != 3 {
+		t.Errorf("expected 25 keys over 3 pages, got %d over %d", len(seen), pages)
+	}
+	if got := c.do(t, "SCAN", "0", "MATCH", "key:1*", "COUNT", "100"); strings.Count(got, "key:1") != 10 {
+		t.Errorf("unexpected match %q", got)
+	}
+	if next, keys := scanPage(t, c.do(t, "SCAN", "0", "COUNT", "9223372036854775807")); next != "0" || len(keys) != 25 {
+		t.Errorf("expected one page of 25 keys, got %q %v", next, keys)
+	}
+	if got := c.do(t, "SCAN", "0", "MATCH", "[abc"); !strings.HasPrefix(got, "ERR") {
+		t.Errorf("expected a malformed pattern to be rejected, got %q", got)
+	}
+	if got := c.do(t, "SCAN", "12345"); got != "[0 []]" {
+		t.Errorf("expected an unknown cursor to end the scan, got %q", got)
+	}
+	m.Set("user:a/b", "value", time.Now().Add(time.Minute))
+	if got := c.do(t, "SCAN", "0", "MATCH", "user:*", "COUNT", "100"); got != "[0 [user:a/b]]" {
+		t.Errorf("expected * to match /, got %q", got)
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	if got := c.do(t, "FLUSHALL"); !strings.HasPrefix(got, "ERR unknown command") {
+		t.Errorf("expected error, got %q", got)
+	}
+	if got := c.do(t, "X\r\n+OK"); got != `ERR unknown command "X\r\n+OK"` {
+		t.Errorf("expected the command name to be quoted, got %q", got)
+	}
+	if got := c.do(t, "PING"); got != "PONG" {
+		t.Errorf("expected PONG, got %q", got)
+	}
+}
+
+func TestProtocolLimits(t *testing.T) {
+	for _, frame := range []string{
+		"*9223372036854775807\r\n",
+		"*1048577\r\n",
+		"*-2\r\n",
+		"*1\r\n$9223372036854775807\r\n",
+		"*1\r\n$536870913\r\n",
+		"*1\r\n$-2\r\n",
+		"*1\r\n$4\r\nPINGxx",
+	} {
+		c, _ := newTestClient(t)
+		if _, err := io.WriteString(c.conn, frame); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.reply(t); got != "ERR Protocol error" {
+			t.Errorf("expected a protocol error for %q, got %q", frame, got)
+		}
+		if _, err := c.r.readLine(); err != io.EOF {
+			t.Errorf("expected the connection to be closed after %q, got %v", frame, err)
+		}
+	}
+}
+
+func TestReadBulkTruncated(t *testing.T) {
+	r := newReader(strings.NewReader("*1\r\n$10\r\nPING"))
+	if _, err := r.readCommand(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected an unexpected EOF, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy describes how failed loads are retried. The delay before
+// attempt n+1 is Backoff doubled n-1 times, capped at MaxBackoff, with up to
+// Jitter of it randomly removed.
+type RetryPolicy struct {
+	// Attempts is the total number of calls made, including the first.
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is a fraction between 0 and 1.
+	Jitter float64
+	// Retryable reports whether an error is worth retrying, by default every
+	// error except context cancellation is.
+	Retryable func(error) bool
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
@@ -0,0 +1,69 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	m := New(WithRetry[string, Animal](RetryPolicy{Attempts: 3, Backoff: time.Millisecond}))
+	var calls atomic.Int32
+	value, err := m.GetOrLoad(context.Background(), "elephant", func(_ context.Context, key string) (Animal, time.Time, error) {
+		if calls.Add(1) < 3 {
+			return Animal{}, time.Time{}, errors.New("transient")
+		}
+		return Animal{key}, time.Now().Add(time.Minute), nil
+	})
+	if err != nil || value.name != "elephant" {
+		t.Errorf("expected retries to succeed, got %v %v", value, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	errFatal := errors.New("fatal")
+	m := New(WithRetry[string, Animal](RetryPolicy{
+		Attempts:  5,
+		Backoff:   time.Millisecond,
+		Retryable: func(err error) bool { return err != errFatal },
+	}))
+
+	var calls atomic.Int32
+	_, err := m.GetOrLoad(context.Background(), "elephant", func(context.Context, string) (Animal, time.Time, error) {
+		calls.Add(1)
+		return Animal{}, time.Time{}, errFatal
+	})
+	if err != errFatal || calls.Load() != 1 {
+		t.Errorf("non retryable errors shouldn't be retried, got %d calls", calls.Load())
+	}
+
+	calls.Store(0)
+	errTransient := errors.New("transient")
+	_, err = m.GetOrLoad(context.Background(), "elephant", func(context.Context, string) (Animal, time.Time, error) {
+		calls.Add(1)
+		return Animal{}, time.Time{}, errTransient
+	})
+	if err != errTransient || calls.Load() != 5 {
+		t.Errorf("expected the last error after 5 attempts, got %v after %d", err, calls.Load())
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{Backoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	for attempt, expected := range map[int]time.Duration{1: 10, 2: 20, 3: 40, 4: 50, 10: 50} {
+		if delay := p.backoff(attempt); delay != expected*time.Millisecond {
+			t.Errorf("attempt %d: expected %v, got %v", attempt, expected*time.Millisecond, delay)
+		}
+	}
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if delay := p.backoff(1); delay < 5*time.Millisecond || delay > 10*time.Millisecond {
+			t.Fatalf("jittered delay out of range: %v", delay)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+module github.com/aicacia/go-expiringmap/sessionstore/gorilla
+
+go 1.20
+
+require (
+	github.com/aicacia/go-expiringmap v0.0.0-00010101000000-000000000000
+	github.com/gorilla/securecookie v1.1.2
+	github.com/gorilla/sessions v1.2.2
+)
+
+require github.com/aicacia/go-cmap v0.0.0-20240724224630-f18e88ea2705 // indirect
+
+replace github.com/aicacia/go-expiringmap => ../../
@@ -0,0 +1,90 @@
+package gorilla
+
+import (
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+type Values = map[interface{}]interface{}
+
+// Store is an in-memory gorilla/sessions Store, session values live in an
+// ExpiringMap for Options.MaxAge and only the signed session id is sent to
+// the client.
+type Store struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+	m       *expiringmap.ExpiringMap[string, Values]
+}
+
+var _ sessions.Store = (*Store)(nil)
+
+func NewStore(m *expiringmap.ExpiringMap[string, Values], keyPairs ...[]byte) *Store {
+	return &Store{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		m: m,
+	}
+}
+
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	options := *s.Options
+	session.Options = &options
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		return session, err
+	}
+	if values, ok := s.m.Get(session.ID); ok {
+		session.Values = copyValues(values)
+		session.IsNew = false
+	}
+	return session, nil
+}
+
+func (s *Store) Save(_ *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			s.m.Delete(session.ID)
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+	if session.ID == "" {
+		session.ID = strings.TrimRight(base32.StdEncoding.EncodeToString(securecookie.GenerateRandomKey(32)), "=")
+	}
+	ttl := time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second)
+	s.m.Set(session.ID, copyValues(session.Values), ttl)
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+func copyValues(values Values) Values {
+	copied := make(Values, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,45 @@
+package gorilla
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func TestStore(t *testing.T) {
+	m := expiringmap.New[string, Values]()
+	store := NewStore(&m, []byte("secret-key-with-32-bytes-length!"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	session, err := store.Get(req, "session")
+	if err != nil || !session.IsNew {
+		t.Fatalf("expected a new session, got %v", err)
+	}
+	session.Values["animal"] = "elephant"
+	w := httptest.NewRecorder()
+	if err := session.Save(req, w); err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 1 {
+		t.Fatal("expected session to be stored in the map.")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	session, err = store.Get(req, "session")
+	if err != nil || session.IsNew || session.Values["animal"] != "elephant" {
+		t.Fatalf("expected stored session, got %v %v", session.Values, err)
+	}
+
+	session.Options.MaxAge = -1
+	if err := session.Save(req, httptest.NewRecorder()); err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsEmpty() {
+		t.Error("expected logout to delete the session.")
+	}
+}
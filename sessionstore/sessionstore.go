@@ -0,0 +1,42 @@
+package sessionstore
+
+import (
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+// Store implements the scs session store interfaces (Store and
+// IterableStore) on top of an ExpiringMap, sessions expire with their
+// deadline.
+type Store struct {
+	m *expiringmap.ExpiringMap[string, []byte]
+}
+
+func New(m *expiringmap.ExpiringMap[string, []byte]) *Store {
+	return &Store{m: m}
+}
+
+func (s *Store) Find(token string) ([]byte, bool, error) {
+	b, ok := s.m.Get(token)
+	return b, ok, nil
+}
+
+func (s *Store) Commit(token string, b []byte, expiry time.Time) error {
+	s.m.Set(token, append([]byte(nil), b...), expiry)
+	return nil
+}
+
+func (s *Store) Delete(token string) error {
+	s.m.Delete(token)
+	return nil
+}
+
+func (s *Store) All() (map[string][]byte, error) {
+	sessions := make(map[string][]byte)
+	s.m.Range(func(token string, b []byte) bool {
+		sessions[token] = b
+		return true
+	})
+	return sessions, nil
+}
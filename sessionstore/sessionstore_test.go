@@ -0,0 +1,38 @@
+package sessionstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func TestStore(t *testing.T) {
+	m := expiringmap.New[string, []byte]()
+	s := New(&m)
+
+	if _, found, err := s.Find("token"); found || err != nil {
+		t.Error("missing session shouldn't be found.")
+	}
+
+	s.Commit("token", []byte("elephant"), time.Now().Add(time.Minute))
+	s.Commit("expired", []byte("monkey"), time.Now().Add(-time.Minute))
+
+	b, found, err := s.Find("token")
+	if !found || err != nil || string(b) != "elephant" {
+		t.Errorf("expected session, got %q %v %v", b, found, err)
+	}
+	if _, found, _ := s.Find("expired"); found {
+		t.Error("expired session shouldn't be found.")
+	}
+
+	all, _ := s.All()
+	if len(all) != 1 {
+		t.Errorf("expected 1 live session, got %d", len(all))
+	}
+
+	s.Delete("token")
+	if _, found, _ := s.Find("token"); found {
+		t.Error("deleted session shouldn't be found.")
+	}
+}
@@ -0,0 +1,245 @@
+package expiringmap
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shard holds the keys whose hash modulo mod is index. Once a resize has
+// moved its keys to children it stays empty, operations that had already
+// found it follow children to the shards holding their keys.
+type shard[K comparable, V any] struct {
+	mutex   sync.RWMutex
+	items   map[K]expiringMapVal[V]
+	evictor evictor[K]
+
+	mod, index uint64
+	moved      bool
+	children   []*shard[K, V]
+
+	// soonest is the earliest ttl among items unless soonestStale is set,
+	// after the entry it belonged to left or was given a later one.
+	soonest      time.Time
+	soonestStale bool
+
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+	waited   atomic.Int64
+	contends atomic.Uint64
+
+	// one in every sampleRate acquisitions records when it took the lock in
+	// heldSince, held estimates the total time the lock was held from them.
+	sampleRate uint64
+	acquired   uint64
+	heldSince  time.Time
+	held       atomic.Int64
+}
+
+func newShard[K comparable, V any](mod, index uint64, sampleRate int) *shard[K, V] {
+	return &shard[K, V]{
+		items:      make(map[K]expiringMapVal[V]),
+		mod:        mod,
+		index:      index,
+		sampleRate: uint64(sampleRate),
+	}
+}
+
+// lock locks the shard, timing the wait only when the lock is taken.
+func (s *shard[K, V]) lock() {
+	if !s.mutex.TryLock() {
+		start := time.Now()
+		s.mutex.Lock()
+		s.waited.Add(int64(time.Since(start)))
+		s.contends.Add(1)
+	}
+	if s.sampleRate > 0 {
+		s.acquired++
+		if s.acquired%s.sampleRate == 0 {
+			s.heldSince = time.Now()
+		}
+	}
+}
+
+// rlock read locks the shard, read locks are quick so their holds aren't
+// sampled.
+func (s *shard[K, V]) rlock() {
+	if s.mutex.TryRLock() {
+		return
+	}
+	start := time.Now()
+	s.mutex.RLock()
+	s.waited.Add(int64(time.Since(start)))
+	s.contends.Add(1)
+}
+
+func (s *shard[K, V]) lockStats() (contended uint64, wait, held time.Duration) {
+	return s.contends.Load(), time.Duration(s.waited.Load()), time.Duration(s.held.Load())
+}
+
+func (s *shard[K, V]) unlock() {
+	if !s.heldSince.IsZero() {
+		s.held.Add(int64(time.Since(s.heldSince)) * int64(s.sampleRate))
+		s.heldSince = time.Time{}
+	}
+	s.mutex.Unlock()
+}
+
+// expiryAdded, expiryReplaced and expiryRemoved are called with the shard
+// locked after items changed.
+func (s *shard[K, V]) expiryAdded(ttl time.Time) {
+	if len(s.items) == 1 {
+		s.soonest, s.soonestStale = ttl, false
+	} else if !s.soonestStale && ttl.Before(s.soonest) {
+		s.soonest = ttl
+	}
+}
+
+func (s *shard[K, V]) expiryReplaced(old, ttl time.Time) {
+	s.expiryAdded(ttl)
+	if !ttl.Equal(old) {
+		s.expiryRemoved(old)
+	}
+}
+
+func (s *shard[K, V]) expiryRemoved(ttl time.Time) {
+	if ttl.Equal(s.soonest) {
+		s.soonestStale = true
+	}
+}
+
+// soonestExpiry returns the earliest ttl among the shard's items, expired or
+// not, scanning them only when the last one left. The shard must be locked.
+func (s *shard[K, V]) soonestExpiry() (time.Time, bool) {
+	if len(s.items) == 0 {
+		return time.Time{}, false
+	}
+	if s.soonestStale {
+		first := true
+		for _, item := range s.items {
+			if first || item.ttl.Before(s.soonest) {
+				s.soonest, first = item.ttl, false
+			}
+		}
+		s.soonestStale = false
+	}
+	return s.soonest, true
+}
+
+func (s *shard[K, V]) owns(hash uint64) bool {
+	return hash%s.mod == s.index
+}
+
+// child returns the child of a moved shard holding hash.
+func (s *shard[K, V]) child(hash uint64) *shard[K, V] {
+	return s.childOf(s.children, hash)
+}
+
+func (s *shard[K, V]) childOf(children []*shard[K, V], hash uint64) *shard[K, V] {
+	for _, c := range children {
+		if c.owns(hash) {
+			return c
+		}
+	}
+	panic("expiringmap: moved shard has no child for key")
+}
+
+// keyFilter limits a walk of a shard to the keys it returns true for, a nil
+// filter keeps every key.
+type keyFilter[K comparable] func(K) bool
+
+func (f keyFilter[K]) skip(key K) bool {
+	return f != nil && !f(key)
+}
+
+func (m *ExpiringMap[K, V]) roots() []*shard[K, V] {
+	return *m.table.Load()
+}
+
+// shard returns the shard key currently hashes to, which a concurrent resize
+// may move, use lockShard to operate on the key.
+func (m *ExpiringMap[K, V]) shard(key K) *shard[K, V] {
+	roots := m.roots()
+	return roots[m.hash(key)%uint64(len(roots))]
+}
+
+// lockShard locks and returns the shard holding key.
+func (m *ExpiringMap[K, V]) lockShard(key K) *shard[K, V] {
+	hash := m.hash(key)
+	roots := m.roots()
+	s := roots[hash%uint64(len(roots))]
+	s.lock()
+	for s.moved {
+		next := s.child(hash)
+		s.unlock()
+		s = next
+		s.lock()
+	}
+	return s
+}
+
+// rlockShard read locks and returns the shard holding key.
+func (m *ExpiringMap[K, V]) rlockShard(key K) *shard[K, V] {
+	hash := m.hash(key)
+	roots := m.roots()
+	s := roots[hash%uint64(len(roots))]
+	s.rlock()
+	for s.moved {
+		next := s.child(hash)
+		s.mutex.RUnlock()
+		s = next
+		s.rlock()
+	}
+	return s
+}
+
+// eachShard calls locked with each shard holding keys locked in turn, then
+// calls then, if it isn't nil, with the shard unlocked. It stops if then
+// returns false. Each key is walked once even when a resize moves keys
+// during the walk, walks of the children of a moved shard are filtered to
+// the keys it held.
+func (m *ExpiringMap[K, V]) eachShard(locked func(s *shard[K, V], owns keyFilter[K]), then func() bool) bool {
+	for _, s := range m.roots() {
+		if !m.visit(s, nil, locked, then) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *ExpiringMap[K, V]) visit(s *shard[K, V], owns keyFilter[K], locked func(s *shard[K, V], owns keyFilter[K]), then func() bool) bool {
+	s.lock()
+	if s.moved {
+		children := s.children
+		s.unlock()
+		inRange := func(key K) bool {
+			return s.owns(m.hash(key)) && !owns.skip(key)
+		}
+		for _, c := range children {
+			if !m.visit(c, inRange, locked, then) {
+				return false
+			}
+		}
+		return true
+	}
+	locked(s, owns)
+	s.unlock()
+	return then == nil || then()
+}
+
+// lockAll locks every shard, waiting for any resize to finish.
+func (m *ExpiringMap[K, V]) lockAll() []*shard[K, V] {
+	m.resizing.Lock()
+	defer m.resizing.Unlock()
+	shards := m.roots()
+	for _, s := range shards {
+		s.lock()
+	}
+	return shards
+}
+
+func unlockAll[K comparable, V any](shards []*shard[K, V]) {
+	for _, s := range shards {
+		s.unlock()
+	}
+}
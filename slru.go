@@ -0,0 +1,93 @@
+package expiringmap
+
+import (
+	"container/list"
+	"time"
+)
+
+const (
+	slruProtectedRatio = 0.8
+	slruProtectedRank  = 1 << 62
+)
+
+// slruEvictor admits keys to a probation segment and promotes them to the
+// protected segment on their second hit. Protected keys pushed out by newer
+// promotions fall back to probation, and victims always come from probation
+// first so a scan can't flush the protected keys.
+type slruEvictor[K comparable] struct {
+	protectedCapacity int
+
+	probation, protected *list.List
+	elements             map[K]*list.Element
+}
+
+type slruItem[K comparable] struct {
+	key  K
+	used int64
+	list *list.List
+}
+
+func newSLRUEvictor[K comparable](capacity int) *slruEvictor[K] {
+	protected := int(float64(capacity) * slruProtectedRatio)
+	if protected < 1 {
+		protected = 1
+	}
+	return &slruEvictor[K]{
+		protectedCapacity: protected,
+		probation:         list.New(),
+		protected:         list.New(),
+		elements:          make(map[K]*list.Element),
+	}
+}
+
+func (e *slruEvictor[K]) add(key K, ttl time.Time, now int64) {
+	if _, ok := e.elements[key]; ok {
+		e.access(key, now)
+		return
+	}
+	e.elements[key] = e.probation.PushFront(&slruItem[K]{key: key, used: now, list: e.probation})
+}
+
+func (e *slruEvictor[K]) access(key K, now int64) {
+	el, ok := e.elements[key]
+	if !ok {
+		return
+	}
+	item := el.Value.(*slruItem[K])
+	item.used = now
+	if item.list == e.protected {
+		e.protected.MoveToFront(el)
+		return
+	}
+	e.probation.Remove(el)
+	item.list = e.protected
+	e.elements[key] = e.protected.PushFront(item)
+	for e.protected.Len() > e.protectedCapacity {
+		demoted := e.protected.Back()
+		e.protected.Remove(demoted)
+		item := demoted.Value.(*slruItem[K])
+		item.list = e.probation
+		e.elements[item.key] = e.probation.PushFront(item)
+	}
+}
+
+func (e *slruEvictor[K]) remove(key K) {
+	if el, ok := e.elements[key]; ok {
+		el.Value.(*slruItem[K]).list.Remove(el)
+		delete(e.elements, key)
+	}
+}
+
+// victim ranks protected keys after every probation key so shards holding
+// only protected keys aren't picked while another shard has probation keys.
+func (e *slruEvictor[K]) victim() (K, int64, bool) {
+	if el := e.probation.Back(); el != nil {
+		item := el.Value.(*slruItem[K])
+		return item.key, item.used, true
+	}
+	if el := e.protected.Back(); el != nil {
+		item := el.Value.(*slruItem[K])
+		return item.key, slruProtectedRank + item.used, true
+	}
+	return *new(K), 0, false
+}
@@ -0,0 +1,56 @@
+package expiringmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSLRUEvictor(t *testing.T) {
+	e := newSLRUEvictor[string](3)
+	e.add("elephant", NoExpiry, 1)
+	e.add("monkey", NoExpiry, 2)
+	e.add("tiger", NoExpiry, 3)
+	e.access("elephant", 4)
+	e.access("monkey", 5)
+
+	if key, _, _ := e.victim(); key != "tiger" {
+		t.Errorf("expected probation keys to go first, got %s", key)
+	}
+	e.access("tiger", 6)
+	if key, _, _ := e.victim(); key != "elephant" {
+		t.Errorf("expected the oldest protected key to be demoted, got %s", key)
+	}
+	e.remove("elephant")
+	if key, _, _ := e.victim(); key != "monkey" {
+		t.Errorf("expected the protected segment once probation is empty, got %s", key)
+	}
+}
+
+func TestEvictSLRUScan(t *testing.T) {
+	m := New(
+		WithMaxEntries[string, int](320),
+		WithEvictionPolicy[string, int](EvictSLRU),
+	)
+	hot := make([]string, 16)
+	for i := range hot {
+		hot[i] = "hot" + strconv.Itoa(i)
+		m.Set(hot[i], i, time.Now().Add(time.Minute))
+		m.Get(hot[i])
+	}
+	for i := 0; i < 5000; i++ {
+		m.Set("scan"+strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	if m.Len() > 320 {
+		t.Errorf("expected at most 320 entries, got %d", m.Len())
+	}
+	kept := 0
+	for _, key := range hot {
+		if m.Has(key) {
+			kept += 1
+		}
+	}
+	if kept != len(hot) {
+		t.Errorf("expected protected keys to survive a scan, kept %d", kept)
+	}
+}
@@ -0,0 +1,323 @@
+package expiringmap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+)
+
+type snapshotConfig struct {
+	ctx         context.Context
+	aead        cipher.AEAD
+	compression Compression
+	recognized  []Compression
+	migrations  map[int]Migration
+	seq         uint64
+	readSeq     *uint64
+	err         error
+}
+
+type SnapshotOption func(*snapshotConfig)
+
+// WithEncryption seals snapshots with aead.
+func WithEncryption(aead cipher.AEAD) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.aead = aead
+	}
+}
+
+// WithEncryptionKey seals snapshots with AES-GCM, key must be 16, 24 or 32
+// bytes long.
+func WithEncryptionKey(key []byte) SnapshotOption {
+	return func(c *snapshotConfig) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.aead, c.err = cipher.NewGCM(block)
+	}
+}
+
+// Compression compresses snapshots, its output must begin with Magic so that
+// restoring recognizes it.
+type Compression interface {
+	Magic() []byte
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+type gzipCompression struct{}
+
+func (gzipCompression) Magic() []byte { return []byte{0x1f, 0x8b} }
+
+func (gzipCompression) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCompression) NewReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+// Gzip compresses snapshots with gzip, restoring always recognizes it.
+var Gzip Compression = gzipCompression{}
+
+// WithCompression compresses snapshots with compression, and lets restoring
+// recognize it. Other formats such as zstd can be plugged in by implementing
+// Compression.
+func WithCompression(compression Compression) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.compression = compression
+		c.recognized = append(c.recognized, compression)
+	}
+}
+
+// WithSnapshotContext parents the spans of snapshot writes and restores on
+// ctx.
+func WithSnapshotContext(ctx context.Context) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.ctx = ctx
+	}
+}
+
+// WithSnapshotSeq stores in seq, as a snapshot is restored, the sequence
+// number of the latest mutation it reflects, so a consumer of the map's
+// mutations can resume after it. Snapshots that never recorded one leave 0.
+func WithSnapshotSeq(seq *uint64) SnapshotOption {
+	return func(c *snapshotConfig) {
+		c.readSeq = seq
+	}
+}
+
+func newSnapshotConfig(options []SnapshotOption) (snapshotConfig, error) {
+	c := snapshotConfig{ctx: context.Background(), recognized: []Compression{Gzip}}
+	for _, option := range options {
+		option(&c)
+	}
+	return c, c.err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// layers closes the outermost writer first so each flushes into the next.
+type layers struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (l *layers) Close() error {
+	var err error
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if cerr := l.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	l.closers = nil
+	return err
+}
+
+// NewSnapshotWriter wraps w so that what is written to it is encoded as
+// options require, giving ReplicateTo an append only log ReadSnapshot can
+// restore. Close flushes it without closing w.
+func NewSnapshotWriter(w io.Writer, options ...SnapshotOption) (io.WriteCloser, error) {
+	c, err := newSnapshotConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	l := &layers{Writer: w}
+	if c.aead != nil {
+		sw := newSealWriter(l.Writer, c.aead)
+		l.Writer, l.closers = sw, append(l.closers, sw)
+	}
+	if c.compression != nil {
+		cw, err := c.compression.NewWriter(l.Writer)
+		if err != nil {
+			return nil, err
+		}
+		l.Writer, l.closers = cw, append(l.closers, cw)
+	}
+	if err := writeSnapshotHeader(l.Writer, c.seq); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if len(l.closers) == 0 {
+		return nopCloser{w}, nil
+	}
+	return l, nil
+}
+
+// NewSnapshotReader decodes what a writer from NewSnapshotWriter with the
+// same encryption option wrote to r, detecting any compression and migrating
+// records written by older versions.
+func NewSnapshotReader(r io.Reader, options ...SnapshotOption) (io.Reader, error) {
+	c, err := newSnapshotConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	if c.aead != nil {
+		r = newOpenReader(r, c.aead)
+	}
+	br := bufio.NewReader(r)
+	for _, compression := range c.recognized {
+		magic := compression.Magic()
+		if head, _ := br.Peek(len(magic)); bytes.Equal(head, magic) {
+			cr, err := compression.NewReader(br)
+			if err != nil {
+				return nil, err
+			}
+			return newMigratingReader(cr, &c), nil
+		}
+	}
+	return newMigratingReader(br, &c), nil
+}
+
+// WriteSnapshot writes the live entries of the map to w, recording the
+// sequence number of the latest mutation they reflect, see WithSnapshotSeq.
+func (m *ExpiringMap[K, V]) WriteSnapshot(w io.Writer, options ...SnapshotOption) (err error) {
+	c, err := newSnapshotConfig(options)
+	if err != nil {
+		return err
+	}
+	_, span := m.startSpan(c.ctx, "expiringmap.write_snapshot")
+	entries := 0
+	defer func() {
+		span.SetAttribute("keys", entries)
+		endSpan(span, err)
+	}()
+	// mutations after seq may be reflected too, a consumer resuming after seq
+	// may see them twice
+	seq := m.Seq()
+	options = append(options[:len(options):len(options)], func(c *snapshotConfig) { c.seq = seq })
+	sw, err := NewSnapshotWriter(w, options...)
+	if err != nil {
+		return err
+	}
+	enc := NewMutationEncoder[K, V](sw)
+	now := m.now()
+	var mutations []Mutation[K, V]
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if !item.expired(now) && !owns.skip(key) {
+				mutations = append(mutations, Mutation[K, V]{Op: MutationSet, Key: key, Val: m.read(key, item), TTL: item.ttl, Stamp: item.stamp})
+			}
+		}
+	}, func() bool {
+		for _, mutation := range mutations {
+			if err = enc.WriteMutation(mutation); err != nil {
+				return false
+			}
+			entries++
+		}
+		mutations = mutations[:0]
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// ReadSnapshot applies a snapshot, or a log of mutations, read from r to the
+// map.
+func (m *ExpiringMap[K, V]) ReadSnapshot(r io.Reader, options ...SnapshotOption) error {
+	c, err := newSnapshotConfig(options)
+	if err != nil {
+		return err
+	}
+	sr, err := NewSnapshotReader(r, options...)
+	if err != nil {
+		return err
+	}
+	return m.applyMutations(c.ctx, NewMutationDecoder[K, V](sr))
+}
+
+// ConflictPolicy decides which entry MergeFromSnapshot keeps when the map
+// already holds a key the snapshot sets.
+type ConflictPolicy int
+
+const (
+	// PreferFresher keeps the entry expiring last, or in last write wins mode
+	// of two stamped entries the one written last. The map's entry wins ties.
+	PreferFresher ConflictPolicy = iota
+	// PreferExisting keeps the map's entry.
+	PreferExisting
+	// PreferSnapshot keeps the snapshot's entry, and applies the deletes a
+	// log of mutations holds.
+	PreferSnapshot
+)
+
+// MergeFromSnapshot streams a snapshot read from r into the map while it
+// keeps serving, locking one key at a time. Expired entries are skipped and
+// keys the map holds are resolved by policy.
+func (m *ExpiringMap[K, V]) MergeFromSnapshot(r io.Reader, policy ConflictPolicy, options ...SnapshotOption) (err error) {
+	c, err := newSnapshotConfig(options)
+	if err != nil {
+		return err
+	}
+	sr, err := NewSnapshotReader(r, options...)
+	if err != nil {
+		return err
+	}
+	_, span := m.startSpan(c.ctx, "expiringmap.merge_snapshot")
+	merged := 0
+	defer func() {
+		span.SetAttribute("keys", merged)
+		endSpan(span, err)
+	}()
+	dec := NewMutationDecoder[K, V](sr)
+	for ; ; merged++ {
+		mutation, err := dec.ReadMutation()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if mutation.Op != MutationSet {
+			if policy == PreferSnapshot && mutation.Op != MutationClear {
+				m.ApplyMutation(mutation)
+			}
+			continue
+		}
+		m.mergeEntry(mutation, policy)
+	}
+}
+
+func (m *ExpiringMap[K, V]) mergeEntry(mutation Mutation[K, V], policy ConflictPolicy) {
+	now := m.now()
+	if mutation.TTL.Before(now) {
+		return
+	}
+	key := mutation.Key
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	if item, ok := m.liveItem(s, key); ok {
+		switch policy {
+		case PreferExisting:
+			return
+		case PreferFresher:
+			if item.stamp != nil && mutation.Stamp != nil {
+				if !item.stamp.Less(mutation.Stamp) {
+					return
+				}
+			} else if !mutation.TTL.After(item.ttl) {
+				return
+			}
+		}
+	}
+	var stamp *Stamp
+	if m.config.lww && mutation.Stamp != nil {
+		m.witness(mutation.Stamp)
+		stamp = mutation.Stamp
+	}
+	m.store(s, key, expiringMapVal[V]{val: mutation.Val, ttl: mutation.TTL, created: now.UnixNano(), stamp: stamp})
+}
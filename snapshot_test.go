@@ -0,0 +1,151 @@
+package expiringmap
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	m := New[string, Plant]()
+	ttl := time.Now().Add(time.Minute).Round(0)
+	m.Set("fern", Plant{"fern"}, ttl)
+	m.Set("cactus", Plant{"cactus"}, ttl)
+	m.Set("moss", Plant{"moss"}, time.Now().Add(-time.Minute))
+
+	var buf bytes.Buffer
+	if err := m.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := New[string, Plant]()
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != 2 {
+		t.Fatalf("expected 2 live entries to be restored, got %d", restored.Len())
+	}
+	if e, ok := restored.GetEntry("fern"); !ok || e.Val.Name != "fern" || !e.TTL.Equal(ttl) {
+		t.Errorf("expected fern to be restored with its ttl, got %+v", e)
+	}
+}
+
+func TestSnapshotSeq(t *testing.T) {
+	m := New[string, Plant]()
+	m.Set("fern", Plant{"fern"}, time.Now().Add(time.Minute))
+	m.Set("cactus", Plant{"cactus"}, time.Now().Add(time.Minute))
+
+	var buf bytes.Buffer
+	if err := m.WriteSnapshot(&buf, WithCompression(Gzip)); err != nil {
+		t.Fatal(err)
+	}
+	var seq uint64
+	restored := New[string, Plant]()
+	if err := restored.ReadSnapshot(&buf, WithSnapshotSeq(&seq)); err != nil {
+		t.Fatal(err)
+	}
+	if seq != m.Seq() || seq != 2 {
+		t.Errorf("expected the snapshot to record sequence %d, got %d", m.Seq(), seq)
+	}
+}
+
+func TestSnapshotLog(t *testing.T) {
+	m := New[string, Plant]()
+	var buf bytes.Buffer
+	w, err := NewSnapshotWriter(&buf, WithEncryptionKey(make([]byte, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := m.ReplicateTo(NewMutationEncoder[string, Plant](w))
+	m.Set("fern", Plant{"fern"}, time.Now().Add(time.Minute))
+	m.Set("cactus", Plant{"cactus"}, time.Now().Add(time.Minute))
+	m.Delete("fern")
+	if err := stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := New[string, Plant]()
+	if err := restored.ReadSnapshot(&buf, WithEncryptionKey(make([]byte, 32))); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Has("fern") || !restored.Has("cactus") {
+		t.Error("expected the log to be replayed")
+	}
+}
+
+func TestCompressedSnapshot(t *testing.T) {
+	m := New[string, Plant]()
+	for i := 0; i < 1000; i++ {
+		m.Set(strconv.Itoa(i), Plant{"fern"}, time.Now().Add(time.Minute))
+	}
+	var plain, compressed, sealed bytes.Buffer
+	if err := m.WriteSnapshot(&plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteSnapshot(&compressed, WithCompression(Gzip)); err != nil {
+		t.Fatal(err)
+	}
+	if compressed.Len() >= plain.Len()/2 {
+		t.Errorf("expected compression to shrink the snapshot, got %d of %d bytes", compressed.Len(), plain.Len())
+	}
+	key := make([]byte, 16)
+	if err := m.WriteSnapshot(&sealed, WithCompression(Gzip), WithEncryptionKey(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	// compression is detected without being asked for
+	restored := New[string, Plant]()
+	if err := restored.ReadSnapshot(&compressed); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != m.Len() {
+		t.Errorf("expected %d entries, got %d", m.Len(), restored.Len())
+	}
+	restored.Clear()
+	if err := restored.ReadSnapshot(&sealed, WithEncryptionKey(key)); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != m.Len() {
+		t.Errorf("expected %d entries, got %d", m.Len(), restored.Len())
+	}
+}
+
+func TestMergeFromSnapshot(t *testing.T) {
+	soon, later := time.Now().Add(time.Minute), time.Now().Add(time.Hour)
+	snapshotted := New[string, Plant]()
+	snapshotted.Set("fern", Plant{"old fern"}, later)
+	snapshotted.Set("moss", Plant{"old moss"}, soon)
+	snapshotted.Set("ivy", Plant{"ivy"}, soon)
+	var buf bytes.Buffer
+	if err := snapshotted.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		policy     ConflictPolicy
+		fern, moss string
+	}{
+		{PreferFresher, "old fern", "new moss"},
+		{PreferExisting, "new fern", "new moss"},
+		{PreferSnapshot, "old fern", "old moss"},
+	} {
+		m := New[string, Plant]()
+		m.Set("fern", Plant{"new fern"}, soon)
+		m.Set("moss", Plant{"new moss"}, later)
+		if err := m.MergeFromSnapshot(bytes.NewReader(buf.Bytes()), test.policy); err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := m.Get("fern"); v.Name != test.fern {
+			t.Errorf("policy %d: expected %q, got %q", test.policy, test.fern, v.Name)
+		}
+		if v, _ := m.Get("moss"); v.Name != test.moss {
+			t.Errorf("policy %d: expected %q, got %q", test.policy, test.moss, v.Name)
+		}
+		if !m.Has("ivy") {
+			t.Errorf("policy %d: expected keys missing from the map to be merged", test.policy)
+		}
+	}
+}
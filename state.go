@@ -0,0 +1,45 @@
+package expiringmap
+
+type State int
+
+const (
+	StateAbsent State = iota
+	StateLive
+	// StateStale is a key that was held but had expired.
+	StateStale
+)
+
+func (s State) String() string {
+	switch s {
+	case StateAbsent:
+		return "absent"
+	case StateLive:
+		return "live"
+	case StateStale:
+		return "stale"
+	default:
+		return "unknown"
+	}
+}
+
+// GetState is Get telling an expired key from one that was never set, the
+// expired value is returned with StateStale and removed. Keys whose expiry
+// has already been noticed, by another read or a scan, are absent.
+func (m *ExpiringMap[K, V]) GetState(key K) (V, State) {
+	s := m.lockShard(key)
+	defer s.unlock()
+	item, ok := s.items[key]
+	if !ok {
+		m.miss(s)
+		return *new(V), StateAbsent
+	}
+	now := m.now()
+	if item.expired(now) {
+		m.expire(s, key, item)
+		m.miss(s)
+		return item.val, StateStale
+	}
+	m.accessed(s, key, now)
+	m.hit(s)
+	return m.read(key, item), StateLive
+}
@@ -0,0 +1,25 @@
+package expiringmap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetState(t *testing.T) {
+	m := New[string, Animal]()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(-time.Second))
+
+	if v, state := m.GetState("elephant"); state != StateLive || v.name != "elephant" {
+		t.Errorf("expected a live elephant, got %v %v", v, state)
+	}
+	if v, state := m.GetState("monkey"); state != StateStale || v.name != "monkey" {
+		t.Errorf("expected a stale monkey, got %v %v", v, state)
+	}
+	if _, state := m.GetState("monkey"); state != StateAbsent {
+		t.Errorf("expected the stale monkey to be removed, got %v", state)
+	}
+	if _, state := m.GetState("tiger"); state != StateAbsent || state.String() != "absent" {
+		t.Errorf("expected an absent tiger, got %v", state)
+	}
+}
@@ -0,0 +1,187 @@
+package expiringmap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats are counters accumulated since the map was created. Removals are
+// broken down by reason.
+type Stats struct {
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+	Sets     uint64 `json:"sets"`
+	Expired  uint64 `json:"expired"`
+	Evicted  uint64 `json:"evicted"`
+	Deleted  uint64 `json:"deleted"`
+	Replaced uint64 `json:"replaced"`
+	Cleared  uint64 `json:"cleared"`
+	// Rejected counts new keys refused by a full map under EvictNone.
+	Rejected uint64 `json:"rejected"`
+	// DroppedAccesses counts reads the eviction policy never saw because the
+	// access buffer was full.
+	DroppedAccesses uint64 `json:"dropped_accesses"`
+	// DroppedMutations counts mutations asynchronous subscribers missed
+	// because their buffer was full.
+	DroppedMutations uint64 `json:"dropped_mutations"`
+
+	// LockContended counts the times a shard's lock was found taken and
+	// LockWait the total time spent waiting for them. LockHeld estimates the
+	// time the locks were held, which includes callbacks and subscribers run
+	// under them, see WithLockSampling. Loads counts loader calls and
+	// LoadTime the time spent in them, outside any lock.
+	LockContended uint64        `json:"lock_contended"`
+	LockWait      time.Duration `json:"lock_wait"`
+	LockHeld      time.Duration `json:"lock_held"`
+	Loads         uint64        `json:"loads"`
+	LoadTime      time.Duration `json:"load_time"`
+}
+
+// WithLockSampling times how long one in every rate acquisitions of a shard's
+// lock hold it, so Stats can estimate the time spent under shard locks. By
+// default only the time spent waiting for a taken lock is measured.
+func WithLockSampling[K comparable, V any](rate int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.lockSampling = rate
+	}
+}
+
+type stats struct {
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+	sets     atomic.Uint64
+	expired  atomic.Uint64
+	evicted  atomic.Uint64
+	deleted  atomic.Uint64
+	replaced atomic.Uint64
+	cleared  atomic.Uint64
+	rejected atomic.Uint64
+
+	droppedAccesses  atomic.Uint64
+	droppedMutations atomic.Uint64
+
+	// lock counters of shards retired by a resize
+	contended atomic.Uint64
+	lockWait  atomic.Int64
+	lockHeld  atomic.Int64
+
+	loads    atomic.Uint64
+	loadTime atomic.Int64
+}
+
+func (s *stats) loaded(start time.Time) {
+	s.loads.Add(1)
+	s.loadTime.Add(int64(time.Since(start)))
+}
+
+func (s *stats) removed(reason RemovalReason) {
+	switch reason {
+	case RemovalExpired:
+		s.expired.Add(1)
+	case RemovalEvicted:
+		s.evicted.Add(1)
+	case RemovalDeleted:
+		s.deleted.Add(1)
+	case RemovalReplaced:
+		s.replaced.Add(1)
+	case RemovalCleared:
+		s.cleared.Add(1)
+	}
+}
+
+func (m *ExpiringMap[K, V]) hit(s *shard[K, V]) {
+	m.stats.hits.Add(1)
+	s.hits.Add(1)
+}
+
+func (m *ExpiringMap[K, V]) miss(s *shard[K, V]) {
+	m.stats.misses.Add(1)
+	s.misses.Add(1)
+}
+
+// retire keeps the lock counters of shards a resize replaced in the totals.
+func (m *ExpiringMap[K, V]) retire(shards []*shard[K, V]) {
+	for _, s := range shards {
+		contended, wait, held := s.lockStats()
+		m.stats.contended.Add(contended)
+		m.stats.lockWait.Add(int64(wait))
+		m.stats.lockHeld.Add(int64(held))
+	}
+}
+
+func (m *ExpiringMap[K, V]) Stats() Stats {
+	s := m.stats
+	contended, wait, held := s.contended.Load(), time.Duration(s.lockWait.Load()), time.Duration(s.lockHeld.Load())
+	for _, shard := range m.roots() {
+		c, w, h := shard.lockStats()
+		contended, wait, held = contended+c, wait+w, held+h
+	}
+	return Stats{
+		Hits:     s.hits.Load(),
+		Misses:   s.misses.Load(),
+		Sets:     s.sets.Load(),
+		Expired:  s.expired.Load(),
+		Evicted:  s.evicted.Load(),
+		Deleted:  s.deleted.Load(),
+		Replaced: s.replaced.Load(),
+		Cleared:  s.cleared.Load(),
+		Rejected: s.rejected.Load(),
+
+		DroppedAccesses:  s.droppedAccesses.Load(),
+		DroppedMutations: s.droppedMutations.Load(),
+
+		LockContended: contended,
+		LockWait:      wait,
+		LockHeld:      held,
+		Loads:         s.loads.Load(),
+		LoadTime:      time.Duration(s.loadTime.Load()),
+	}
+}
+
+// Removals returns the total number of values that left the map.
+func (s Stats) Removals() uint64 {
+	return s.Expired + s.Evicted + s.Deleted + s.Replaced + s.Cleared
+}
+
+// HitRatio returns the fraction of reads that found a value.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ShardStats describes one shard, so that a hot shard stands out.
+type ShardStats struct {
+	// Entries counts the items the shard holds, including expired ones not
+	// yet removed.
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	// Contended counts the times the shard's lock was found taken, and
+	// LockWait the total time spent waiting for it. LockHeld estimates the
+	// time it was held, see WithLockSampling.
+	Contended uint64        `json:"contended"`
+	LockWait  time.Duration `json:"lock_wait"`
+	LockHeld  time.Duration `json:"lock_held"`
+}
+
+// HitRatio returns the fraction of the shard's reads that found a value.
+func (s ShardStats) HitRatio() float64 {
+	return Stats{Hits: s.Hits, Misses: s.Misses}.HitRatio()
+}
+
+// ShardStats returns the stats of each shard since the map was created.
+func (m *ExpiringMap[K, V]) ShardStats() []ShardStats {
+	shards := m.roots()
+	stats := make([]ShardStats, len(shards))
+	for i, s := range shards {
+		s.lock()
+		stats[i].Entries = len(s.items)
+		s.unlock()
+		stats[i].Hits = s.hits.Load()
+		stats[i].Misses = s.misses.Load()
+		stats[i].Contended, stats[i].LockWait, stats[i].LockHeld = s.lockStats()
+	}
+	return stats
+}
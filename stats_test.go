@@ -0,0 +1,132 @@
+package expiringmap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	m := New[string, Animal]()
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(-time.Minute))
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+	m.Set("zebra", Animal{"zebra"}, time.Now().Add(time.Minute))
+
+	m.Get("elephant")
+	m.Get("monkey")
+	m.Get("giraffe")
+	m.Delete("tiger")
+	m.Clear()
+
+	expected := Stats{Hits: 1, Misses: 2, Sets: 5, Expired: 1, Deleted: 1, Replaced: 1, Cleared: 2}
+	if stats := m.Stats(); stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+	if m.Stats().Removals() != 5 {
+		t.Errorf("expected 5 removals, got %d", m.Stats().Removals())
+	}
+	if ratio := m.Stats().HitRatio(); ratio < 0.33 || ratio > 0.34 {
+		t.Errorf("unexpected hit ratio %v", ratio)
+	}
+}
+
+func TestMutationReason(t *testing.T) {
+	m := New[string, Animal]()
+	var reasons []RemovalReason
+	m.Subscribe(func(mutation Mutation[string, Animal]) {
+		reasons = append(reasons, mutation.Reason)
+	})
+	m.Set("elephant", Animal{"elephant"}, time.Now().Add(time.Minute))
+	m.Set("monkey", Animal{"monkey"}, time.Now().Add(-time.Minute))
+	m.Get("monkey")
+	m.Delete("elephant")
+	m.Clear()
+
+	expected := []RemovalReason{RemovalNone, RemovalNone, RemovalExpired, RemovalDeleted, RemovalCleared}
+	if len(reasons) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, reasons)
+	}
+	for i := range expected {
+		if reasons[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], reasons[i])
+		}
+	}
+}
+
+func TestShardStats(t *testing.T) {
+	m := New[string, int]()
+	m.Set("hot", 1, time.Now().Add(time.Minute))
+	for i := 0; i < 10; i++ {
+		m.Get("hot")
+	}
+	// the seed is random, so find a cold key on another shard
+	cold := "cold"
+	for i := 0; m.shard(cold) == m.shard("hot"); i++ {
+		cold = fmt.Sprint("cold", i)
+	}
+	m.Get(cold)
+
+	stats := m.ShardStats()
+	if len(stats) != shardCount {
+		t.Fatalf("expected %d shards, got %d", shardCount, len(stats))
+	}
+	hot := stats[m.hash("hot")%uint64(shardCount)]
+	if hot.Entries != 1 || hot.Hits != 10 || hot.HitRatio() != 1 {
+		t.Errorf("unexpected stats for the hot shard %+v", hot)
+	}
+	if cold := stats[m.hash(cold)%uint64(shardCount)]; cold.Misses != 1 {
+		t.Errorf("expected the miss on the cold shard, got %+v", cold)
+	}
+
+	// a held lock is waited for and counted
+	s := m.shard("hot")
+	s.mutex.Lock()
+	done := make(chan struct{})
+	go func() {
+		m.Get("hot")
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.mutex.Unlock()
+	<-done
+	if hot := m.ShardStats()[m.hash("hot")%uint64(shardCount)]; hot.Contended != 1 || hot.LockWait < 5*time.Millisecond {
+		t.Errorf("expected the wait to be recorded, got %+v", hot)
+	}
+}
+
+func TestLockStats(t *testing.T) {
+	m := New(WithLockSampling[string, int](1))
+	m.Subscribe(func(mutation Mutation[string, int]) {
+		time.Sleep(5 * time.Millisecond)
+	})
+	m.Set("slow", 1, time.Now().Add(time.Minute))
+	if held := m.Stats().LockHeld; held < 5*time.Millisecond {
+		t.Errorf("expected the subscriber to hold the lock, got %v", held)
+	}
+
+	s := m.shard("slow")
+	s.lock()
+	done := make(chan struct{})
+	go func() {
+		m.Get("slow")
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.unlock()
+	<-done
+	m.Resize(64)
+	if stats := m.Stats(); stats.LockContended != 1 || stats.LockWait < 5*time.Millisecond {
+		t.Errorf("expected the wait to outlive the resize, got %+v", stats)
+	}
+
+	m.GetOrLoad(context.Background(), "loaded", func(ctx context.Context, key string) (int, time.Time, error) {
+		time.Sleep(5 * time.Millisecond)
+		return 1, time.Now().Add(time.Minute), nil
+	})
+	if stats := m.Stats(); stats.Loads != 1 || stats.LoadTime < 5*time.Millisecond {
+		t.Errorf("expected the load to be timed, got %+v", stats)
+	}
+}
@@ -0,0 +1,133 @@
+// Package statshttp serves the Stats of maps over HTTP, as JSON or in the
+// Prometheus text format, for services without a metrics stack.
+package statshttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+// Source is satisfied by *expiringmap.ExpiringMap and the types wrapping it.
+type Source interface {
+	Stats() expiringmap.Stats
+	Len() int
+}
+
+type config struct {
+	prometheus bool
+}
+
+type Option func(*config)
+
+// WithPrometheus also serves the Prometheus text format, to requests asking
+// for ?format=prometheus or accepting text/plain as scrapers do.
+func WithPrometheus() Option {
+	return func(c *config) {
+		c.prometheus = true
+	}
+}
+
+type handler struct {
+	config
+	names   []string
+	sources map[string]Source
+}
+
+// Handler serves the stats of each source under its name.
+func Handler(sources map[string]Source, options ...Option) http.Handler {
+	h := &handler{sources: sources}
+	for _, option := range options {
+		option(&h.config)
+	}
+	for name := range sources {
+		h.names = append(h.names, name)
+	}
+	sort.Strings(h.names)
+	return h
+}
+
+type report struct {
+	Len int `json:"len"`
+	expiringmap.Stats
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if h.prometheus && (r.URL.Query().Get("format") == "prometheus" || strings.Contains(r.Header.Get("Accept"), "text/plain")) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		h.writePrometheus(w)
+		return
+	}
+	reports := make(map[string]report, len(h.sources))
+	for name, source := range h.sources {
+		stats := source.Stats()
+		reports[name] = report{Len: source.Len(), Stats: stats, HitRatio: stats.HitRatio()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
+
+type metric struct {
+	name, kind, help string
+	value            func(stats expiringmap.Stats) float64
+}
+
+var metrics = []metric{
+	{"expiringmap_hits_total", "counter", "Reads that found a value.", func(s expiringmap.Stats) float64 { return float64(s.Hits) }},
+	{"expiringmap_misses_total", "counter", "Reads that found no value.", func(s expiringmap.Stats) float64 { return float64(s.Misses) }},
+	{"expiringmap_sets_total", "counter", "Values stored.", func(s expiringmap.Stats) float64 { return float64(s.Sets) }},
+	{"expiringmap_rejected_total", "counter", "New keys refused by a full map.", func(s expiringmap.Stats) float64 { return float64(s.Rejected) }},
+	{"expiringmap_dropped_accesses_total", "counter", "Reads the eviction policy never saw.", func(s expiringmap.Stats) float64 { return float64(s.DroppedAccesses) }},
+	{"expiringmap_dropped_mutations_total", "counter", "Mutations asynchronous subscribers missed.", func(s expiringmap.Stats) float64 { return float64(s.DroppedMutations) }},
+	{"expiringmap_lock_contended_total", "counter", "Shard locks found taken.", func(s expiringmap.Stats) float64 { return float64(s.LockContended) }},
+	{"expiringmap_lock_wait_seconds_total", "counter", "Time spent waiting for shard locks.", func(s expiringmap.Stats) float64 { return s.LockWait.Seconds() }},
+	{"expiringmap_lock_held_seconds_total", "counter", "Estimated time shard locks were held.", func(s expiringmap.Stats) float64 { return s.LockHeld.Seconds() }},
+	{"expiringmap_loads_total", "counter", "Loader calls.", func(s expiringmap.Stats) float64 { return float64(s.Loads) }},
+	{"expiringmap_load_seconds_total", "counter", "Time spent in loaders.", func(s expiringmap.Stats) float64 { return s.LoadTime.Seconds() }},
+}
+
+var removals = []struct {
+	reason expiringmap.RemovalReason
+	value  func(stats expiringmap.Stats) uint64
+}{
+	{expiringmap.RemovalExpired, func(s expiringmap.Stats) uint64 { return s.Expired }},
+	{expiringmap.RemovalEvicted, func(s expiringmap.Stats) uint64 { return s.Evicted }},
+	{expiringmap.RemovalDeleted, func(s expiringmap.Stats) uint64 { return s.Deleted }},
+	{expiringmap.RemovalReplaced, func(s expiringmap.Stats) uint64 { return s.Replaced }},
+	{expiringmap.RemovalCleared, func(s expiringmap.Stats) uint64 { return s.Cleared }},
+}
+
+func (h *handler) writePrometheus(w http.ResponseWriter) {
+	stats := make([]expiringmap.Stats, len(h.names))
+	for i, name := range h.names {
+		stats[i] = h.sources[name].Stats()
+	}
+	fmt.Fprintln(w, "# HELP expiringmap_entries Live entries.")
+	fmt.Fprintln(w, "# TYPE expiringmap_entries gauge")
+	for _, name := range h.names {
+		fmt.Fprintf(w, "expiringmap_entries{cache=%q} %d\n", name, h.sources[name].Len())
+	}
+	for _, metric := range metrics {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for i, name := range h.names {
+			fmt.Fprintf(w, "%s{cache=%q} %g\n", metric.name, name, metric.value(stats[i]))
+		}
+	}
+	fmt.Fprintln(w, "# HELP expiringmap_removals_total Values that left the map by reason.")
+	fmt.Fprintln(w, "# TYPE expiringmap_removals_total counter")
+	for i, name := range h.names {
+		for _, removal := range removals {
+			fmt.Fprintf(w, "expiringmap_removals_total{cache=%q,reason=%q} %d\n", name, removal.reason, removal.value(stats[i]))
+		}
+	}
+}
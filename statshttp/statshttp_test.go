@@ -0,0 +1,69 @@
+package statshttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func newSources() map[string]Source {
+	m := expiringmap.New[string, int]()
+	m.Set("a", 1, time.Now().Add(time.Minute))
+	m.Get("a")
+	m.Get("b")
+	m.Delete("a")
+	m.Set("c", 1, time.Now().Add(time.Minute))
+	return map[string]Source{"users": &m}
+}
+
+func TestHandlerJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler(newSources()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?format=prometheus", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected json without WithPrometheus, got %s", ct)
+	}
+	var reports map[string]struct {
+		Len      int     `json:"len"`
+		Hits     uint64  `json:"hits"`
+		Deleted  uint64  `json:"deleted"`
+		HitRatio float64 `json:"hit_ratio"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&reports); err != nil {
+		t.Fatal(err)
+	}
+	if r := reports["users"]; r.Len != 1 || r.Hits != 1 || r.Deleted != 1 || r.HitRatio != 0.5 {
+		t.Errorf("unexpected report %+v", r)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(newSources()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected writes to be refused, got %d", rec.Code)
+	}
+}
+
+func TestHandlerPrometheus(t *testing.T) {
+	h := Handler(newSources(), WithPrometheus())
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE expiringmap_hits_total counter",
+		`expiringmap_entries{cache="users"} 1`,
+		`expiringmap_hits_total{cache="users"} 1`,
+		`expiringmap_misses_total{cache="users"} 1`,
+		`expiringmap_removals_total{cache="users",reason="deleted"} 1`,
+		`expiringmap_lock_wait_seconds_total{cache="users"} 0`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected %q in\n%s", line, body)
+		}
+	}
+}
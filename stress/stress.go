@@ -0,0 +1,140 @@
+package stress
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+type Distribution int
+
+const (
+	Uniform Distribution = iota
+	Zipfian
+)
+
+// Config describes the load shape. Keys are ints drawn from [0, Keys) and
+// each write picks a ttl uniformly between MinTTL and MaxTTL.
+type Config struct {
+	Goroutines   int
+	Duration     time.Duration
+	Keys         int
+	Distribution Distribution
+	// ZipfS is the skew of the Zipfian distribution, greater than 1.
+	ZipfS float64
+	// ReadRatio is the fraction of operations that are reads.
+	ReadRatio float64
+	MinTTL    time.Duration
+	MaxTTL    time.Duration
+	Seed      int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Goroutines:   runtime.GOMAXPROCS(0) * 4,
+		Duration:     time.Second,
+		Keys:         1 << 16,
+		Distribution: Zipfian,
+		ZipfS:        1.1,
+		ReadRatio:    0.9,
+		MinTTL:       time.Second,
+		MaxTTL:       time.Minute,
+		Seed:         1,
+	}
+}
+
+type Result struct {
+	Reads      uint64
+	Writes     uint64
+	Hits       uint64
+	Elapsed    time.Duration
+	Throughput float64
+	// LeakedGoroutines is how many more goroutines were running once the
+	// map was closed than before it was stressed.
+	LeakedGoroutines int
+}
+
+// Run stresses m until cfg.Duration passes or ctx is done, then closes m and
+// checks for goroutines left behind.
+func Run(ctx context.Context, m *expiringmap.ExpiringMap[int, int], cfg Config) Result {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var reads, writes, hits atomic.Uint64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for g := 0; g < cfg.Goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(cfg.Seed + int64(g)))
+			next := cfg.keys(r)
+			for i := 0; ; i++ {
+				if i%64 == 0 && ctx.Err() != nil {
+					return
+				}
+				key := next()
+				if r.Float64() < cfg.ReadRatio {
+					reads.Add(1)
+					if _, ok := m.Get(key); ok {
+						hits.Add(1)
+					}
+				} else {
+					writes.Add(1)
+					m.Set(key, i, time.Now().Add(cfg.ttl(r)))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	m.Close()
+
+	result := Result{
+		Reads:   reads.Load(),
+		Writes:  writes.Load(),
+		Hits:    hits.Load(),
+		Elapsed: elapsed,
+	}
+	result.Throughput = float64(result.Reads+result.Writes) / elapsed.Seconds()
+	result.LeakedGoroutines = leaked(before)
+	return result
+}
+
+func (cfg Config) keys(r *rand.Rand) func() int {
+	if cfg.Distribution == Zipfian && cfg.Keys > 1 {
+		s := cfg.ZipfS
+		if s <= 1 {
+			s = 1.1
+		}
+		zipf := rand.NewZipf(r, s, 1, uint64(cfg.Keys-1))
+		return func() int { return int(zipf.Uint64()) }
+	}
+	return func() int { return r.Intn(cfg.Keys) }
+}
+
+func (cfg Config) ttl(r *rand.Rand) time.Duration {
+	if cfg.MaxTTL <= cfg.MinTTL {
+		return cfg.MinTTL
+	}
+	return cfg.MinTTL + time.Duration(r.Int63n(int64(cfg.MaxTTL-cfg.MinTTL)))
+}
+
+// leaked gives exiting goroutines a moment before counting them.
+func leaked(before int) int {
+	var after int
+	for i := 0; i < 50; i++ {
+		after = runtime.NumGoroutine()
+		if after <= before {
+			return 0
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return after - before
+}
@@ -0,0 +1,50 @@
+package stress
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func TestRun(t *testing.T) {
+	for _, distribution := range []Distribution{Uniform, Zipfian} {
+		cfg := DefaultConfig()
+		cfg.Goroutines = 4
+		cfg.Duration = 50 * time.Millisecond
+		cfg.Keys = 1000
+		cfg.Distribution = distribution
+		cfg.MinTTL = time.Millisecond
+		cfg.MaxTTL = 10 * time.Millisecond
+
+		m := expiringmap.New(expiringmap.WithMaxEntries[int, int](100), expiringmap.WithBufferedAccess[int, int]())
+		result := Run(context.Background(), &m, cfg)
+		if result.Reads == 0 || result.Writes == 0 || result.Throughput == 0 {
+			t.Errorf("expected operations to run, got %+v", result)
+		}
+		if result.Hits == 0 {
+			t.Error("expected some reads to hit.")
+		}
+		if result.LeakedGoroutines != 0 {
+			t.Errorf("expected no leaked goroutines, got %d", result.LeakedGoroutines)
+		}
+		if m.Len() > 100 {
+			t.Errorf("expected the capacity to hold under stress, got %d", m.Len())
+		}
+	}
+}
+
+func TestLeaked(t *testing.T) {
+	before := runtime.NumGoroutine()
+	stop := make(chan struct{})
+	go func() { <-stop }()
+	if n := leaked(before); n != 1 {
+		t.Errorf("expected 1 leaked goroutine, got %d", n)
+	}
+	close(stop)
+	if n := leaked(before); n != 0 {
+		t.Errorf("expected no leaked goroutines, got %d", n)
+	}
+}
@@ -0,0 +1,86 @@
+package expiringmap
+
+import "time"
+
+// The following mirror sync.Map. Variants without a ttl take it from the value
+// or the TTL provider like Put does.
+
+func (m *ExpiringMap[K, V]) Load(key K) (V, bool) {
+	return m.Get(key)
+}
+
+func (m *ExpiringMap[K, V]) Store(key K, value V) {
+	m.Put(key, value)
+}
+
+func (m *ExpiringMap[K, V]) StoreWithTTL(key K, value V, ttl time.Time) {
+	m.Set(key, value, ttl)
+}
+
+func (m *ExpiringMap[K, V]) LoadOrStore(key K, value V) (V, bool) {
+	return m.LoadOrStoreWithTTL(key, value, m.ttlFor(key, value))
+}
+
+func (m *ExpiringMap[K, V]) LoadOrStoreWithTTL(key K, value V, ttl time.Time) (actual V, loaded bool) {
+	return m.GetOrSet(key, value, ttl)
+}
+
+// LoadAndDelete deletes key and returns its live value, so callers can
+// release what they removed without a racy Get first.
+func (m *ExpiringMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	s := m.lockShard(key)
+	defer s.unlock()
+	if item, ok := m.liveItem(s, key); ok {
+		m.remove(s, key, item, RemovalDeleted, false)
+		return item.val, true
+	}
+	return *new(V), false
+}
+
+func (m *ExpiringMap[K, V]) Swap(key K, value V) (V, bool) {
+	return m.SwapWithTTL(key, value, m.ttlFor(key, value))
+}
+
+// SwapWithTTL stores value and returns the previous live value if any.
+func (m *ExpiringMap[K, V]) SwapWithTTL(key K, value V, ttl time.Time) (previous V, loaded bool) {
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	item, loaded := m.liveItem(s, key)
+	m.set(s, key, value, ttl)
+	return item.val, loaded
+}
+
+func (m *ExpiringMap[K, V]) CompareAndSwap(key K, old, new V) bool {
+	return m.CompareAndSwapWithTTL(key, old, new, m.ttlFor(key, new))
+}
+
+// CompareAndSwapWithTTL stores new if the live value for key equals old. Like
+// sync.Map it panics if V is not comparable.
+func (m *ExpiringMap[K, V]) CompareAndSwapWithTTL(key K, old, new V, ttl time.Time) bool {
+	s := m.lockShard(key)
+	defer m.unlock(s)
+	if item, ok := m.liveItem(s, key); ok && any(item.val) == any(old) {
+		m.set(s, key, new, ttl)
+		return true
+	}
+	return false
+}
+
+// CompareAndDelete deletes key if its live value equals old.
+func (m *ExpiringMap[K, V]) CompareAndDelete(key K, old V) bool {
+	return m.RemoveIf(key, func(value V) bool { return any(value) == any(old) })
+}
+
+// liveItem returns the live item for key, expiring it if it is stale. The shard
+// must be locked.
+func (m *ExpiringMap[K, V]) liveItem(s *shard[K, V], key K) (expiringMapVal[V], bool) {
+	item, ok := s.items[key]
+	if !ok {
+		return item, false
+	}
+	if item.expired(m.now()) {
+		m.expire(s, key, item)
+		return expiringMapVal[V]{}, false
+	}
+	return item, true
+}
@@ -0,0 +1,130 @@
+package expiringmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncMap is the subset of sync.Map the map can stand in for.
+type syncMap[K comparable, V any] interface {
+	Load(key K) (V, bool)
+	Store(key K, value V)
+	LoadOrStore(key K, value V) (V, bool)
+	LoadAndDelete(key K) (V, bool)
+	Swap(key K, value V) (V, bool)
+	CompareAndSwap(key K, old, new V) bool
+	CompareAndDelete(key K, old V) bool
+	Range(f func(key K, value V) bool)
+}
+
+var _ syncMap[any, any] = &sync.Map{}
+
+func TestSyncMapMethods(t *testing.T) {
+	m := New[string, int]()
+	var sm syncMap[string, int] = &m
+
+	sm.Store("elephant", 1)
+	if v, ok := sm.Load("elephant"); !ok || v != 1 {
+		t.Errorf("expected 1, got %d %v", v, ok)
+	}
+	if v, loaded := sm.LoadOrStore("elephant", 2); !loaded || v != 1 {
+		t.Errorf("expected the existing value, got %d %v", v, loaded)
+	}
+	if v, loaded := sm.LoadOrStore("monkey", 2); loaded || v != 2 {
+		t.Errorf("expected the stored value, got %d %v", v, loaded)
+	}
+	if v, loaded := sm.Swap("elephant", 3); !loaded || v != 1 {
+		t.Errorf("expected the previous value, got %d %v", v, loaded)
+	}
+	if _, loaded := sm.Swap("tiger", 4); loaded {
+		t.Error("tiger wasn't present.")
+	}
+	if sm.CompareAndSwap("elephant", 1, 5) {
+		t.Error("expected a mismatch to fail.")
+	}
+	if !sm.CompareAndSwap("elephant", 3, 5) {
+		t.Error("expected a match to swap.")
+	}
+	if sm.CompareAndDelete("elephant", 3) || !sm.CompareAndDelete("elephant", 5) {
+		t.Error("expected only a matching delete to succeed.")
+	}
+	if v, ok := sm.LoadAndDelete("monkey"); !ok || v != 2 {
+		t.Errorf("expected the removed value, got %d %v", v, ok)
+	}
+	if _, ok := sm.LoadAndDelete("monkey"); ok {
+		t.Error("monkey should be gone.")
+	}
+	if m.Len() != 1 {
+		t.Errorf("expected only tiger, got %d", m.Len())
+	}
+}
+
+func TestSyncMapWithTTL(t *testing.T) {
+	m := New[string, int]()
+	m.StoreWithTTL("elephant", 1, time.Now().Add(10*time.Millisecond))
+	if _, loaded := m.LoadOrStoreWithTTL("elephant", 2, time.Now().Add(time.Minute)); !loaded {
+		t.Error("expected elephant to be loaded.")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if _, loaded := m.SwapWithTTL("elephant", 2, time.Now().Add(time.Minute)); loaded {
+		t.Error("expired values shouldn't be returned.")
+	}
+	m.StoreWithTTL("monkey", 1, time.Now().Add(10*time.Millisecond))
+	time.Sleep(20 * time.Millisecond)
+	if m.CompareAndSwapWithTTL("monkey", 1, 2, time.Now().Add(time.Minute)) {
+		t.Error("expired values shouldn't compare equal.")
+	}
+	if m.CompareAndDelete("monkey", 1) {
+		t.Error("expired values shouldn't be deleted.")
+	}
+}
+
+func TestCompareAndSwapPanicsOnUncomparable(t *testing.T) {
+	m := New[string, []int]()
+	m.Store("elephant", []int{1})
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic like sync.Map.")
+		}
+	}()
+	m.CompareAndSwap("elephant", []int{1}, []int{2})
+}
+
+func TestLoadAndDeleteConcurrent(t *testing.T) {
+	m := New[string, int]()
+	const n = 1000
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	released := make(map[int]int)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				if v, ok := m.LoadAndDelete("resource"); ok {
+					mutex.Lock()
+					released[v]++
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if v, loaded := m.SwapWithTTL("resource", i, time.Now().Add(time.Minute)); loaded {
+			mutex.Lock()
+			released[v]++
+			mutex.Unlock()
+		}
+	}
+	wg.Wait()
+	if v, ok := m.LoadAndDelete("resource"); ok {
+		released[v]++
+	}
+	for i := 0; i < n; i++ {
+		if released[i] != 1 {
+			t.Fatalf("expected value %d to be released once, got %d", i, released[i])
+		}
+	}
+}
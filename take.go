@@ -0,0 +1,172 @@
+package expiringmap
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TakeExpired blocks until an entry expires and removes and returns it,
+// earliest expiry first, so the map can be used as a delay queue. Entries
+// dropped by a read after expiring are not handed out. It sleeps until the
+// soonest expiry, woken early only by a Set expiring sooner.
+func (m *ExpiringMap[K, V]) TakeExpired(ctx context.Context) (K, V, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		// joining before looking means a Set while we look wakes us
+		t, wake := m.takers.join()
+		key, value, next, ok := m.takeExpired()
+		if ok {
+			m.takers.leave(t)
+			return key, value, nil
+		}
+		m.takers.sleep(t, next)
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			if timer == nil {
+				timer = time.NewTimer(next.Sub(m.now()))
+			} else {
+				timer.Reset(next.Sub(m.now()))
+			}
+			fire = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			m.takers.leave(t)
+			return *new(K), *new(V), ctx.Err()
+		case <-wake:
+			if timer != nil && !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		case <-fire:
+			m.takers.leave(t)
+		}
+	}
+}
+
+// takers wakes the TakeExpired callers sleeping past the expiry of an entry
+// stored meanwhile. Stores only load soonest unless they have to wake one, so
+// a map without takers pays an atomic load per store.
+type takers struct {
+	// soonest is the earliest a taker sleeps until in unix nanoseconds,
+	// math.MinInt64 when none is waiting
+	soonest atomic.Int64
+	mutex   sync.Mutex
+	waiting map[*taker]struct{}
+	wake    chan struct{}
+}
+
+type taker struct {
+	until int64
+}
+
+func newTakers() *takers {
+	t := &takers{waiting: make(map[*taker]struct{}), wake: make(chan struct{})}
+	t.soonest.Store(math.MinInt64)
+	return t
+}
+
+// join registers a taker woken by any store until it sleeps.
+func (t *takers) join() (*taker, <-chan struct{}) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	w := &taker{until: math.MaxInt64}
+	t.waiting[w] = struct{}{}
+	t.update()
+	return w, t.wake
+}
+
+// sleep makes w only woken by an entry expiring before next, any entry when
+// next is zero.
+func (t *takers) sleep(w *taker, next time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.waiting[w]; ok && !next.IsZero() {
+		w.until = unixNanos(next)
+		t.update()
+	}
+}
+
+func (t *takers) leave(w *taker) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.waiting, w)
+	t.update()
+}
+
+// stored wakes every taker when ttl is sooner than one of them sleeps until,
+// they join again to look for it.
+func (t *takers) stored(ttl time.Time) {
+	if !ttl.Before(time.Unix(0, t.soonest.Load())) {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.waiting) == 0 {
+		return
+	}
+	close(t.wake)
+	t.wake = make(chan struct{})
+	t.waiting = make(map[*taker]struct{})
+	t.soonest.Store(math.MinInt64)
+}
+
+func (t *takers) update() {
+	soonest := int64(math.MinInt64)
+	for w := range t.waiting {
+		if soonest == math.MinInt64 || w.until < soonest {
+			soonest = w.until
+		}
+	}
+	t.soonest.Store(soonest)
+}
+
+// unixNanos is ttl in unix nanoseconds, those past 2262 saturating.
+func unixNanos(ttl time.Time) int64 {
+	if ttl.After(time.Unix(0, math.MaxInt64)) {
+		return math.MaxInt64
+	}
+	return ttl.UnixNano()
+}
+
+// takeExpired removes the entry that expired first, or reports when the
+// next entry expires. Only the shard holding it is scanned.
+func (m *ExpiringMap[K, V]) takeExpired() (K, V, time.Time, bool) {
+	for {
+		var (
+			owner *shard[K, V]
+			next  time.Time
+		)
+		m.eachShard(func(s *shard[K, V], _ keyFilter[K]) {
+			if ttl, ok := s.soonestExpiry(); ok && (owner == nil || ttl.Before(next)) {
+				owner, next = s, ttl
+			}
+		}, nil)
+		if owner == nil || !next.Before(m.now()) {
+			return *new(K), *new(V), next, false
+		}
+
+		// a resize moving owner's keys sends us round again
+		owner.lock()
+		if ttl, ok := owner.soonestExpiry(); ok && ttl.Before(m.now()) {
+			for key, item := range owner.items {
+				if item.ttl.Equal(ttl) {
+					m.expire(owner, key, item)
+					owner.unlock()
+					return key, item.val, item.ttl, true
+				}
+			}
+		}
+		owner.unlock()
+	}
+}
@@ -0,0 +1,117 @@
+package expiringmap
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTakeExpired(t *testing.T) {
+	m := New[string, Animal]()
+	now := time.Now()
+	m.Set("monkey", Animal{"monkey"}, now.Add(30*time.Millisecond))
+	m.Set("elephant", Animal{"elephant"}, now.Add(10*time.Millisecond))
+	m.Set("tiger", Animal{"tiger"}, now.Add(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for _, name := range []string{"elephant", "monkey"} {
+		key, value, err := m.TakeExpired(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != name || value.name != name {
+			t.Errorf("expected %s to be taken, got %s", name, key)
+		}
+	}
+	if m.Len() != 1 {
+		t.Error("taken entries should be removed.")
+	}
+}
+
+func TestTakeExpiredWakesOnSet(t *testing.T) {
+	m := New[string, Animal]()
+	m.Set("tiger", Animal{"tiger"}, time.Now().Add(time.Minute))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("elephant", Animal{"elephant"}, time.Now().Add(10*time.Millisecond))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	key, _, err := m.TakeExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "elephant" {
+		t.Errorf("expected elephant, got %s", key)
+	}
+}
+
+func TestTakeExpiredContext(t *testing.T) {
+	m := New[string, Animal]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := m.TakeExpired(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestTakeExpiredOrder(t *testing.T) {
+	m := New[int, int]()
+	now := time.Now()
+	for i := 0; i < 200; i++ {
+		m.Set(i, i, now.Add(-time.Duration(200-i)*time.Millisecond))
+	}
+	m.Set(-1, -1, now.Add(time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 200; i++ {
+		key, _, err := m.TakeExpired(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != i {
+			t.Fatalf("expected %d to be taken, got %d", i, key)
+		}
+	}
+	if m.Len() != 1 {
+		t.Errorf("expected only the live entry to remain, got %d", m.Len())
+	}
+}
+
+func TestTakeExpiredWakesOnFirstSet(t *testing.T) {
+	m := New[string, Animal]()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		m.Set("elephant", Animal{"elephant"}, time.Now().Add(10*time.Millisecond))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if key, _, err := m.TakeExpired(ctx); err != nil || key != "elephant" {
+		t.Errorf("expected elephant, got %s %v", key, err)
+	}
+}
+
+func TestTakeExpiredNoSubscriber(t *testing.T) {
+	m := New[string, Animal]()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.TakeExpired(ctx)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if list := m.subscribers.list.Load(); list != nil && len(*list) != 0 {
+		t.Error("expected a waiting TakeExpired not to subscribe to mutations.")
+	}
+	<-done
+	if len(m.takers.waiting) != 0 {
+		t.Error("expected TakeExpired to leave once done.")
+	}
+}
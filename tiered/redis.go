@@ -0,0 +1,87 @@
+package tiered
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+// Redis is the subset of a Redis client used by the remote tier. ok is false
+// when the key does not exist and a ttl of zero means the key has no expiry.
+type Redis interface {
+	Get(ctx context.Context, key string) (value []byte, ttl time.Duration, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+type Codec[V any] interface {
+	Marshal(value V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[V]) Unmarshal(data []byte) (V, error) {
+	var value V
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+type RedisTier[V any] struct {
+	client Redis
+	codec  Codec[V]
+	prefix string
+}
+
+func NewRedisTier[V any](client Redis, codec Codec[V], prefix string) *RedisTier[V] {
+	if codec == nil {
+		codec = JSONCodec[V]{}
+	}
+	return &RedisTier[V]{
+		client: client,
+		codec:  codec,
+		prefix: prefix,
+	}
+}
+
+func (r *RedisTier[V]) Get(ctx context.Context, key string) (V, time.Time, bool, error) {
+	data, ttl, ok, err := r.client.Get(ctx, r.prefix+key)
+	if err != nil || !ok {
+		return *new(V), time.Time{}, false, err
+	}
+	value, err := r.codec.Unmarshal(data)
+	if err != nil {
+		return *new(V), time.Time{}, false, err
+	}
+	expiresAt := expiringmap.NoExpiry
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	return value, expiresAt, true, nil
+}
+
+func (r *RedisTier[V]) Set(ctx context.Context, key string, value V, ttl time.Time) error {
+	data, err := r.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	// a zero time or NoExpiry sets the key without an expiry
+	var d time.Duration
+	if !ttl.IsZero() && ttl.Before(expiringmap.NoExpiry) {
+		d = time.Until(ttl)
+		if d <= 0 {
+			return r.client.Del(ctx, r.prefix+key)
+		}
+	}
+	return r.client.Set(ctx, r.prefix+key, data, d)
+}
+
+func (r *RedisTier[V]) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key)
+}
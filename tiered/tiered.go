@@ -0,0 +1,73 @@
+package tiered
+
+import (
+	"context"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+type Remote[K comparable, V any] interface {
+	Get(ctx context.Context, key K) (V, time.Time, bool, error)
+	Set(ctx context.Context, key K, value V, ttl time.Time) error
+	Delete(ctx context.Context, key K) error
+}
+
+type Cache[K comparable, V any] struct {
+	local       *expiringmap.ExpiringMap[K, V]
+	remote      Remote[K, V]
+	maxLocalTTL time.Duration
+}
+
+// New returns an L1/L2 cache where misses in local fall through to remote.
+// Entries copied into local expire at the remote expiry or after maxLocalTTL,
+// whichever comes first.
+func New[K comparable, V any](local *expiringmap.ExpiringMap[K, V], remote Remote[K, V], maxLocalTTL time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		local:       local,
+		remote:      remote,
+		maxLocalTTL: maxLocalTTL,
+	}
+}
+
+func (c *Cache[K, V]) Local() *expiringmap.ExpiringMap[K, V] {
+	return c.local
+}
+
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if value, ok := c.local.Get(key); ok {
+		return value, true, nil
+	}
+	value, ttl, ok, err := c.remote.Get(ctx, key)
+	if err != nil || !ok {
+		return *new(V), false, err
+	}
+	c.local.Set(key, value, c.localTTL(ttl))
+	return value, true, nil
+}
+
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V, ttl time.Time) error {
+	if err := c.remote.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	c.local.Set(key, value, c.localTTL(ttl))
+	return nil
+}
+
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	c.local.Delete(key)
+	return c.remote.Delete(ctx, key)
+}
+
+// Invalidate drops key from the local tier only.
+func (c *Cache[K, V]) Invalidate(key K) bool {
+	return c.local.Delete(key)
+}
+
+func (c *Cache[K, V]) localTTL(ttl time.Time) time.Time {
+	capped := time.Now().Add(c.maxLocalTTL)
+	if ttl.IsZero() || !ttl.Before(expiringmap.NoExpiry) || capped.Before(ttl) {
+		return capped
+	}
+	return ttl
+}
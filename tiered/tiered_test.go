@@ -0,0 +1,118 @@
+package tiered
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+type fakeRedis struct {
+	mutex sync.Mutex
+	items map[string][]byte
+	ttls  map[string]time.Duration
+	gets  int
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{items: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (r *fakeRedis) Get(_ context.Context, key string) ([]byte, time.Duration, bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.gets += 1
+	value, ok := r.items[key]
+	return value, r.ttls[key], ok, nil
+}
+
+func (r *fakeRedis) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.items[key] = value
+	r.ttls[key] = ttl
+	return nil
+}
+
+func (r *fakeRedis) Del(_ context.Context, key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.items, key)
+	delete(r.ttls, key)
+	return nil
+}
+
+func TestGetFallsThrough(t *testing.T) {
+	redis := newFakeRedis()
+	redis.Set(context.Background(), "animal:elephant", []byte(`"big"`), time.Hour)
+
+	local := expiringmap.New[string, string]()
+	c := New[string, string](&local, NewRedisTier[string](redis, nil, "animal:"), time.Minute)
+
+	value, ok, err := c.Get(context.Background(), "elephant")
+	if err != nil || !ok || value != "big" {
+		t.Fatalf("expected remote hit, got %q %v %v", value, ok, err)
+	}
+	ttl, ok := local.TTL("elephant")
+	if !ok {
+		t.Fatal("expected entry to be populated locally.")
+	}
+	if time.Until(ttl) > time.Minute {
+		t.Error("local ttl should be capped.")
+	}
+
+	c.Get(context.Background(), "elephant")
+	if redis.gets != 1 {
+		t.Errorf("expected a single remote lookup, got %d.", redis.gets)
+	}
+}
+
+func TestNoExpiry(t *testing.T) {
+	redis := newFakeRedis()
+	local := expiringmap.New[string, string]()
+	c := New[string, string](&local, NewRedisTier[string](redis, nil, ""), time.Minute)
+
+	if err := c.Set(context.Background(), "tortoise", "old", expiringmap.NoExpiry); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := redis.ttls["tortoise"]; ttl != 0 {
+		t.Errorf("expected the key to be set without an expiry, got %v", ttl)
+	}
+	if ttl, _ := local.TTL("tortoise"); time.Until(ttl) > time.Minute {
+		t.Errorf("expected the local ttl to be capped, got %v", ttl)
+	}
+
+	local.Clear()
+	if _, ok, _ := c.Get(context.Background(), "tortoise"); !ok {
+		t.Fatal("expected a key without an expiry to be found.")
+	}
+	if ttl, ok := local.TTL("tortoise"); !ok || time.Until(ttl) > time.Minute {
+		t.Errorf("expected a key without an expiry to be copied locally with the cap, got %v %v", ttl, ok)
+	}
+}
+
+func TestSetDelete(t *testing.T) {
+	redis := newFakeRedis()
+	local := expiringmap.New[string, string]()
+	c := New[string, string](&local, NewRedisTier[string](redis, nil, ""), time.Minute)
+
+	ttl := time.Now().Add(10 * time.Second)
+	if err := c.Set(context.Background(), "monkey", "small", ttl); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := local.TTL("monkey"); !got.Equal(ttl) {
+		t.Error("local ttl shouldn't exceed the entry ttl.")
+	}
+	if string(redis.items["monkey"]) != `"small"` {
+		t.Error("expected value to be written to redis.")
+	}
+
+	if err := c.Delete(context.Background(), "monkey"); err != nil {
+		t.Fatal(err)
+	}
+	if local.Has("monkey") || len(redis.items) != 0 {
+		t.Error("expected value to be deleted from both tiers.")
+	}
+}
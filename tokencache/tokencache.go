@@ -0,0 +1,104 @@
+package tokencache
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/internal/singleflight"
+)
+
+const (
+	DefaultEarlyExpiry = 10 * time.Second
+	DefaultMaxTTL      = time.Hour
+)
+
+type Key struct {
+	ClientID string
+	Scope    string
+}
+
+// NewKey builds a Key with scopes sorted so the same set of scopes always
+// maps to the same cached token.
+func NewKey(clientID string, scopes ...string) Key {
+	scopes = append([]string(nil), scopes...)
+	sort.Strings(scopes)
+	return Key{ClientID: clientID, Scope: strings.Join(scopes, " ")}
+}
+
+type Token struct {
+	AccessToken string
+	TokenType   string
+	Expiry      time.Time
+}
+
+type TokenSource interface {
+	Token(ctx context.Context, key Key) (*Token, error)
+}
+
+type TokenSourceFunc func(ctx context.Context, key Key) (*Token, error)
+
+func (fn TokenSourceFunc) Token(ctx context.Context, key Key) (*Token, error) {
+	return fn(ctx, key)
+}
+
+// Cache hands out cached tokens until EarlyExpiry before they expire, then
+// fetches a new one from source. Concurrent refreshes for the same key share
+// a single call to source.
+type Cache struct {
+	EarlyExpiry time.Duration
+	// MaxTTL bounds how long tokens without an expiry are cached.
+	MaxTTL time.Duration
+	source TokenSource
+	cache  *expiringmap.ExpiringMap[Key, *Token]
+	group  singleflight.Group[Key, *Token]
+}
+
+func New(cache *expiringmap.ExpiringMap[Key, *Token], source TokenSource) *Cache {
+	return &Cache{
+		EarlyExpiry: DefaultEarlyExpiry,
+		MaxTTL:      DefaultMaxTTL,
+		source:      source,
+		cache:       cache,
+	}
+}
+
+func (c *Cache) Token(ctx context.Context, key Key) (*Token, error) {
+	if token, ok := c.cache.Get(key); ok {
+		return token, nil
+	}
+	token, err, _ := c.group.Do(key, func() (*Token, error) {
+		if token, ok := c.cache.Get(key); ok {
+			return token, nil
+		}
+		token, err := c.source.Token(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ttl := c.ttl(token); ttl.After(time.Now()) {
+			c.cache.Set(key, token, ttl)
+		}
+		return token, nil
+	})
+	return token, err
+}
+
+// Invalidate drops the cached token for key, for example after the resource
+// server rejected it.
+func (c *Cache) Invalidate(key Key) bool {
+	return c.cache.Delete(key)
+}
+
+func (c *Cache) ttl(token *Token) time.Time {
+	maxTTL := time.Now().Add(c.MaxTTL)
+	if token.Expiry.IsZero() {
+		return maxTTL
+	}
+	ttl := token.Expiry.Add(-c.EarlyExpiry)
+	if ttl.After(maxTTL) {
+		return maxTTL
+	}
+	return ttl
+}
@@ -0,0 +1,69 @@
+package tokencache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+)
+
+func TestToken(t *testing.T) {
+	var calls atomic.Int32
+	source := TokenSourceFunc(func(ctx context.Context, key Key) (*Token, error) {
+		n := calls.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		return &Token{AccessToken: key.ClientID + strconv.Itoa(int(n)), Expiry: time.Now().Add(time.Minute)}, nil
+	})
+	cache := expiringmap.New[Key, *Token]()
+	c := New(&cache, source)
+	key := NewKey("elephant", "write", "read")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := c.Token(context.Background(), key)
+			if err != nil || token.AccessToken != "elephant1" {
+				t.Errorf("unexpected token %v %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("expected a single refresh, got %d", calls.Load())
+	}
+	if key != NewKey("elephant", "read", "write") {
+		t.Error("scope order shouldn't matter.")
+	}
+
+	ttl, _ := cache.TTL(key)
+	if remaining := time.Until(ttl); remaining > time.Minute-DefaultEarlyExpiry {
+		t.Errorf("expected token to expire early, got %v", remaining)
+	}
+
+	c.Invalidate(key)
+	if token, _ := c.Token(context.Background(), key); token.AccessToken != "elephant2" {
+		t.Error("expected invalidated token to be refreshed.")
+	}
+}
+
+func TestTokenAlmostExpired(t *testing.T) {
+	var calls atomic.Int32
+	source := TokenSourceFunc(func(ctx context.Context, key Key) (*Token, error) {
+		calls.Add(1)
+		return &Token{AccessToken: "monkey", Expiry: time.Now().Add(time.Second)}, nil
+	})
+	cache := expiringmap.New[Key, *Token]()
+	c := New(&cache, source)
+
+	c.Token(context.Background(), NewKey("monkey"))
+	c.Token(context.Background(), NewKey("monkey"))
+	if calls.Load() != 2 {
+		t.Error("tokens within EarlyExpiry of their expiry shouldn't be cached.")
+	}
+}
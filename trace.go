@@ -0,0 +1,44 @@
+package expiringmap
+
+import "context"
+
+// Tracer starts spans, it is the part of an OpenTelemetry tracer the map
+// uses so that adapting one takes a few lines and the map no dependency.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SetAttribute(key string, value int)
+	RecordError(err error)
+	End()
+}
+
+// WithTracer starts spans around loader calls, snapshot writes and restores,
+// and replays of mutations. Each carries the number of keys involved as its
+// keys attribute.
+func WithTracer[K comparable, V any](tracer Tracer) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.tracer = tracer
+	}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, int) {}
+func (noopSpan) RecordError(error)        {}
+func (noopSpan) End()                     {}
+
+func (m *ExpiringMap[K, V]) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if m.config.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return m.config.tracer.Start(ctx, name)
+}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,69 @@
+package expiringmap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSpan struct {
+	name       string
+	attributes map[string]int
+	err        error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value int) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)              { s.err = err }
+func (s *testSpan) End()                               { s.ended = true }
+
+type testTracer struct {
+	mutex sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	span := &testSpan{name: name, attributes: make(map[string]int)}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &testTracer{}
+	m := New(WithTracer[string, int](tracer))
+	failure := errors.New("backend down")
+	m.GetOrLoad(context.Background(), "a", func(ctx context.Context, key string) (int, time.Time, error) {
+		return 0, time.Time{}, failure
+	})
+	m.GetManyOrLoad(context.Background(), []string{"b", "c"}, BatchLoaderFunc[string, int](func(ctx context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		return map[string]int{"b": 1, "c": 2}, map[string]time.Time{"b": time.Now().Add(time.Minute), "c": time.Now().Add(time.Minute)}, nil
+	}))
+	var buf bytes.Buffer
+	m.WriteSnapshot(&buf)
+	restored := New(WithTracer[string, int](tracer))
+	restored.ReadSnapshot(&buf)
+
+	expected := []struct {
+		name string
+		keys int
+		err  error
+	}{
+		{"expiringmap.load", 1, failure},
+		{"expiringmap.load_many", 2, nil},
+		{"expiringmap.write_snapshot", 2, nil},
+		{"expiringmap.replay", 2, nil},
+	}
+	if len(tracer.spans) != len(expected) {
+		t.Fatalf("expected %d spans, got %d", len(expected), len(tracer.spans))
+	}
+	for i, span := range tracer.spans {
+		if e := expected[i]; span.name != e.name || span.attributes["keys"] != e.keys || span.err != e.err || !span.ended {
+			t.Errorf("expected span %+v, got %+v", e, span)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package expiringmap
+
+// MapValues returns a new map holding fn of each live entry of m, keeping
+// their expiry and metadata. fn may call back into m.
+func MapValues[K comparable, V, U any](m *ExpiringMap[K, V], fn func(key K, value V) U, options ...Option[K, U]) *ExpiringMap[K, U] {
+	out := New(options...)
+	for _, e := range m.snapshot() {
+		out.SetEntry(Entry[K, U]{
+			Key:      e.Key,
+			Val:      fn(e.Key, e.Val),
+			TTL:      e.TTL,
+			Created:  e.Created,
+			Tags:     e.Tags,
+			Priority: e.Priority,
+		})
+	}
+	return &out
+}
+
+// Reduce folds fn over the live entries of m, one locked pass per shard, so
+// fn must not call back into m.
+func Reduce[K comparable, V, A any](m *ExpiringMap[K, V], initial A, fn func(acc A, key K, value V) A) A {
+	acc := initial
+	now := m.now()
+	m.eachShard(func(s *shard[K, V], owns keyFilter[K]) {
+		for key, item := range s.items {
+			if owns.skip(key) {
+				continue
+			}
+			if item.expired(now) {
+				m.expire(s, key, item)
+			} else {
+				acc = fn(acc, key, m.read(key, item))
+			}
+		}
+	}, nil)
+	return acc
+}
+
+// GroupBy buckets the live entries of m by fn's result, fn may call back into
+// m.
+func GroupBy[K comparable, V any, G comparable](m *ExpiringMap[K, V], fn func(key K, value V) G) map[G][]Entry[K, V] {
+	groups := make(map[G][]Entry[K, V])
+	for _, e := range m.snapshot() {
+		g := fn(e.Key, e.Val)
+		groups[g] = append(groups[g], e)
+	}
+	return groups
+}
@@ -0,0 +1,68 @@
+package expiringmap
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMapValues(t *testing.T) {
+	m := New[string, string]()
+	ttl := time.Now().Add(time.Minute)
+	m.Set("a", "1", ttl)
+	m.Set("b", "2", ttl)
+	m.Set("c", "3", time.Now().Add(-time.Second))
+
+	parsed := MapValues(&m, func(_ string, value string) int {
+		n, _ := strconv.Atoi(value)
+		return n
+	})
+	if parsed.Len() != 2 {
+		t.Errorf("expected the 2 live entries, got %d", parsed.Len())
+	}
+	if v, _ := parsed.Get("b"); v != 2 {
+		t.Errorf("expected 2, got %d", v)
+	}
+	if got, _ := parsed.TTL("a"); !got.Equal(ttl) {
+		t.Errorf("expected the ttl to be kept, got %v", got)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	m := New[string, int]()
+	for i := 1; i <= 4; i++ {
+		m.Set(strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	m.Set("stale", 100, time.Now().Add(-time.Second))
+
+	sum := Reduce(&m, 0, func(acc int, _ string, value int) int { return acc + value })
+	if sum != 10 {
+		t.Errorf("expected 10, got %d", sum)
+	}
+	longest := Reduce(&m, "", func(acc string, key string, _ int) string {
+		if len(key) > len(acc) {
+			return key
+		}
+		return acc
+	})
+	if len(longest) != 1 {
+		t.Errorf("expected expired keys to be skipped, got %q", longest)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	m := New[string, int]()
+	ttl := time.Now().Add(time.Minute)
+	for i := 0; i < 10; i++ {
+		m.Set(strconv.Itoa(i), i, ttl)
+	}
+	groups := GroupBy(&m, func(_ string, value int) bool { return value%2 == 0 })
+	if len(groups) != 2 || len(groups[true]) != 5 || len(groups[false]) != 5 {
+		t.Errorf("expected 2 groups of 5, got %v", groups)
+	}
+	for _, e := range groups[true] {
+		if e.Val%2 != 0 || !e.TTL.Equal(ttl) {
+			t.Errorf("unexpected entry %+v", e)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package ttlcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aicacia/go-expiringmap"
+	"github.com/aicacia/go-expiringmap/internal/singleflight"
+)
+
+const (
+	NoTTL      time.Duration = -1
+	DefaultTTL time.Duration = 0
+)
+
+type EvictionReason int
+
+const (
+	EvictionReasonDeleted EvictionReason = iota + 1
+	EvictionReasonCapacityReached
+	EvictionReasonExpired
+)
+
+type Item[K comparable, V any] struct {
+	mutex     sync.RWMutex
+	key       K
+	value     V
+	ttl       time.Duration
+	expiresAt time.Time
+}
+
+func newItem[K comparable, V any](key K, value V, ttl time.Duration) *Item[K, V] {
+	return &Item[K, V]{key: key, value: value, ttl: ttl}
+}
+
+// touch restarts the item's TTL and returns its new expiry for the map.
+func (item *Item[K, V]) touch() time.Time {
+	item.mutex.Lock()
+	defer item.mutex.Unlock()
+	if item.ttl <= 0 {
+		item.expiresAt = time.Time{}
+		return expiringmap.NoExpiry
+	}
+	item.expiresAt = time.Now().Add(item.ttl)
+	return item.expiresAt
+}
+
+func (item *Item[K, V]) Key() K {
+	return item.key
+}
+
+func (item *Item[K, V]) Value() V {
+	return item.value
+}
+
+func (item *Item[K, V]) TTL() time.Duration {
+	return item.ttl
+}
+
+// ExpiresAt is the zero time for items without a TTL.
+func (item *Item[K, V]) ExpiresAt() time.Time {
+	item.mutex.RLock()
+	defer item.mutex.RUnlock()
+	return item.expiresAt
+}
+
+func (item *Item[K, V]) IsExpired() bool {
+	expiresAt := item.ExpiresAt()
+	return !expiresAt.IsZero() && expiresAt.Before(time.Now())
+}
+
+type Loader[K comparable, V any] interface {
+	Load(c *Cache[K, V], key K) *Item[K, V]
+}
+
+type LoaderFunc[K comparable, V any] func(c *Cache[K, V], key K) *Item[K, V]
+
+func (l LoaderFunc[K, V]) Load(c *Cache[K, V], key K) *Item[K, V] {
+	return l(c, key)
+}
+
+// SuppressedLoader lets only one Load per key run at a time, callers arriving
+// meanwhile share its result.
+type SuppressedLoader[K comparable, V any] struct {
+	loader Loader[K, V]
+	group  singleflight.Group[K, *Item[K, V]]
+}
+
+func NewSuppressedLoader[K comparable, V any](loader Loader[K, V]) *SuppressedLoader[K, V] {
+	return &SuppressedLoader[K, V]{loader: loader}
+}
+
+func (l *SuppressedLoader[K, V]) Load(c *Cache[K, V], key K) *Item[K, V] {
+	item, _, _ := l.group.Do(key, func() (*Item[K, V], error) {
+		return l.loader.Load(c, key), nil
+	})
+	return item
+}
+
+type options[K comparable, V any] struct {
+	ttl               time.Duration
+	capacity          uint64
+	loader            Loader[K, V]
+	disableTouchOnHit bool
+}
+
+type Option[K comparable, V any] func(*options[K, V])
+
+func WithTTL[K comparable, V any](ttl time.Duration) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.ttl = ttl
+	}
+}
+
+func WithCapacity[K comparable, V any](capacity uint64) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.capacity = capacity
+	}
+}
+
+// WithLoader is used on misses, passed to Get it overrides the cache's.
+func WithLoader[K comparable, V any](loader Loader[K, V]) Option[K, V] {
+	return func(o *options[K, V]) {
+		o.loader = loader
+	}
+}
+
+// WithDisableTouchOnHit stops Get from extending the expiry of the items it
+// finds.
+func WithDisableTouchOnHit[K comparable, V any]() Option[K, V] {
+	return func(o *options[K, V]) {
+		o.disableTouchOnHit = true
+	}
+}
+
+// Cache has the API of github.com/jellydator/ttlcache/v3 backed by an
+// ExpiringMap.
+type Cache[K comparable, V any] struct {
+	options    options[K, V]
+	items      expiringmap.ExpiringMap[K, *Item[K, V]]
+	mutex      sync.Mutex
+	insertions map[int]func(context.Context, *Item[K, V])
+	evictions  map[int]func(context.Context, EvictionReason, *Item[K, V])
+	nextID     int
+	ctx        context.Context
+	wake       chan struct{}
+	stop       chan struct{}
+}
+
+func New[K comparable, V any](opts ...Option[K, V]) *Cache[K, V] {
+	c := &Cache[K, V]{
+		insertions: make(map[int]func(context.Context, *Item[K, V])),
+		evictions:  make(map[int]func(context.Context, EvictionReason, *Item[K, V])),
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		ctx:        context.Background(),
+	}
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+	mapOptions := []expiringmap.Option[K, *Item[K, V]]{
+		expiringmap.WithRemovalListener(c.removed),
+	}
+	if c.options.capacity > 0 {
+		mapOptions = append(mapOptions, expiringmap.WithMaxEntries[K, *Item[K, V]](int(c.options.capacity)))
+	}
+	c.items = expiringmap.New(mapOptions...)
+	return c
+}
+
+func (c *Cache[K, V]) removed(_ K, item *Item[K, V], reason expiringmap.RemovalReason) {
+	var evictionReason EvictionReason
+	switch reason {
+	case expiringmap.RemovalDeleted, expiringmap.RemovalCleared:
+		evictionReason = EvictionReasonDeleted
+	case expiringmap.RemovalEvicted:
+		evictionReason = EvictionReasonCapacityReached
+	case expiringmap.RemovalExpired:
+		evictionReason = EvictionReasonExpired
+	default:
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, fn := range c.evictions {
+		go fn(c.ctx, evictionReason, item)
+	}
+}
+
+// Set stores value for ttl, DefaultTTL uses the cache's TTL and NoTTL never
+// expires.
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) *Item[K, V] {
+	if ttl == DefaultTTL {
+		ttl = c.options.ttl
+	}
+	item := newItem(key, value, ttl)
+	if _, loaded := c.items.SwapWithTTL(key, item, item.touch()); !loaded {
+		c.inserted(item)
+	}
+	c.wakeUp()
+	return item
+}
+
+func (c *Cache[K, V]) inserted(item *Item[K, V]) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, fn := range c.insertions {
+		go fn(c.ctx, item)
+	}
+}
+
+// Get returns the item for key or nil, calling the loader on misses.
+func (c *Cache[K, V]) Get(key K, opts ...Option[K, V]) *Item[K, V] {
+	o := c.options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if item, ok := c.items.Get(key); ok {
+		if !o.disableTouchOnHit && item.ttl > 0 {
+			c.items.Expire(key, item.touch())
+		}
+		return item
+	}
+	if o.loader != nil {
+		return o.loader.Load(c, key)
+	}
+	return nil
+}
+
+// GetOrSet returns the existing item for key or sets value, retrieved
+// reports which.
+func (c *Cache[K, V]) GetOrSet(key K, value V, opts ...Option[K, V]) (*Item[K, V], bool) {
+	o := c.options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	item := newItem(key, value, o.ttl)
+	actual, retrieved := c.items.LoadOrStoreWithTTL(key, item, item.touch())
+	if !retrieved {
+		c.inserted(item)
+		c.wakeUp()
+	}
+	return actual, retrieved
+}
+
+func (c *Cache[K, V]) GetAndDelete(key K) (*Item[K, V], bool) {
+	return c.items.LoadAndDelete(key)
+}
+
+func (c *Cache[K, V]) Has(key K) bool {
+	return c.items.Has(key)
+}
+
+func (c *Cache[K, V]) Touch(key K) {
+	if item, ok := c.items.Get(key); ok && item.ttl > 0 {
+		c.items.Expire(key, item.touch())
+	}
+}
+
+func (c *Cache[K, V]) Delete(key K) {
+	c.items.Delete(key)
+}
+
+func (c *Cache[K, V]) DeleteAll() {
+	c.items.Clear()
+}
+
+func (c *Cache[K, V]) DeleteExpired() {
+	c.items.Len()
+}
+
+func (c *Cache[K, V]) Len() int {
+	return c.items.Len()
+}
+
+func (c *Cache[K, V]) Keys() []K {
+	var keys []K
+	c.items.Range(func(key K, _ *Item[K, V]) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (c *Cache[K, V]) Items() map[K]*Item[K, V] {
+	items := make(map[K]*Item[K, V])
+	c.items.Range(func(key K, item *Item[K, V]) bool {
+		items[key] = item
+		return true
+	})
+	return items
+}
+
+func (c *Cache[K, V]) Range(fn func(item *Item[K, V]) bool) {
+	c.items.Range(func(_ K, item *Item[K, V]) bool {
+		return fn(item)
+	})
+}
+
+// OnInsertion calls fn on its own goroutine for every new item, the returned
+// function unsubscribes it.
+func (c *Cache[K, V]) OnInsertion(fn func(context.Context, *Item[K, V])) func() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id := c.nextID
+	c.nextID++
+	c.insertions[id] = fn
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.insertions, id)
+	}
+}
+
+// OnEviction calls fn on its own goroutine for every item leaving the cache
+// other than by being replaced.
+func (c *Cache[K, V]) OnEviction(fn func(context.Context, EvictionReason, *Item[K, V])) func() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	id := c.nextID
+	c.nextID++
+	c.evictions[id] = fn
+	return func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		delete(c.evictions, id)
+	}
+}
+
+func (c *Cache[K, V]) wakeUp() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start deletes expired items as they expire until Stop is called, it blocks
+// so is usually run on its own goroutine.
+func (c *Cache[K, V]) Start() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		timer.Stop()
+		if next, ok := c.items.NextExpiry(); ok {
+			timer.Reset(time.Until(next))
+		} else {
+			timer.Reset(time.Hour)
+		}
+		select {
+		case <-timer.C:
+			c.DeleteExpired()
+		case <-c.wake:
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *Cache[K, V]) Stop() {
+	c.stop <- struct{}{}
+}
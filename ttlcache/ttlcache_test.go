@@ -0,0 +1,122 @@
+package ttlcache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c := New[string, int](WithTTL[string, int](20 * time.Millisecond))
+	c.Set("elephant", 1, DefaultTTL)
+	c.Set("tortoise", 2, NoTTL)
+
+	item := c.Get("elephant")
+	if item == nil || item.Key() != "elephant" || item.Value() != 1 || item.TTL() != 20*time.Millisecond {
+		t.Fatalf("unexpected item %+v", item)
+	}
+	if !c.Get("tortoise").ExpiresAt().IsZero() {
+		t.Error("items without a TTL shouldn't have an expiry.")
+	}
+	if c.Len() != 2 || len(c.Keys()) != 2 || len(c.Items()) != 2 {
+		t.Error("expected 2 items.")
+	}
+	if item, retrieved := c.GetOrSet("elephant", 3); !retrieved || item.Value() != 1 {
+		t.Error("expected the existing elephant.")
+	}
+	if item, ok := c.GetAndDelete("elephant"); !ok || item.Value() != 1 {
+		t.Error("expected to delete elephant.")
+	}
+	if c.Has("elephant") {
+		t.Error("elephant should be gone.")
+	}
+	c.DeleteAll()
+	if c.Len() != 0 {
+		t.Error("expected the cache to be empty.")
+	}
+}
+
+func TestTouchOnHit(t *testing.T) {
+	c := New[string, int](WithTTL[string, int](30 * time.Millisecond))
+	c.Set("elephant", 1, DefaultTTL)
+	c.Set("mouse", 1, DefaultTTL)
+	for i := 0; i < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+		c.Get("elephant")
+		c.Get("mouse", WithDisableTouchOnHit[string, int]())
+	}
+	if c.Get("elephant") == nil {
+		t.Error("hits should extend elephant's ttl.")
+	}
+	if c.Get("mouse", WithDisableTouchOnHit[string, int]()) != nil {
+		t.Error("mouse should have expired.")
+	}
+}
+
+func TestLoader(t *testing.T) {
+	var loads atomic.Int32
+	loader := NewSuppressedLoader[string, int](LoaderFunc[string, int](func(c *Cache[string, int], key string) *Item[string, int] {
+		loads.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return c.Set(key, len(key), DefaultTTL)
+	}))
+	c := New(WithLoader[string, int](loader))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if item := c.Get("elephant"); item == nil || item.Value() != 8 {
+				t.Error("expected the loaded item.")
+			}
+		}()
+	}
+	wg.Wait()
+	if loads.Load() != 1 {
+		t.Errorf("expected a single load, got %d", loads.Load())
+	}
+	if c.Get("mouse", WithLoader[string, int](LoaderFunc[string, int](func(*Cache[string, int], string) *Item[string, int] {
+		return nil
+	}))) != nil {
+		t.Error("expected the per-call loader to be used.")
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c := New[string, int](WithCapacity[string, int](1))
+	var mutex sync.Mutex
+	var inserted []string
+	reasons := map[string]EvictionReason{}
+	c.OnInsertion(func(_ context.Context, item *Item[string, int]) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		inserted = append(inserted, item.Key())
+	})
+	c.OnEviction(func(_ context.Context, reason EvictionReason, item *Item[string, int]) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		reasons[item.Key()] = reason
+	})
+	go c.Start()
+	defer c.Stop()
+
+	c.Set("elephant", 1, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	c.Set("mouse", 1, NoTTL)
+	c.Set("mouse", 2, NoTTL)
+	c.Set("tiger", 1, NoTTL)
+	c.Delete("tiger")
+	time.Sleep(10 * time.Millisecond)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(inserted) != 3 {
+		t.Errorf("expected 3 insertions, got %v", inserted)
+	}
+	if reasons["elephant"] != EvictionReasonExpired || reasons["mouse"] != EvictionReasonCapacityReached || reasons["tiger"] != EvictionReasonDeleted {
+		t.Errorf("unexpected eviction reasons %v", reasons)
+	}
+}
@@ -0,0 +1,94 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWarmBatchSize   = 100
+	defaultWarmParallelism = 4
+)
+
+var ErrNotLoaded = errors.New("expiringmap: key not returned by loader")
+
+// BatchLoader loads many keys in one call, returning each found value with
+// its expiry. It is used by Warm, Refresher and GetManyOrLoad so origins with
+// a multi-get are never called key by key.
+type BatchLoader[K comparable, V any] interface {
+	LoadMany(ctx context.Context, keys []K) (map[K]V, map[K]time.Time, error)
+}
+
+// BatchLoaderFunc adapts a function to BatchLoader.
+type BatchLoaderFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, map[K]time.Time, error)
+
+func (f BatchLoaderFunc[K, V]) LoadMany(ctx context.Context, keys []K) (map[K]V, map[K]time.Time, error) {
+	return f(ctx, keys)
+}
+
+// Warm loads keys in batches and stores the results, returning the error for
+// each key that couldn't be loaded. Keys the loader leaves out of its result
+// fail with ErrNotLoaded.
+func (m *ExpiringMap[K, V]) Warm(ctx context.Context, keys []K, loader BatchLoader[K, V]) map[K]error {
+	batchSize, parallelism := m.config.warmBatchSize, m.config.warmParallelism
+	if batchSize <= 0 {
+		batchSize = defaultWarmBatchSize
+	}
+	if parallelism <= 0 {
+		parallelism = defaultWarmParallelism
+	}
+
+	var (
+		mutex  sync.Mutex
+		errs   = make(map[K]error)
+		wg     sync.WaitGroup
+		tokens = make(chan struct{}, parallelism)
+	)
+	fail := func(batch []K, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, key := range batch {
+			errs[key] = err
+		}
+	}
+	for start := 0; start < len(keys); start += batchSize {
+		end := start + batchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch := keys[start:end]
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+			fail(keys[start:], ctx.Err())
+			wg.Wait()
+			return errs
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			values, ttls, err := m.loadMany(ctx, "expiringmap.warm", batch, loader)
+			if err != nil {
+				fail(batch, err)
+				return
+			}
+			var missing []K
+			for _, key := range batch {
+				value, ok := values[key]
+				if !ok {
+					missing = append(missing, key)
+					continue
+				}
+				m.setLoaded(key, value, ttls[key])
+			}
+			fail(missing, ErrNotLoaded)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
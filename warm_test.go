@@ -0,0 +1,94 @@
+package expiringmap
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarm(t *testing.T) {
+	m := New(WithWarmLimits[string, int](10, 2))
+	keys := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, strconv.Itoa(i))
+	}
+
+	var calls, running, peak atomic.Int32
+	errDown := errors.New("down")
+	errs := m.Warm(context.Background(), keys, BatchLoaderFunc[string, int](func(ctx context.Context, batch []string) (map[string]int, map[string]time.Time, error) {
+		calls.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if batch[0] == "10" {
+			return nil, nil, errDown
+		}
+		values := make(map[string]int)
+		ttls := make(map[string]time.Time)
+		for _, key := range batch {
+			if key == "99" {
+				continue
+			}
+			values[key], _ = strconv.Atoi(key)
+			ttls[key] = time.Now().Add(time.Minute)
+		}
+		return values, ttls, nil
+	}))
+
+	if calls.Load() != 10 {
+		t.Errorf("expected 10 batches, got %d", calls.Load())
+	}
+	if peak.Load() > 2 {
+		t.Errorf("expected at most 2 concurrent loads, got %d", peak.Load())
+	}
+	if len(errs) != 11 {
+		t.Errorf("expected 11 errors, got %d", len(errs))
+	}
+	if errs["15"] != errDown || errs["99"] != ErrNotLoaded {
+		t.Errorf("unexpected errors %v %v", errs["15"], errs["99"])
+	}
+	if m.Len() != 89 {
+		t.Errorf("expected 89 warmed keys, got %d", m.Len())
+	}
+	if value, ok := m.Get("42"); !ok || value != 42 {
+		t.Error("expected warmed value.")
+	}
+}
+
+func TestWarmCancelled(t *testing.T) {
+	m := New[string, int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := m.Warm(ctx, []string{"elephant"}, BatchLoaderFunc[string, int](func(context.Context, []string) (map[string]int, map[string]time.Time, error) {
+		return nil, nil, nil
+	}))
+	if !errors.Is(errs["elephant"], context.Canceled) && errs["elephant"] != ErrNotLoaded {
+		t.Errorf("unexpected error %v", errs["elephant"])
+	}
+}
+
+func TestWarmRetries(t *testing.T) {
+	m := New(WithRetry[string, int](RetryPolicy{Attempts: 3}))
+	calls := 0
+	errs := m.Warm(context.Background(), []string{"elephant"}, BatchLoaderFunc[string, int](func(_ context.Context, keys []string) (map[string]int, map[string]time.Time, error) {
+		if calls += 1; calls == 1 {
+			return nil, nil, errors.New("flaky")
+		}
+		return map[string]int{"elephant": 1}, map[string]time.Time{"elephant": time.Now().Add(time.Minute)}, nil
+	}))
+	if len(errs) != 0 || calls != 2 {
+		t.Errorf("expected the failed batch to be retried, got %v after %d calls", errs, calls)
+	}
+	if loads := m.Stats().Loads; loads != 1 {
+		t.Errorf("expected the batch to count as a load, got %d", loads)
+	}
+}
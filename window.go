@@ -0,0 +1,112 @@
+package expiringmap
+
+import "time"
+
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+type sample[N Number] struct {
+	val N
+	at  time.Time
+}
+
+// Window aggregates the samples added to each key over the last window.
+type Window[K comparable, N Number] struct {
+	m      ExpiringMap[K, []sample[N]]
+	window time.Duration
+}
+
+func NewWindow[K comparable, N Number](window time.Duration) *Window[K, N] {
+	return &Window[K, N]{
+		m:      New[K, []sample[N]](),
+		window: window,
+	}
+}
+
+// Add trims and appends to key's samples in place under its shard's lock,
+// like ExpiringCounter.Incr, so the samples a reader holds stay as they were.
+func (w *Window[K, N]) Add(key K, value N) {
+	w.m.compute(key, func(samples []sample[N], ok bool) ([]sample[N], time.Time, bool) {
+		now := time.Now()
+		return append(w.live(samples, now), sample[N]{value, now}), now.Add(w.window), true
+	})
+}
+
+func (w *Window[K, N]) Count(key K) int {
+	return len(w.samples(key))
+}
+
+func (w *Window[K, N]) Sum(key K) N {
+	var sum N
+	for _, s := range w.samples(key) {
+		sum += s.val
+	}
+	return sum
+}
+
+func (w *Window[K, N]) Avg(key K) float64 {
+	samples := w.samples(key)
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s.val)
+	}
+	return sum / float64(len(samples))
+}
+
+func (w *Window[K, N]) Min(key K) (N, bool) {
+	samples := w.samples(key)
+	if len(samples) == 0 {
+		return 0, false
+	}
+	min := samples[0].val
+	for _, s := range samples[1:] {
+		if s.val < min {
+			min = s.val
+		}
+	}
+	return min, true
+}
+
+func (w *Window[K, N]) Max(key K) (N, bool) {
+	samples := w.samples(key)
+	if len(samples) == 0 {
+		return 0, false
+	}
+	max := samples[0].val
+	for _, s := range samples[1:] {
+		if s.val > max {
+			max = s.val
+		}
+	}
+	return max, true
+}
+
+func (w *Window[K, N]) Reset(key K) bool {
+	return w.m.Delete(key)
+}
+
+// Len returns the number of keys with samples in the window.
+func (w *Window[K, N]) Len() int {
+	return w.m.Len()
+}
+
+func (w *Window[K, N]) samples(key K) []sample[N] {
+	samples, _ := w.m.Get(key)
+	return w.live(samples, time.Now())
+}
+
+func (w *Window[K, N]) live(samples []sample[N], now time.Time) []sample[N] {
+	cutoff := now.Add(-w.window)
+	for i, s := range samples {
+		if !s.at.Before(cutoff) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
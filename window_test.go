@@ -0,0 +1,71 @@
+package expiringmap
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	w := NewWindow[string, float64](30 * time.Millisecond)
+	w.Add("latency", 100)
+	time.Sleep(20 * time.Millisecond)
+	w.Add("latency", 20)
+	w.Add("latency", 30)
+
+	if w.Count("latency") != 3 || w.Sum("latency") != 150 || w.Avg("latency") != 50 {
+		t.Errorf("unexpected aggregate count=%d sum=%v", w.Count("latency"), w.Sum("latency"))
+	}
+	if max, ok := w.Max("latency"); !ok || max != 100 {
+		t.Errorf("expected max 100, got %v", max)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if w.Count("latency") != 2 || w.Sum("latency") != 50 {
+		t.Error("expected old samples to leave the window.")
+	}
+	if min, ok := w.Min("latency"); !ok || min != 20 {
+		t.Errorf("expected min 20, got %v", min)
+	}
+	if max, _ := w.Max("latency"); max != 30 {
+		t.Errorf("expected max 30, got %v", max)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := w.Min("latency"); ok {
+		t.Error("window should be empty.")
+	}
+	if w.Len() != 0 {
+		t.Error("keys should expire with their last sample.")
+	}
+}
+
+func TestWindowTrimWhileReading(t *testing.T) {
+	w := NewWindow[string, int](time.Millisecond)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				if w.Sum("elephant") < 0 {
+					t.Error("negative sum")
+				}
+			}
+		}
+	}()
+	deadline := time.Now().Add(20 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		w.Add("elephant", 1)
+	}
+	close(done)
+	wg.Wait()
+	w.Add("elephant", 1)
+	if w.Count("elephant") == 0 {
+		t.Error("expected the latest sample to be counted")
+	}
+}
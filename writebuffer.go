@@ -0,0 +1,149 @@
+package expiringmap
+
+import (
+	"sync"
+	"time"
+)
+
+type pendingWrite[K comparable, V any] struct {
+	key K
+	val V
+	ttl time.Time
+}
+
+// WriteBuffer collects Sets and applies them to the map in batches grouped by
+// shard, so each shard's lock is taken once per batch. Buffered writes are
+// not visible to readers until they are flushed, which happens once size
+// writes are pending, once maxDelay has passed since the first, or on Flush.
+type WriteBuffer[K comparable, V any] struct {
+	// Coalesce keeps only the last pending write for each key, it must be
+	// set before the first Set.
+	Coalesce bool
+
+	m        *ExpiringMap[K, V]
+	size     int
+	maxDelay time.Duration
+
+	// flushing keeps batches applied in the order they were taken
+	flushing sync.Mutex
+	mutex    sync.Mutex
+	pending  [][]pendingWrite[K, V]
+	indexes  []map[K]int
+	count    int
+	merged   uint64
+	timer    *time.Timer
+	closed   bool
+}
+
+func (m *ExpiringMap[K, V]) NewWriteBuffer(size int, maxDelay time.Duration) *WriteBuffer[K, V] {
+	return &WriteBuffer[K, V]{
+		m:        m,
+		size:     size,
+		maxDelay: maxDelay,
+		pending:  make([][]pendingWrite[K, V], m.Shards()),
+	}
+}
+
+// Set buffers a write, applying it straight away once the buffer is closed.
+func (b *WriteBuffer[K, V]) Set(key K, value V, ttl time.Time) {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		b.m.Set(key, value, ttl)
+		return
+	}
+	i := b.m.hash(key) % uint64(len(b.pending))
+	if b.Coalesce && b.coalesce(i, key, value, ttl) {
+		b.mutex.Unlock()
+		return
+	}
+	b.pending[i] = append(b.pending[i], pendingWrite[K, V]{key, value, ttl})
+	b.count += 1
+	full := b.size > 0 && b.count >= b.size
+	if !full && b.timer == nil && b.maxDelay > 0 {
+		b.timer = time.AfterFunc(b.maxDelay, b.Flush)
+	}
+	b.mutex.Unlock()
+	if full {
+		b.Flush()
+	}
+}
+
+func (b *WriteBuffer[K, V]) coalesce(i uint64, key K, value V, ttl time.Time) bool {
+	if b.indexes == nil {
+		b.indexes = make([]map[K]int, len(b.pending))
+	}
+	if b.indexes[i] == nil {
+		b.indexes[i] = make(map[K]int)
+	}
+	if j, ok := b.indexes[i][key]; ok {
+		b.pending[i][j] = pendingWrite[K, V]{key, value, ttl}
+		b.merged += 1
+		return true
+	}
+	b.indexes[i][key] = len(b.pending[i])
+	return false
+}
+
+// Coalesced returns the number of writes replaced by a later write to the
+// same key before being applied.
+func (b *WriteBuffer[K, V]) Coalesced() uint64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.merged
+}
+
+// Pending returns the number of writes waiting to be applied.
+func (b *WriteBuffer[K, V]) Pending() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.count
+}
+
+func (b *WriteBuffer[K, V]) Flush() {
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+	b.mutex.Lock()
+	pending := b.pending
+	b.pending = make([][]pendingWrite[K, V], len(pending))
+	b.indexes = nil
+	b.count = 0
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.mutex.Unlock()
+
+	for _, writes := range pending {
+		if len(writes) > 0 {
+			b.m.applyWrites(writes)
+		}
+	}
+}
+
+// Close flushes pending writes, later Sets go straight to the map.
+func (b *WriteBuffer[K, V]) Close() error {
+	b.mutex.Lock()
+	b.closed = true
+	b.mutex.Unlock()
+	b.Flush()
+	return nil
+}
+
+// applyWrites applies writes taking each shard's lock once for consecutive
+// writes to it, after a resize writes buffered together may span shards.
+func (m *ExpiringMap[K, V]) applyWrites(writes []pendingWrite[K, V]) {
+	s := m.lockShard(writes[0].key)
+	defer func() { m.unlock(s) }()
+	now := m.now()
+	for _, w := range writes {
+		if !s.owns(m.hash(w.key)) {
+			m.unlock(s)
+			s = m.lockShard(w.key)
+		}
+		if item, ok := s.items[w.key]; ok && item.expired(now) {
+			m.expire(s, w.key, item)
+		}
+		m.set(s, w.key, w.val, w.ttl)
+	}
+}
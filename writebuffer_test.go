@@ -0,0 +1,108 @@
+package expiringmap
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteBuffer(t *testing.T) {
+	m := New[string, int]()
+	b := m.NewWriteBuffer(10, time.Minute)
+
+	for i := 0; i < 5; i++ {
+		b.Set(strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	if m.Len() != 0 || b.Pending() != 5 {
+		t.Error("writes should be buffered.")
+	}
+	b.Flush()
+	if m.Len() != 5 || b.Pending() != 0 {
+		t.Errorf("expected 5 flushed writes, got %d", m.Len())
+	}
+
+	for i := 5; i < 15; i++ {
+		b.Set(strconv.Itoa(i), i, time.Now().Add(time.Minute))
+	}
+	if m.Len() != 15 {
+		t.Errorf("expected a full buffer to flush, got %d", m.Len())
+	}
+
+	b.Set("0", 100, time.Now().Add(time.Minute))
+	b.Close()
+	if value, _ := m.Get("0"); value != 100 {
+		t.Error("expected Close to flush.")
+	}
+	b.Set("elephant", 1, time.Now().Add(time.Minute))
+	if !m.Has("elephant") {
+		t.Error("writes after Close should be applied directly.")
+	}
+}
+
+func TestWriteBufferMaxDelay(t *testing.T) {
+	m := New[string, int]()
+	b := m.NewWriteBuffer(100, 10*time.Millisecond)
+	defer b.Close()
+
+	b.Set("elephant", 1, time.Now().Add(time.Minute))
+	time.Sleep(30 * time.Millisecond)
+	if !m.Has("elephant") {
+		t.Error("expected writes to be flushed after max delay.")
+	}
+}
+
+func TestWriteBufferOrder(t *testing.T) {
+	m := New[string, int]()
+	b := m.NewWriteBuffer(0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				b.Set(strconv.Itoa(i), j, time.Now().Add(time.Minute))
+			}
+		}(i)
+	}
+	wg.Wait()
+	b.Flush()
+	for i := 0; i < 4; i++ {
+		if value, _ := m.Get(strconv.Itoa(i)); value != 99 {
+			t.Errorf("expected the last write to win, got %d", value)
+		}
+	}
+}
+
+func TestWriteBufferCoalesce(t *testing.T) {
+	m := New[string, int]()
+	var sets int
+	m.Subscribe(func(mutation Mutation[string, int]) {
+		if mutation.Op == MutationSet {
+			sets += 1
+		}
+	})
+	b := m.NewWriteBuffer(10, time.Minute)
+	b.Coalesce = true
+
+	for i := 0; i < 100; i++ {
+		b.Set("elephant", i, time.Now().Add(time.Minute))
+		b.Set("monkey", i, time.Now().Add(time.Minute))
+	}
+	if b.Pending() != 2 || b.Coalesced() != 198 {
+		t.Errorf("expected 2 pending writes, got %d", b.Pending())
+	}
+	b.Flush()
+	if sets != 2 {
+		t.Errorf("expected 2 applied sets, got %d", sets)
+	}
+	if value, _ := m.Get("elephant"); value != 99 {
+		t.Errorf("expected the last value, got %d", value)
+	}
+
+	b.Set("elephant", 1, time.Now().Add(time.Minute))
+	b.Flush()
+	if value, _ := m.Get("elephant"); value != 1 {
+		t.Error("expected coalescing to restart after a flush.")
+	}
+}